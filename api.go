@@ -0,0 +1,15 @@
+package gotsw
+
+//go:generate go run ./internal/gen -spec swagger.json -out zz_generated.go
+
+// API exposes low-level bindings for every endpoint in the Teraswitch API
+// spec. The bindings are generated from swagger.json and return the raw API
+// envelope; prefer the hand-written methods on Client where they exist.
+type API struct {
+	c *Client
+}
+
+// API returns the low-level endpoint bindings for the client.
+func (c *Client) API() *API {
+	return &API{c: c}
+}
@@ -0,0 +1,279 @@
+package gotsw
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one mutating API call.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"` // from WithActor
+	Operation string    `json:"operation"`       // such as "CreateMetalService"
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	ProjectID int64     `json:"projectId,omitempty"`
+
+	// TargetID is the service or key acted on: the ID in the path, or
+	// for a creation the ID of the new resource. It is zero if unknown.
+	TargetID int64 `json:"targetId,omitempty"`
+
+	// Request is the request body as JSON with secrets, such as
+	// passwords and user data, replaced by "[REDACTED]".
+	Request json.RawMessage `json:"request,omitempty"`
+
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"statusCode,omitempty"` // zero if no response was received
+	Message    string `json:"message,omitempty"`    // the API's message, if any
+	Error      string `json:"error,omitempty"`      // the error returned to the caller, if any
+}
+
+// Auditor records mutating API calls.
+type Auditor interface {
+	Audit(ctx context.Context, rec AuditRecord) error
+}
+
+// SetAuditor sets the auditor which records every mutating call made
+// through the client: creations, reinstalls, power commands, renames,
+// deletions and so on. Calls are recorded after they complete, including
+// calls refused by middleware before being sent. Audit errors are logged
+// with the client's logger and do not fail the call.
+func (c *Client) SetAuditor(a Auditor) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auditor = a
+	return c
+}
+
+type actorKey struct{}
+
+// WithActor returns a context which attributes the calls made with it to
+// actor, such as a user name or service identity, in audit records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// readOnlyOperations are operations which do not use GET but change
+// nothing.
+var readOnlyOperations = map[string]bool{
+	"CalculatePrice": true,
+}
+
+func audited(op *Operation) bool {
+	return op.Method != http.MethodGet && op.Method != http.MethodHead && !readOnlyOperations[op.Name]
+}
+
+// audit records a completed operation with a.
+func (c *Client) audit(ctx context.Context, a Auditor, op *Operation, err error) {
+	rec := AuditRecord{
+		Time:       time.Now().UTC(),
+		Actor:      ActorFromContext(ctx),
+		Operation:  op.Name,
+		Method:     op.Method,
+		Path:       op.Path,
+		TargetID:   pathID(op.Path),
+		Request:    redactBody(op.Body),
+		StatusCode: op.StatusCode,
+	}
+	q := op.Query()
+	for _, key := range []string{"projectId", "ProjectId"} {
+		if id, err := strconv.ParseInt(q.Get(key), 10, 64); err == nil {
+			rec.ProjectID = id
+		}
+	}
+
+	var resp struct {
+		Success *bool           `json:"success"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if op.Result != nil {
+		if data, err := json.Marshal(op.Result); err == nil {
+			_ = json.Unmarshal(data, &resp)
+		}
+	}
+	rec.Message = resp.Message
+	if rec.TargetID == 0 && len(resp.Result) > 0 {
+		var created struct {
+			ID int64 `json:"id"`
+		}
+		if json.Unmarshal(resp.Result, &created) == nil {
+			rec.TargetID = created.ID
+		}
+	}
+
+	rec.Success = err == nil && op.StatusCode < 400 && (resp.Success == nil || *resp.Success)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if aerr := a.Audit(ctx, rec); aerr != nil {
+		c.Logger().ErrorContext(ctx, "gotsw: audit failed", "operation", op.Name, "error", aerr)
+	}
+}
+
+// pathID returns the first numeric segment of path, such as 1001 in
+// "Metal/1001/Reinstall", or 0.
+func pathID(path string) int64 {
+	for _, part := range strings.Split(path, "/") {
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			return id
+		}
+	}
+	return 0
+}
+
+// secretFields are request fields never written to audit records,
+// compared ignoring case.
+var secretFields = map[string]bool{
+	"password": true,
+	"userdata": true,
+	"secret":   true,
+	"token":    true,
+	"apikey":   true,
+}
+
+// redactBody returns body as JSON with secret fields redacted at any
+// depth.
+func redactBody(body any) json.RawMessage {
+	if body == nil {
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	var v any
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	data, err = json.Marshal(redact(v))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if secretFields[strings.ToLower(k)] {
+				if val != nil && val != "" {
+					v[k] = "[REDACTED]"
+				}
+				continue
+			}
+			v[k] = redact(val)
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return v
+}
+
+// JSONLinesAuditor writes audit records as JSON, one per line.
+type JSONLinesAuditor struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesAuditor returns an auditor which writes to w. Each record is
+// written with a single Write call.
+func NewJSONLinesAuditor(w io.Writer) *JSONLinesAuditor {
+	return &JSONLinesAuditor{w: w}
+}
+
+// OpenAuditLog returns an auditor which appends to the file at path,
+// creating it, readable only by its owner, if needed. Close the file with
+// Close.
+func OpenAuditLog(path string) (*JSONLinesAuditor, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesAuditor(f), nil
+}
+
+// Audit writes rec.
+func (a *JSONLinesAuditor) Audit(ctx context.Context, rec AuditRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (a *JSONLinesAuditor) Close() error {
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SlogAuditor logs audit records.
+type SlogAuditor struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlogAuditor returns an auditor which logs each record to logger at
+// info level, with the message "gotsw: audit" and the record's fields,
+// other than Time, as attributes.
+func NewSlogAuditor(logger *slog.Logger) *SlogAuditor {
+	return &SlogAuditor{logger: logger, level: slog.LevelInfo}
+}
+
+// Audit logs rec.
+func (a *SlogAuditor) Audit(ctx context.Context, rec AuditRecord) error {
+	attrs := []slog.Attr{
+		slog.String("operation", rec.Operation),
+		slog.String("method", rec.Method),
+		slog.String("path", rec.Path),
+		slog.Bool("success", rec.Success),
+	}
+	if rec.Actor != "" {
+		attrs = append(attrs, slog.String("actor", rec.Actor))
+	}
+	if rec.ProjectID != 0 {
+		attrs = append(attrs, slog.Int64("projectId", rec.ProjectID))
+	}
+	if rec.TargetID != 0 {
+		attrs = append(attrs, slog.Int64("targetId", rec.TargetID))
+	}
+	if len(rec.Request) > 0 {
+		attrs = append(attrs, slog.String("request", string(rec.Request)))
+	}
+	if rec.StatusCode != 0 {
+		attrs = append(attrs, slog.Int("statusCode", rec.StatusCode))
+	}
+	if rec.Message != "" {
+		attrs = append(attrs, slog.String("message", rec.Message))
+	}
+	if rec.Error != "" {
+		attrs = append(attrs, slog.String("error", rec.Error))
+	}
+	a.logger.LogAttrs(ctx, a.level, "gotsw: audit", attrs...)
+	return nil
+}
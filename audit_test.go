@@ -0,0 +1,228 @@
+package gotsw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	password := "hunter2"
+	tests := []struct {
+		name string
+		body any
+		want string
+		part bool // want is only part of the result
+	}{
+		{"nil", nil, "", false},
+		{"nil pointer", (*CreateBareMetalRequest)(nil), "", false},
+		{"unencodable", make(chan int), "", false},
+		{
+			name: "top level",
+			body: map[string]any{"displayName": "web-1", "password": "hunter2", "userData": "#cloud-config"},
+			want: `{"displayName":"web-1","password":"[REDACTED]","userData":"[REDACTED]"}`,
+		},
+		{
+			name: "case ignored",
+			body: map[string]any{"Password": "x", "APIKEY": "y", "Token": "z", "secretName": "kept"},
+			want: `{"APIKEY":"[REDACTED]","Password":"[REDACTED]","Token":"[REDACTED]","secretName":"kept"}`,
+		},
+		{
+			name: "nested",
+			body: map[string]any{"users": []any{map[string]any{"name": "root", "password": "x"}}, "webhook": map[string]any{"secret": "s"}},
+			want: `{"users":[{"name":"root","password":"[REDACTED]"}],"webhook":{"secret":"[REDACTED]"}}`,
+		},
+		{
+			name: "empty secrets kept",
+			body: map[string]any{"password": "", "token": nil},
+			want: `{"password":"","token":null}`,
+		},
+		{
+			name: "large numbers",
+			body: map[string]any{"id": int64(9007199254740993)},
+			want: `{"id":9007199254740993}`,
+		},
+		{
+			name: "struct",
+			body: &CreateBareMetalRequest{DisplayName: "web-1", TierID: "7302p", Password: &password},
+			want: `"password":"[REDACTED]"`,
+			part: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(redactBody(tt.body))
+			if strings.Contains(got, password) {
+				t.Errorf("redactBody() = %s, which contains the password", got)
+			}
+			if tt.part && !strings.Contains(got, tt.want) || !tt.part && got != tt.want {
+				t.Errorf("redactBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// auditRecords returns the records written by a JSONLinesAuditor.
+func auditRecords(t *testing.T, buf *bytes.Buffer) []AuditRecord {
+	t.Helper()
+	var recs []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestAudit(t *testing.T) {
+	password := "hunter2"
+	refuse := func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error { return errors.New("refused") }
+	}
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		mw     Middleware
+		call   func(ctx context.Context, c *Client) error
+		want   *AuditRecord // nil if the call is not audited
+	}{
+		{
+			name:   "create",
+			status: http.StatusOK,
+			body:   `{"success":true,"result":{"id":1001}}`,
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.CreateMetalService(ctx, 480, &CreateBareMetalRequest{DisplayName: "web-1", Password: &password})
+				return err
+			},
+			want: &AuditRecord{Actor: "alice", Operation: "CreateMetalService", Method: "POST", Path: "Metal", ProjectID: 480, TargetID: 1001, Success: true, StatusCode: 200},
+		},
+		{
+			name:   "read",
+			status: http.StatusOK,
+			body:   `{"success":true,"result":{"id":1001}}`,
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.GetMetalService(ctx, 1001)
+				return err
+			},
+		},
+		{
+			name:   "failed",
+			status: http.StatusBadRequest,
+			body:   `{"success":false,"message":"name taken"}`,
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.RenameMetalService(ctx, 1001, "web-2")
+				return err
+			},
+			want: &AuditRecord{Actor: "alice", Operation: "RenameMetalService", Method: "POST", TargetID: 1001, StatusCode: 400, Message: "name taken"},
+		},
+		{
+			name:   "refused by middleware",
+			status: http.StatusOK,
+			body:   `{"success":true}`,
+			mw:     refuse,
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.RenameMetalService(ctx, 1001, "web-2")
+				return err
+			},
+			want: &AuditRecord{Actor: "alice", Operation: "RenameMetalService", Method: "POST", TargetID: 1001, Error: "refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, requests := newTestClient(t, tt.status, tt.body)
+			var buf bytes.Buffer
+			c.SetAuditor(NewJSONLinesAuditor(&buf))
+			if tt.mw != nil {
+				c.Use(tt.mw)
+			}
+			tt.call(WithActor(context.Background(), "alice"), c)
+
+			recs := auditRecords(t, &buf)
+			if tt.want == nil {
+				if len(recs) != 0 {
+					t.Errorf("records = %+v, want none", recs)
+				}
+				return
+			}
+			if len(recs) != 1 {
+				t.Fatalf("records = %+v, want one", recs)
+			}
+			got := recs[0]
+			if got.Time.IsZero() {
+				t.Error("record has no time")
+			}
+			if strings.Contains(string(got.Request), password) {
+				t.Errorf("request %s contains the password", got.Request)
+			}
+			if tt.mw != nil && requests.Load() != 0 {
+				t.Errorf("%d requests sent, want none", requests.Load())
+			}
+			if got.Actor != tt.want.Actor || got.Operation != tt.want.Operation || got.Method != tt.want.Method ||
+				(tt.want.Path != "" && got.Path != tt.want.Path) || got.ProjectID != tt.want.ProjectID || got.TargetID != tt.want.TargetID ||
+				got.Success != tt.want.Success || got.StatusCode != tt.want.StatusCode || got.Message != tt.want.Message ||
+				!strings.Contains(got.Error, tt.want.Error) {
+				t.Errorf("record = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestSlogAuditor(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewSlogAuditor(slog.New(slog.NewJSONHandler(&buf, nil)))
+	err := a.Audit(context.Background(), AuditRecord{Operation: "DeleteMetalService", Method: "DELETE", Path: "Metal/7", TargetID: 7, Success: true, StatusCode: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["msg"] != "gotsw: audit" || got["level"] != "INFO" || got["operation"] != "DeleteMetalService" || got["targetId"] != 7.0 || got["success"] != true {
+		t.Errorf("log = %v", got)
+	}
+	for _, key := range []string{"actor", "projectId", "message", "error", "request"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("log has empty %s", key)
+		}
+	}
+}
+
+func TestOpenAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		a, err := OpenAuditLog(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Audit(context.Background(), AuditRecord{Operation: "RenameMetalService"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("log has %d lines, want 2 appended", n)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+}
@@ -0,0 +1,331 @@
+// Package budget refuses to provision servers beyond a monthly spend
+// ceiling.
+//
+// A Guard is client middleware. Before each metal service or instance is
+// created it adds up the project's committed monthly spend from the
+// prices of its metal services and instances, prices the request from the
+// tier list, and fails with ErrBudgetExceeded if the total would exceed
+// the project's limit:
+//
+//	guard := budget.NewGuard(client, budget.WithLimit(480, 5000))
+//	client.Use(guard.Middleware)
+//
+//	_, err := client.CreateMetalService(ctx, 480, &gotsw.CreateBareMetalRequest{Quantity: 10, ...})
+//	var exceeded *budget.ExceededError
+//	if errors.As(err, &exceeded) {
+//		log.Printf("would spend %.2f of %.2f", exceeded.Total(), exceeded.Limit)
+//	}
+//
+// The API does not publish instance prices, so instances are priced only
+// with WithInstancePrice, both when they are requested and when they are
+// counted in a project's spend. Instances of tiers without a price are
+// refused, and existing ones are not counted.
+//
+// Wrap a context with Override to create services regardless.
+//
+// Guarded creations are serialized, so concurrent requests cannot each
+// pass the check and together exceed the limit.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/pricing"
+)
+
+// HoursPerMonth converts hourly prices to monthly, for services without a
+// monthly price.
+const HoursPerMonth = 730
+
+// ErrBudgetExceeded is returned, wrapped in an *ExceededError, when a
+// request would take a project over its limit.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// ErrUnknownPrice is returned when a request cannot be priced, such as an
+// instance whose tier has no price configured with WithInstancePrice.
+var ErrUnknownPrice = errors.New("budget: cannot price request")
+
+// ExceededError describes a request refused by a Guard.
+type ExceededError struct {
+	Operation string
+	ProjectID int64
+	Limit     float64 // monthly
+	Current   float64 // monthly spend committed before the request
+	Requested float64 // monthly cost of the request
+}
+
+// Total returns the monthly spend if the request were allowed.
+func (e *ExceededError) Total() float64 { return e.Current + e.Requested }
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("budget: %s refused: project %d would spend %.2f a month (%.2f committed + %.2f requested), over its limit of %.2f",
+		e.Operation, e.ProjectID, e.Total(), e.Current, e.Requested, e.Limit)
+}
+
+// Unwrap returns ErrBudgetExceeded.
+func (e *ExceededError) Unwrap() error { return ErrBudgetExceeded }
+
+type overrideKey struct{}
+
+// Override returns a context in which guards allow every request.
+func Override(ctx context.Context) context.Context {
+	return context.WithValue(ctx, overrideKey{}, true)
+}
+
+func overridden(ctx context.Context) bool {
+	v, _ := ctx.Value(overrideKey{}).(bool)
+	return v
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithLimit sets the monthly spend limit of a project.
+func WithLimit(projectID int64, monthly float64) Option {
+	return func(g *Guard) {
+		g.limits[projectID] = monthly
+	}
+}
+
+// WithDefaultLimit sets the monthly spend limit of projects without one
+// of their own. Without it, such projects are not limited.
+func WithDefaultLimit(monthly float64) Option {
+	return func(g *Guard) {
+		g.defaultLimit = &monthly
+	}
+}
+
+// WithProjectID sets the project of requests which do not name one, such
+// as API().CreateMetal, which creates services in the API key's project.
+func WithProjectID(id int64) Option {
+	return func(g *Guard) {
+		g.projectID = id
+	}
+}
+
+// WithInstancePrice sets the monthly price of an instance tier. The API
+// does not publish instance prices, so instances of tiers without one are
+// refused with ErrUnknownPrice and left out of Spend.
+func WithInstancePrice(tierID string, monthly float64) Option {
+	return func(g *Guard) {
+		g.instancePrices[tierID] = monthly
+	}
+}
+
+// WithTierCacheTTL sets how long the metal tier list used to price
+// requests is reused. The default is an hour.
+func WithTierCacheTTL(d time.Duration) Option {
+	return func(g *Guard) {
+		g.tierTTL = d
+	}
+}
+
+// Guard checks provisioning requests against spend limits.
+type Guard struct {
+	client         *gotsw.Client
+	limits         map[int64]float64
+	defaultLimit   *float64
+	projectID      int64
+	instancePrices map[string]float64
+	tierTTL        time.Duration
+	now            func() time.Time
+
+	mu sync.Mutex // held across each guarded creation
+
+	tiersMu  sync.Mutex
+	tiers    []gotsw.MetalTier
+	tiersAge time.Time
+}
+
+// NewGuard returns a guard which looks up spend and prices with client.
+// It has no effect until its Middleware is added to a client, normally
+// the same one.
+func NewGuard(client *gotsw.Client, opts ...Option) *Guard {
+	g := &Guard{
+		client:         client,
+		limits:         map[int64]float64{},
+		instancePrices: map[string]float64{},
+		tierTTL:        time.Hour,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Middleware checks CreateMetalService, CreateMetal and CreateInstance
+// operations and passes every other operation through.
+func (g *Guard) Middleware(next gotsw.Handler) gotsw.Handler {
+	return func(ctx context.Context, op *gotsw.Operation) error {
+		switch op.Name {
+		case "CreateMetalService", "CreateMetal", "CreateInstance":
+		default:
+			return next(ctx, op)
+		}
+		if overridden(ctx) {
+			return next(ctx, op)
+		}
+
+		projectID, err := g.project(op)
+		if err != nil {
+			return err
+		}
+		limit, ok := g.limit(projectID)
+		if !ok {
+			return next(ctx, op)
+		}
+		requested, err := g.cost(ctx, op)
+		if err != nil {
+			return err
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		current, err := g.Spend(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("budget: %w", err)
+		}
+		if current+requested > limit {
+			return &ExceededError{
+				Operation: op.Name,
+				ProjectID: projectID,
+				Limit:     limit,
+				Current:   current,
+				Requested: requested,
+			}
+		}
+		return next(ctx, op)
+	}
+}
+
+func (g *Guard) limit(projectID int64) (float64, bool) {
+	if limit, ok := g.limits[projectID]; ok {
+		return limit, true
+	}
+	if g.defaultLimit != nil {
+		return *g.defaultLimit, true
+	}
+	return 0, false
+}
+
+// Spend returns the committed monthly spend of a project: the sum of the
+// monthly prices of its metal services and instances which are not
+// terminated. Instances are counted at their WithInstancePrice price, and
+// only those of tiers with one.
+func (g *Guard) Spend(ctx context.Context, projectID int64) (float64, error) {
+	services, err := g.client.ListAllMetal(ctx, gotsw.ListMetalOptions{ProjectID: projectID})
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, m := range services {
+		if m.Status == gotsw.StatusTerminated {
+			continue
+		}
+		if m.MonthlyPrice != nil {
+			total += *m.MonthlyPrice
+		} else {
+			total += m.HourlyPrice * HoursPerMonth
+		}
+	}
+	instances, err := g.instanceSpend(ctx, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return total + instances, nil
+}
+
+// instanceSpend returns the monthly spend of a project's instances. It
+// lists them only if some instance tier has a price.
+func (g *Guard) instanceSpend(ctx context.Context, projectID int64) (float64, error) {
+	if len(g.instancePrices) == 0 {
+		return 0, nil
+	}
+	params := gotsw.ListInstancesParams{ProjectID: int32(projectID), Limit: 100}
+	var total float64
+	for {
+		resp, err := g.client.API().ListInstances(ctx, params)
+		if err != nil {
+			return 0, err
+		}
+		if !resp.Success {
+			return 0, errors.New(resp.Message)
+		}
+		for _, in := range resp.Result {
+			if in.Status != gotsw.StatusTerminated {
+				total += g.instancePrices[in.TierID]
+			}
+		}
+
+		n := int32(len(resp.Result))
+		params.Skip += n
+		count := resp.Metadata.TotalCount
+		if n == 0 || (count > 0 && params.Skip >= count) || (count == 0 && n < params.Limit) {
+			return total, nil
+		}
+	}
+}
+
+// project returns the project a creation is in.
+func (g *Guard) project(op *gotsw.Operation) (int64, error) {
+	if body, ok := op.Body.(*gotsw.CreateInstanceRequest); ok && body.ProjectID != 0 {
+		return body.ProjectID, nil
+	}
+	if v := op.Query().Get("projectId"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("budget: invalid projectId %q", v)
+		}
+		return id, nil
+	}
+	return g.projectID, nil
+}
+
+// cost returns the monthly cost of a creation.
+func (g *Guard) cost(ctx context.Context, op *gotsw.Operation) (float64, error) {
+	switch body := op.Body.(type) {
+	case *gotsw.CreateBareMetalRequest:
+		tiers, err := g.metalTiers(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("budget: %w", err)
+		}
+		quote, err := pricing.Estimate(*body, tiers)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrUnknownPrice, err)
+		}
+		return quote.TotalMonthly(), nil
+	case *gotsw.CreateInstanceRequest:
+		monthly, ok := g.instancePrices[body.TierID]
+		if !ok {
+			return 0, fmt.Errorf("%w: no price for instance tier %q", ErrUnknownPrice, body.TierID)
+		}
+		return monthly, nil
+	}
+	return 0, fmt.Errorf("%w: unexpected %s body %T", ErrUnknownPrice, op.Name, op.Body)
+}
+
+// metalTiers returns the tier list, refreshing it when it is older than
+// the cache TTL.
+func (g *Guard) metalTiers(ctx context.Context) ([]gotsw.MetalTier, error) {
+	g.tiersMu.Lock()
+	defer g.tiersMu.Unlock()
+	if g.tiers != nil && g.now().Sub(g.tiersAge) < g.tierTTL {
+		return g.tiers, nil
+	}
+	resp, err := g.client.ListMetalTiers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	g.tiers, g.tiersAge = resp.Result, g.now()
+	return g.tiers, nil
+}
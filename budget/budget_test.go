@@ -0,0 +1,106 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestGuardMiddleware(t *testing.T) {
+	metal := func(quantity int) func(context.Context, *gotsw.Client, int64) error {
+		return func(ctx context.Context, c *gotsw.Client, projectID int64) error {
+			_, err := c.CreateMetalService(ctx, projectID, &gotsw.CreateBareMetalRequest{
+				RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12", Quantity: quantity,
+			})
+			return err
+		}
+	}
+	instance := func(tier string) func(context.Context, *gotsw.Client, int64) error {
+		return func(ctx context.Context, c *gotsw.Client, projectID int64) error {
+			_, err := c.API().CreateInstance(ctx, &gotsw.CreateInstanceRequest{
+				RegionID: "PIT1", TierID: tier, ImageID: "ubuntu-noble", ProjectID: projectID,
+			})
+			return err
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		unlimited bool     // no default limit
+		instances []string // tiers of existing instances
+		override  bool
+		create    func(context.Context, *gotsw.Client, int64) error
+		err       error
+		current   float64 // of the ExceededError
+		requested float64
+	}{
+		{name: "under the limit", create: metal(1)},
+		{name: "over the limit", create: metal(2), err: ErrBudgetExceeded, current: 349, requested: 698},
+		{name: "overridden", create: metal(2), override: true},
+		{name: "no limit", unlimited: true, opts: []Option{WithLimit(2, 1)}, create: metal(5)},
+		{name: "instance under the limit", opts: []Option{WithInstancePrice("c1.small", 100)}, create: instance("c1.small")},
+		{name: "instance over the limit", opts: []Option{WithInstancePrice("c1.large", 700)}, create: instance("c1.large"), err: ErrBudgetExceeded, current: 349, requested: 700},
+		{name: "instance without a price", create: instance("c1.small"), err: ErrUnknownPrice},
+		{
+			name:      "existing instances",
+			opts:      []Option{WithInstancePrice("c1.large", 300)},
+			instances: []string{"c1.large", "c1.large", "c1.small"},
+			create:    metal(1),
+			err:       ErrBudgetExceeded,
+			current:   949,
+			requested: 349,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := gotswtest.NewServer()
+			defer srv.Close()
+			ctx := context.Background()
+			projectID := srv.ProjectID()
+			price := 349.0
+			srv.AddMetal(gotsw.Metal{ProjectID: projectID, Status: gotsw.StatusActive, TierID: "7302p", MonthlyPrice: &price})
+			srv.AddMetal(gotsw.Metal{ProjectID: projectID, Status: gotsw.StatusTerminated, TierID: "l40s", HourlyPrice: 10})
+			for _, tier := range tt.instances {
+				if err := instance(tier)(ctx, srv.Client(), projectID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := tt.opts
+			if !tt.unlimited {
+				opts = append(opts, WithDefaultLimit(1000))
+			}
+			guard := NewGuard(srv.Client(), opts...)
+			client := srv.Client().Use(guard.Middleware)
+			if tt.override {
+				ctx = Override(ctx)
+			}
+			err := tt.create(ctx, client, projectID)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("create = %v, want %v", err, tt.err)
+			}
+			var exceeded *ExceededError
+			if errors.As(err, &exceeded) && (exceeded.Current != tt.current || exceeded.Requested != tt.requested || exceeded.Limit != 1000) {
+				t.Errorf("ExceededError = %+v, want %.2f committed and %.2f requested of 1000", exceeded, tt.current, tt.requested)
+			}
+		})
+	}
+}
+
+func TestGuardRefusedRequestIsNotSent(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.Client().Use(NewGuard(srv.Client(), WithLimit(srv.ProjectID(), 100)).Middleware)
+	_, err := client.CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12"})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("CreateMetalService() = %v, want ErrBudgetExceeded", err)
+	}
+	if n := srv.Stock("PIT1", "7302p"); n != 10 {
+		t.Errorf("stock = %d after a refused creation, want 10", n)
+	}
+}
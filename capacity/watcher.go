@@ -0,0 +1,414 @@
+// Package capacity watches metal availability and orders servers when
+// stock appears.
+//
+// A Watcher polls the API for a set of Wants, each a region and tier with
+// optional memory and disk requirements. It reports every change in the
+// available quantity as an Event and, for wants with an Order, creates
+// services as soon as capacity appears:
+//
+//	w := capacity.NewWatcher(client, projectID, []capacity.Want{{
+//		Region: "LAX1",
+//		Tier:   "7302p",
+//		Count:  2,
+//		Order: &gotsw.CreateBareMetalRequest{
+//			DisplayName: "db",
+//			ImageID:     "ubuntu-noble",
+//			SSHKeyIDs:   []int{42},
+//		},
+//	}}, capacity.WithMaxOrders(2), capacity.WithEventHandler(func(e capacity.Event) {
+//		log.Print(e)
+//	}))
+//	err := w.Run(ctx)
+package capacity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// DefaultInterval is how often a Watcher polls unless configured otherwise.
+const DefaultInterval = time.Minute
+
+// Want is a configuration to watch for.
+type Want struct {
+	Region string
+	Tier   string
+
+	// MemoryGB and Disks narrow the want to matching configurations
+	// from GetMetalAvailability. If both are empty, the tier's
+	// MaxQuantity in the region is watched instead.
+	MemoryGB int
+	Disks    map[string]string
+
+	// Order, if set, is the request used to create services when
+	// capacity appears. The watcher fills in its region, tier, memory,
+	// disks and quantity. Orders are only placed by a watcher with
+	// WithMaxOrders.
+	Order *gotsw.CreateBareMetalRequest
+
+	// Count is the number of services to order. It defaults to 1.
+	Count int
+}
+
+func (w *Want) String() string {
+	s := w.Region + "/" + w.Tier
+	if w.MemoryGB != 0 {
+		s += fmt.Sprintf("/%dGB", w.MemoryGB)
+	}
+	return s
+}
+
+func (w *Want) count() int {
+	if w.Count <= 0 {
+		return 1
+	}
+	return w.Count
+}
+
+// constrained reports whether the want needs per-configuration
+// availability rather than the tier's.
+func (w *Want) constrained() bool {
+	return w.MemoryGB != 0 || len(w.Disks) > 0
+}
+
+// matches reports whether c satisfies the want.
+func (w *Want) matches(c gotsw.MetalConfiguration) bool {
+	if c.Tier.ID != w.Tier {
+		return false
+	}
+	if w.MemoryGB != 0 && c.MemoryGB != w.MemoryGB {
+		return false
+	}
+	for slot, size := range w.Disks {
+		if c.Disks[slot] != size {
+			return false
+		}
+	}
+	return true
+}
+
+// EventKind is a kind of Event.
+type EventKind string
+
+const (
+	EventAvailability EventKind = "availability" // the available quantity changed
+	EventOrdered      EventKind = "ordered"      // services were ordered, or would be in dry-run mode
+	EventError        EventKind = "error"        // polling or ordering failed
+)
+
+// Event reports a change seen by a Watcher.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	Want *Want // nil for errors not specific to a want
+
+	// Previous and Quantity are the available quantity before and after
+	// an EventAvailability. Previous is -1 on the first poll.
+	Previous int
+	Quantity int
+
+	// Ordered is the number of services in an EventOrdered, and Metal
+	// the service the API returned. Metal is nil in dry-run mode.
+	Ordered int
+	Metal   *gotsw.Metal
+	DryRun  bool
+
+	Err error
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case EventAvailability:
+		if e.Previous < 0 {
+			return fmt.Sprintf("%s: %d available", e.Want, e.Quantity)
+		}
+		return fmt.Sprintf("%s: %d available (was %d)", e.Want, e.Quantity, e.Previous)
+	case EventOrdered:
+		if e.DryRun {
+			return fmt.Sprintf("%s: would order %d (dry run)", e.Want, e.Ordered)
+		}
+		return fmt.Sprintf("%s: ordered %d", e.Want, e.Ordered)
+	}
+	if e.Want != nil {
+		return fmt.Sprintf("%s: %v", e.Want, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// Option configures a Watcher.
+type Option func(*Watcher)
+
+// WithInterval sets how often the watcher polls.
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithMaxOrders caps the total number of services the watcher orders
+// across all wants. Without it, or with n <= 0, nothing is ordered.
+func WithMaxOrders(n int) Option {
+	return func(w *Watcher) {
+		w.maxOrders = n
+	}
+}
+
+// WithDryRun reports the orders the watcher would place without placing
+// them. Dry-run orders count towards the cap.
+func WithDryRun() Option {
+	return func(w *Watcher) {
+		w.dryRun = true
+	}
+}
+
+// WithEventHandler sets the function called with each event. It is called
+// from the goroutine running Run.
+func WithEventHandler(fn func(Event)) Option {
+	return func(w *Watcher) {
+		w.handler = fn
+	}
+}
+
+// WithClock sets the function used to timestamp events.
+func WithClock(now func() time.Time) Option {
+	return func(w *Watcher) {
+		w.now = now
+	}
+}
+
+// Watcher polls availability for a set of wants.
+type Watcher struct {
+	client    *gotsw.Client
+	projectID int64
+	wants     []Want
+
+	interval  time.Duration
+	maxOrders int
+	dryRun    bool
+	handler   func(Event)
+	now       func() time.Time
+
+	quantity map[int]int // by want index
+	ordered  map[int]int // by want index
+	total    int
+}
+
+// NewWatcher returns a Watcher for wants in the given project.
+func NewWatcher(client *gotsw.Client, projectID int64, wants []Want, opts ...Option) *Watcher {
+	w := &Watcher{
+		client:    client,
+		projectID: projectID,
+		wants:     wants,
+		interval:  DefaultInterval,
+		handler:   func(Event) {},
+		now:       time.Now,
+		quantity:  map[int]int{},
+		ordered:   map[int]int{},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Ordered returns the number of services ordered so far.
+func (w *Watcher) Ordered() int {
+	return w.total
+}
+
+// Run polls until ctx is done, returning ctx.Err(), or until every want
+// with an Order has been fulfilled or the order cap is reached, returning
+// nil. Errors while polling are reported as events and do not stop it.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.Poll(ctx)
+		if w.ordering() && w.done() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll checks availability once, reporting changes and placing orders.
+func (w *Watcher) Poll(ctx context.Context) {
+	available, err := w.fetch(ctx)
+	if err != nil {
+		w.emit(Event{Kind: EventError, Err: err})
+	}
+	for i := range w.wants {
+		want := &w.wants[i]
+		q, ok := available[i]
+		if !ok {
+			continue
+		}
+		prev, seen := w.quantity[i]
+		if !seen {
+			prev = -1
+		}
+		if q != prev {
+			w.quantity[i] = q
+			w.emit(Event{Kind: EventAvailability, Want: want, Previous: prev, Quantity: q})
+		}
+		if q > 0 {
+			n := w.order(ctx, i, q)
+			// Later wants for the same region and tier draw on the
+			// stock just ordered from.
+			for j := i + 1; j < len(w.wants) && n > 0; j++ {
+				other := &w.wants[j]
+				if _, ok := available[j]; ok && other.Region == want.Region && other.Tier == want.Tier {
+					available[j] = max(available[j]-n, 0)
+				}
+			}
+		}
+	}
+}
+
+// fetch returns the available quantity of each want, keyed by index.
+// Wants whose availability could not be fetched are missing, and the
+// failures are returned joined.
+func (w *Watcher) fetch(ctx context.Context) (map[int]int, error) {
+	available := map[int]int{}
+	var tiers []gotsw.MetalTier
+	configs := map[string][]gotsw.MetalConfiguration{}
+	failed := map[string]bool{} // by region, or "" for the tier list
+	var errs []error
+
+	for i := range w.wants {
+		want := &w.wants[i]
+		if !want.constrained() {
+			if tiers == nil && !failed[""] {
+				resp, err := w.client.ListMetalTiers(ctx, "")
+				if err != nil {
+					errs = append(errs, fmt.Errorf("list tiers: %w", err))
+					failed[""] = true
+					continue
+				}
+				tiers = resp.Result
+			}
+			if !failed[""] {
+				available[i] = tierQuantity(tiers, want)
+			}
+			continue
+		}
+
+		if _, ok := configs[want.Region]; !ok && !failed[want.Region] {
+			resp, err := w.client.GetMetalAvailability(ctx, w.projectID, want.Region)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("availability in %s: %w", want.Region, err))
+				failed[want.Region] = true
+				continue
+			}
+			configs[want.Region] = resp.Result
+		}
+		if failed[want.Region] {
+			continue
+		}
+		q := 0
+		for _, c := range configs[want.Region] {
+			if want.matches(c) && c.Quantity > q {
+				q = c.Quantity
+			}
+		}
+		available[i] = q
+	}
+	return available, errors.Join(errs...)
+}
+
+func tierQuantity(tiers []gotsw.MetalTier, want *Want) int {
+	for _, t := range tiers {
+		if t.ID != want.Tier {
+			continue
+		}
+		if a := t.Availability[want.Region]; a != nil {
+			return a.MaxQuantity
+		}
+	}
+	return 0
+}
+
+// order places an order for want i if it still needs services and the cap
+// allows, returning the number of services ordered.
+func (w *Watcher) order(ctx context.Context, i, available int) int {
+	want := &w.wants[i]
+	if want.Order == nil {
+		return 0
+	}
+	n := min(available, want.count()-w.ordered[i], w.maxOrders-w.total)
+	if n <= 0 {
+		return 0
+	}
+
+	if w.dryRun {
+		w.record(i, n)
+		w.emit(Event{Kind: EventOrdered, Want: want, Ordered: n, DryRun: true})
+		return n
+	}
+
+	req := *want.Order
+	req.RegionID = want.Region
+	req.TierID = want.Tier
+	req.Quantity = n
+	if want.MemoryGB != 0 {
+		req.MemoryGB = want.MemoryGB
+	}
+	if len(want.Disks) > 0 {
+		req.Disks = want.Disks
+	}
+	resp, err := w.client.CreateMetalService(ctx, w.projectID, &req)
+	if err == nil && !resp.Success {
+		err = errors.New(resp.Message)
+	}
+	if err != nil {
+		w.emit(Event{Kind: EventError, Want: want, Err: fmt.Errorf("order: %w", err)})
+		return 0
+	}
+	w.record(i, n)
+	w.emit(Event{Kind: EventOrdered, Want: want, Ordered: n, Metal: &resp.Result})
+	return n
+}
+
+func (w *Watcher) record(i, n int) {
+	w.ordered[i] += n
+	w.total += n
+}
+
+// ordering reports whether the watcher places orders at all.
+func (w *Watcher) ordering() bool {
+	if w.maxOrders <= 0 {
+		return false
+	}
+	for i := range w.wants {
+		if w.wants[i].Order != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// done reports whether no more orders can be placed.
+func (w *Watcher) done() bool {
+	if w.total >= w.maxOrders {
+		return true
+	}
+	for i := range w.wants {
+		if w.wants[i].Order != nil && w.ordered[i] < w.wants[i].count() {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Watcher) emit(e Event) {
+	e.Time = w.now()
+	w.handler(e)
+}
@@ -0,0 +1,169 @@
+package capacity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+var order = &gotsw.CreateBareMetalRequest{DisplayName: "gpu", ImageID: "debian-12", SSHKeyIDs: []int{1}}
+
+func TestPoll(t *testing.T) {
+	tests := []struct {
+		name      string
+		want      Want
+		opts      []Option
+		stock     []int // PIT1 l40s stock before each poll
+		events    []string
+		ordered   int
+		remaining int // stock left after the last poll
+	}{
+		{
+			name:      "availability changes",
+			want:      Want{Region: "PIT1", Tier: "l40s"},
+			stock:     []int{0, 0, 2, 1},
+			events:    []string{"PIT1/l40s: 0 available", "PIT1/l40s: 2 available (was 0)", "PIT1/l40s: 1 available (was 2)"},
+			remaining: 1,
+		},
+		{
+			name:      "constrained by memory",
+			want:      Want{Region: "PIT1", Tier: "l40s", MemoryGB: 384},
+			stock:     []int{0, 3},
+			events:    []string{"PIT1/l40s/384GB: 0 available", "PIT1/l40s/384GB: 3 available (was 0)"},
+			remaining: 3,
+		},
+		{
+			name:      "no matching configuration",
+			want:      Want{Region: "PIT1", Tier: "l40s", MemoryGB: 768},
+			stock:     []int{3},
+			events:    []string{"PIT1/l40s/768GB: 0 available"},
+			remaining: 3,
+		},
+		{
+			name:      "orders need a cap",
+			want:      Want{Region: "PIT1", Tier: "l40s", Order: order},
+			stock:     []int{3},
+			events:    []string{"PIT1/l40s: 3 available"},
+			remaining: 3,
+		},
+		{
+			name:      "order up to count",
+			want:      Want{Region: "PIT1", Tier: "l40s", Order: order, Count: 2},
+			opts:      []Option{WithMaxOrders(5)},
+			stock:     []int{0, 1, 3},
+			events:    []string{"PIT1/l40s: 0 available", "PIT1/l40s: 1 available (was 0)", "PIT1/l40s: ordered 1", "PIT1/l40s: 3 available (was 1)", "PIT1/l40s: ordered 1"},
+			ordered:   2,
+			remaining: 2,
+		},
+		{
+			name:      "order cap",
+			want:      Want{Region: "PIT1", Tier: "l40s", Order: order, Count: 5},
+			opts:      []Option{WithMaxOrders(2)},
+			stock:     []int{4},
+			events:    []string{"PIT1/l40s: 4 available", "PIT1/l40s: ordered 2"},
+			ordered:   2,
+			remaining: 2,
+		},
+		{
+			name:      "dry run",
+			want:      Want{Region: "PIT1", Tier: "l40s", Order: order},
+			opts:      []Option{WithMaxOrders(1), WithDryRun()},
+			stock:     []int{4},
+			events:    []string{"PIT1/l40s: 4 available", "PIT1/l40s: would order 1 (dry run)"},
+			ordered:   1,
+			remaining: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := gotswtest.NewServer()
+			defer srv.Close()
+			var events []string
+			opts := append([]Option{WithEventHandler(func(e Event) {
+				events = append(events, e.String())
+			})}, tt.opts...)
+			w := NewWatcher(srv.Client(), srv.ProjectID(), []Want{tt.want}, opts...)
+			for _, q := range tt.stock {
+				if q != srv.Stock("PIT1", "l40s") {
+					srv.SetStock("PIT1", "l40s", q)
+				}
+				w.Poll(context.Background())
+			}
+			if len(events) != len(tt.events) {
+				t.Fatalf("events = %q, want %q", events, tt.events)
+			}
+			for i := range events {
+				if events[i] != tt.events[i] {
+					t.Errorf("event %d = %q, want %q", i, events[i], tt.events[i])
+				}
+			}
+			if w.Ordered() != tt.ordered {
+				t.Errorf("Ordered() = %d, want %d", w.Ordered(), tt.ordered)
+			}
+			if got := srv.Stock("PIT1", "l40s"); got != tt.remaining {
+				t.Errorf("stock = %d, want %d", got, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestPollSharedStock(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	srv.SetStock("PIT1", "l40s", 3)
+	var events []string
+	wants := []Want{
+		{Region: "PIT1", Tier: "l40s", Order: order, Count: 2},
+		{Region: "PIT1", Tier: "l40s", MemoryGB: 384, Order: order, Count: 2},
+	}
+	w := NewWatcher(srv.Client(), srv.ProjectID(), wants, WithMaxOrders(10),
+		WithEventHandler(func(e Event) { events = append(events, e.String()) }))
+	w.Poll(context.Background())
+
+	want := []string{"PIT1/l40s: 3 available", "PIT1/l40s: ordered 2", "PIT1/l40s/384GB: 1 available", "PIT1/l40s/384GB: ordered 1"}
+	if strings.Join(events, "; ") != strings.Join(want, "; ") {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if w.Ordered() != 3 || srv.Stock("PIT1", "l40s") != 0 {
+		t.Errorf("Ordered() = %d with %d left, want 3 and 0", w.Ordered(), srv.Stock("PIT1", "l40s"))
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	srv.SetStock("PIT1", "l40s", 1)
+	w := NewWatcher(srv.Client(), srv.ProjectID(), []Want{{Region: "PIT1", Tier: "l40s", Order: order}},
+		WithMaxOrders(1), WithInterval(time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v, want nil once the order is placed", err)
+	}
+
+	// A watcher which never orders runs until its context is done.
+	w = NewWatcher(srv.Client(), srv.ProjectID(), []Want{{Region: "PIT1", Tier: "l40s"}}, WithInterval(time.Millisecond))
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPollErrors(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	var events []Event
+	w := NewWatcher(srv.Client(), srv.ProjectID(), []Want{{Region: "PIT1", Tier: "l40s", Order: order}},
+		WithMaxOrders(1), WithEventHandler(func(e Event) { events = append(events, e) }))
+	srv.SetStock("PIT1", "l40s", 1)
+	srv.Close()
+	w.Poll(context.Background())
+	if len(events) != 1 || events[0].Kind != EventError || events[0].Err == nil {
+		t.Errorf("events = %+v, want one error", events)
+	}
+}
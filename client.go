@@ -47,12 +47,14 @@ func New(auth string) *Client {
 // Client is an HTTP caller for methods to the Coder API.
 // @typescript-ignore Client
 type Client struct {
-	// mu protects the fields sessionToken, logger, and logBodies. These
-	// need to be safe for concurrent access.
+	// mu protects the fields sessionToken, logger, logBodies, middleware and
+	// auditor. These need to be safe for concurrent access.
 	mu            sync.RWMutex
 	authorization string
 	logger        *slog.Logger
 	logBodies     bool
+	middleware    []Middleware
+	auditor       Auditor
 
 	HTTPClient *http.Client
 	URL        *url.URL
@@ -0,0 +1,205 @@
+package cloudinit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigRender(t *testing.T) {
+	locked := true
+	c := &Config{
+		Hostname: "web-1",
+		Users: []User{
+			DefaultUser,
+			{Name: "deploy", Groups: []string{"sudo", "docker"}, LockPasswd: &locked},
+		},
+		Packages:   []string{"nginx"},
+		WriteFiles: []File{{Path: "/etc/motd", Content: "hi\n"}},
+		Extra:      map[string]any{"timezone": "UTC"},
+	}
+	got, err := c.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#cloud-config
+hostname: web-1
+users:
+  - default
+  - name: deploy
+    groups: [sudo, docker]
+    lock_passwd: true
+packages:
+  - nginx
+write_files:
+  - path: /etc/motd
+    content: |
+      hi
+timezone: UTC
+`
+	if string(got) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConfigRenderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"user without name", Config{Users: []User{{Shell: "/bin/sh"}}}, "user 0 has no name"},
+		{"file without path", Config{WriteFiles: []File{{Content: "x"}}}, "write_files entry 0 has no path"},
+		{"extra shadows field", Config{Extra: map[string]any{"packages": []string{"x"}}}, `module "packages" must be set through its Config field`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.Render()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Render() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserDataBuild(t *testing.T) {
+	config := &Config{Packages: []string{"nginx"}}
+	script := "#!/bin/sh\necho hi\n"
+	tests := []struct {
+		name    string
+		build   func() *UserData
+		check   func(t *testing.T, data string)
+		wantErr error
+		large   bool // too large for Validate
+	}{
+		{
+			name:  "single config",
+			build: func() *UserData { return New().AddConfig(config) },
+			check: func(t *testing.T, data string) {
+				if !strings.HasPrefix(data, Header+"\n") {
+					t.Errorf("data = %q, want a bare cloud-config", data)
+				}
+			},
+		},
+		{
+			name:  "multipart",
+			build: func() *UserData { return New().AddConfig(config).AddScript("setup.sh", script) },
+			check: func(t *testing.T, data string) {
+				for _, want := range []string{"multipart/mixed", ContentTypeCloudConfig, ContentTypeShellScript, `filename="setup.sh"`} {
+					if !strings.Contains(data, want) {
+						t.Errorf("data does not contain %q", want)
+					}
+				}
+			},
+		},
+		{
+			name:  "gzip",
+			build: func() *UserData { return New().AddConfig(config).Gzip(true) },
+			check: func(t *testing.T, data string) {
+				if !strings.HasPrefix(data, "H4sI") {
+					t.Errorf("data = %q, want base64 gzip", data)
+				}
+			},
+		},
+		{
+			name:    "too large",
+			build:   func() *UserData { return New().AddScript("big.sh", "#!/bin/sh\n"+strings.Repeat("#", DefaultMaxSize)) },
+			wantErr: ErrTooLarge,
+		},
+		{
+			name: "limit disabled",
+			build: func() *UserData {
+				return New().MaxSize(0).AddScript("big.sh", "#!/bin/sh\n"+strings.Repeat("#", DefaultMaxSize))
+			},
+			large: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.build().Build()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := Validate(data); err != nil && !tt.large {
+				t.Errorf("Validate(Build()) = %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, data)
+			}
+		})
+	}
+}
+
+func TestUserDataBuildErrors(t *testing.T) {
+	if _, err := New().Build(); err == nil {
+		t.Error("Build() with no parts succeeded")
+	}
+	if _, err := New().AddScript("x.sh", "echo hi").Build(); err == nil || !strings.Contains(err.Error(), "must start with #!") {
+		t.Errorf("Build() with a script without a shebang = %v", err)
+	}
+}
+
+func TestMultipartDeterministic(t *testing.T) {
+	parts := []Part{
+		{ContentType: ContentTypeShellScript, Filename: "a.sh", Content: []byte("#!/bin/sh\n")},
+		{ContentType: ContentTypeCloudConfig, Content: []byte("#cloud-config\n")},
+	}
+	a, err := Multipart(parts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Multipart(parts...)
+	if string(a) != string(b) {
+		t.Error("Multipart is not deterministic")
+	}
+	if _, err := Multipart(Part{Filename: "x"}); err == nil {
+		t.Error("Multipart of a part without a content type succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		userData string
+		want     string // substring of the error, "" if valid
+	}{
+		{"cloud-config", "#cloud-config\npackages: [nginx]\n", ""},
+		{"empty cloud-config", "#cloud-config\n", ""},
+		{"script", "#!/bin/bash\necho hi\n", ""},
+		{"boothook", "#cloud-boothook\necho hi\n", ""},
+		{"include", "#include\nhttps://example.com/a\n", ""},
+		{"invalid YAML", "#cloud-config\npackages: [nginx\n", "invalid cloud-config YAML"},
+		{"not a mapping", "#cloud-config\n- nginx\n", "must be a YAML mapping"},
+		{"unknown format", "hello\n", "unrecognised user data format"},
+		{"too large", "#!/bin/sh\n" + strings.Repeat("#", DefaultMaxSize), "too large"},
+		{
+			"multipart with bad part",
+			"Content-Type: multipart/mixed; boundary=\"b\"\r\nMIME-Version: 1.0\r\n\r\n" +
+				"--b\r\nContent-Type: text/x-shellscript\r\n\r\necho hi\r\n--b--\r\n",
+			"script does not start with #!",
+		},
+		{
+			"multipart with unsupported part",
+			"Content-Type: multipart/mixed; boundary=\"b\"\r\nMIME-Version: 1.0\r\n\r\n" +
+				"--b\r\nContent-Type: application/zip\r\n\r\nPK\r\n--b--\r\n",
+			`unsupported content type "application/zip"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.userData)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package cloudinit builds cloud-init user data for metal services.
+//
+// A Config renders a #cloud-config document from typed fields. Configs and
+// shell scripts can be combined into a MIME multipart archive with
+// UserData, which also compresses the result and checks it fits within
+// the size accepted by the API:
+//
+//	data, err := cloudinit.New().
+//		AddConfig(&cloudinit.Config{
+//			Packages: []string{"nginx"},
+//			WriteFiles: []cloudinit.File{
+//				{Path: "/etc/motd", Content: "Hello, world!\n"},
+//			},
+//		}).
+//		AddScript("setup.sh", "#!/bin/sh\nsystemctl enable --now nginx\n").
+//		Build()
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header is the first line of every #cloud-config document.
+const Header = "#cloud-config"
+
+// Config is a #cloud-config document. Modules without a field of their own
+// can be set through Extra.
+type Config struct {
+	Hostname          string   `yaml:"hostname,omitempty"`
+	Users             []User   `yaml:"users,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+	PackageUpdate     bool     `yaml:"package_update,omitempty"`
+	PackageUpgrade    bool     `yaml:"package_upgrade,omitempty"`
+	Packages          []string `yaml:"packages,omitempty"`
+	WriteFiles        []File   `yaml:"write_files,omitempty"`
+	RunCmd            []string `yaml:"runcmd,omitempty"`
+
+	// Extra holds additional top-level modules, keyed by module name.
+	Extra map[string]any `yaml:",inline"`
+}
+
+// DefaultUser is the distribution's default user. Include it in
+// Config.Users to keep it alongside any users being added.
+var DefaultUser = User{Name: "default"}
+
+// User is an entry in the users module.
+type User struct {
+	Name              string   `yaml:"name"`
+	Gecos             string   `yaml:"gecos,omitempty"`
+	Groups            []string `yaml:"groups,omitempty,flow"`
+	Shell             string   `yaml:"shell,omitempty"`
+	Sudo              string   `yaml:"sudo,omitempty"`
+	LockPasswd        *bool    `yaml:"lock_passwd,omitempty"`
+	PasswdHash        string   `yaml:"passwd,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+// MarshalYAML encodes DefaultUser as the bare "default" entry cloud-init
+// expects.
+func (u User) MarshalYAML() (any, error) {
+	if u.isDefault() {
+		return u.Name, nil
+	}
+	type user User
+	return user(u), nil
+}
+
+func (u User) isDefault() bool {
+	return u.Name == DefaultUser.Name && u.Gecos == "" && len(u.Groups) == 0 &&
+		u.Shell == "" && u.Sudo == "" && u.LockPasswd == nil && u.PasswdHash == "" &&
+		len(u.SSHAuthorizedKeys) == 0
+}
+
+// File is an entry in the write_files module.
+type File struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content,omitempty"`
+	Encoding    string `yaml:"encoding,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Append      bool   `yaml:"append,omitempty"`
+	Defer       bool   `yaml:"defer,omitempty"`
+}
+
+// Render returns the config as a #cloud-config document.
+func (c *Config) Render() ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(Header + "\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, fmt.Errorf("cloudinit: encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("cloudinit: encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// validate checks for mistakes which would produce a document cloud-init
+// silently ignores.
+func (c *Config) validate() error {
+	for i, u := range c.Users {
+		if u.Name == "" {
+			return fmt.Errorf("cloudinit: user %d has no name", i)
+		}
+	}
+	for i, f := range c.WriteFiles {
+		if f.Path == "" {
+			return fmt.Errorf("cloudinit: write_files entry %d has no path", i)
+		}
+	}
+	for name := range c.Extra {
+		switch name {
+		case "hostname", "users", "ssh_authorized_keys", "package_update",
+			"package_upgrade", "packages", "write_files", "runcmd":
+			return fmt.Errorf("cloudinit: module %q must be set through its Config field", name)
+		}
+	}
+	return nil
+}
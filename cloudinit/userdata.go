@@ -0,0 +1,199 @@
+package cloudinit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// Content types of user data parts.
+const (
+	ContentTypeCloudConfig = "text/cloud-config"
+	ContentTypeShellScript = "text/x-shellscript"
+	ContentTypeBoothook    = "text/cloud-boothook"
+)
+
+// DefaultMaxSize is the largest user data, after encoding, accepted by
+// Build unless changed with MaxSize. It matches the limit enforced by
+// common cloud-init datasources.
+const DefaultMaxSize = 16 * 1024
+
+// ErrTooLarge is returned when encoded user data exceeds the size limit.
+var ErrTooLarge = errors.New("cloudinit: user data too large")
+
+// Part is a single document in multipart user data.
+type Part struct {
+	ContentType string
+	Filename    string
+	Content     []byte
+}
+
+// ConfigPart renders c as a #cloud-config part.
+func ConfigPart(c *Config) (Part, error) {
+	content, err := c.Render()
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{ContentType: ContentTypeCloudConfig, Filename: "cloud-config.yaml", Content: content}, nil
+}
+
+// ScriptPart returns a shell script part. The script must start with a
+// shebang line.
+func ScriptPart(filename, script string) (Part, error) {
+	if !strings.HasPrefix(script, "#!") {
+		return Part{}, fmt.Errorf("cloudinit: script %s must start with #!", filename)
+	}
+	return Part{ContentType: ContentTypeShellScript, Filename: filename, Content: []byte(script)}, nil
+}
+
+// UserData assembles the user data for a create or reinstall request.
+type UserData struct {
+	parts   []Part
+	gzip    bool
+	maxSize int
+	err     error
+}
+
+// New returns an empty UserData limited to DefaultMaxSize.
+func New() *UserData {
+	return &UserData{maxSize: DefaultMaxSize}
+}
+
+// AddConfig adds c as a #cloud-config part.
+func (u *UserData) AddConfig(c *Config) *UserData {
+	p, err := ConfigPart(c)
+	if err != nil {
+		u.err = errors.Join(u.err, err)
+		return u
+	}
+	return u.AddPart(p)
+}
+
+// AddScript adds a shell script part.
+func (u *UserData) AddScript(filename, script string) *UserData {
+	p, err := ScriptPart(filename, script)
+	if err != nil {
+		u.err = errors.Join(u.err, err)
+		return u
+	}
+	return u.AddPart(p)
+}
+
+// AddPart adds an arbitrary part.
+func (u *UserData) AddPart(p Part) *UserData {
+	u.parts = append(u.parts, p)
+	return u
+}
+
+// Gzip sets whether the user data is gzip compressed and base64 encoded,
+// which cloud-init detects and reverses before processing.
+func (u *UserData) Gzip(enabled bool) *UserData {
+	u.gzip = enabled
+	return u
+}
+
+// MaxSize sets the largest size of the encoded user data. Zero disables
+// the limit.
+func (u *UserData) MaxSize(n int) *UserData {
+	u.maxSize = n
+	return u
+}
+
+// Build returns the encoded user data. A single part is used as is; more
+// are combined into a MIME multipart archive.
+func (u *UserData) Build() (string, error) {
+	if u.err != nil {
+		return "", u.err
+	}
+
+	var data []byte
+	switch len(u.parts) {
+	case 0:
+		return "", errors.New("cloudinit: no parts")
+	case 1:
+		data = u.parts[0].Content
+	default:
+		var err error
+		if data, err = Multipart(u.parts...); err != nil {
+			return "", err
+		}
+	}
+
+	if u.gzip {
+		var err error
+		if data, err = gzipBase64(data); err != nil {
+			return "", err
+		}
+	}
+
+	if u.maxSize > 0 && len(data) > u.maxSize {
+		return "", fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrTooLarge, len(data), u.maxSize)
+	}
+	return string(data), nil
+}
+
+// Multipart combines parts into a MIME multipart archive. The boundary is
+// derived from the content, so the same parts always produce the same
+// archive.
+func Multipart(parts ...Part) ([]byte, error) {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p.Content)
+	}
+	boundary := "==" + hex.EncodeToString(h.Sum(nil))[:32] + "=="
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	for _, p := range parts {
+		if p.ContentType == "" {
+			return nil, fmt.Errorf("cloudinit: part %s has no content type", p.Filename)
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.ContentType+`; charset="utf-8"`)
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Transfer-Encoding", "8bit")
+		if p.Filename != "" {
+			header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", p.Filename))
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(p.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=%q\r\n", boundary)
+	out.WriteString("MIME-Version: 1.0\r\n\r\n")
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+func gzipBase64(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	out := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(out, buf.Bytes())
+	return out, nil
+}
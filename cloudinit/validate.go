@@ -0,0 +1,151 @@
+package cloudinit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate checks user data before it is submitted, whether built by this
+// package or written by hand. It accepts #cloud-config documents, scripts,
+// boothooks and MIME multipart archives of them, optionally gzip compressed
+// and base64 encoded, no larger than DefaultMaxSize.
+func Validate(userData string) error {
+	if len(userData) > DefaultMaxSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrTooLarge, len(userData), DefaultMaxSize)
+	}
+	data, err := decode([]byte(userData))
+	if err != nil {
+		return err
+	}
+	return validatePart("", data)
+}
+
+// decode reverses gzip compression and base64 encoding of user data.
+func decode(data []byte) ([]byte, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err == nil && isGzip(decoded) {
+		data = decoded
+	}
+	if !isGzip(data) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cloudinit: decompress user data: %w", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("cloudinit: decompress user data: %w", err)
+	}
+	return out, nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// validatePart validates a single document. contentType is empty if the
+// type should be detected from the document's first line.
+func validatePart(contentType string, data []byte) error {
+	if contentType == "" {
+		contentType = detect(data)
+	}
+	switch contentType {
+	case ContentTypeCloudConfig:
+		return validateConfig(data)
+	case ContentTypeShellScript:
+		if !bytes.HasPrefix(data, []byte("#!")) {
+			return errors.New("cloudinit: script does not start with #!")
+		}
+		return nil
+	case ContentTypeBoothook, "text/x-include-url", "text/jinja2":
+		return nil
+	case "multipart/mixed":
+		return validateMultipart(data)
+	case "":
+		return errors.New("cloudinit: unrecognised user data format")
+	default:
+		return fmt.Errorf("cloudinit: unsupported content type %q", contentType)
+	}
+}
+
+// detect returns the content type of a document from its first line.
+func detect(data []byte) string {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	line = bytes.TrimSpace(line)
+	switch {
+	case bytes.Equal(line, []byte(Header)):
+		return ContentTypeCloudConfig
+	case bytes.HasPrefix(line, []byte("#!")):
+		return ContentTypeShellScript
+	case bytes.HasPrefix(line, []byte("#cloud-boothook")):
+		return ContentTypeBoothook
+	case bytes.HasPrefix(line, []byte("#include")):
+		return "text/x-include-url"
+	case bytes.HasPrefix(line, []byte("## template: jinja")):
+		return "text/jinja2"
+	case bytes.HasPrefix(bytes.ToLower(line), []byte("content-type: multipart/")):
+		return "multipart/mixed"
+	}
+	return ""
+}
+
+// validateConfig checks a #cloud-config document is a YAML mapping.
+func validateConfig(data []byte) error {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("cloudinit: invalid cloud-config YAML: %w", err)
+	}
+	if doc == nil {
+		return nil
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return errors.New("cloudinit: cloud-config must be a YAML mapping")
+	}
+	return nil
+}
+
+// validateMultipart validates every part of a MIME multipart archive.
+func validateMultipart(data []byte) error {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return fmt.Errorf("cloudinit: parse multipart: %w", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("cloudinit: parse multipart: %w", err)
+	}
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for i := 0; ; i++ {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cloudinit: parse multipart: %w", err)
+		}
+		content, err := io.ReadAll(p)
+		if err != nil {
+			return fmt.Errorf("cloudinit: read part %d: %w", i, err)
+		}
+		mediaType, _, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		if err != nil {
+			return fmt.Errorf("cloudinit: part %d: %w", i, err)
+		}
+		if err := validatePart(mediaType, content); err != nil {
+			return fmt.Errorf("part %d (%s): %w", i, p.FileName(), err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// exporter collects inventory and capacity metrics in the background and
+// serves the last successful collection, so scrapes never wait on the API.
+type exporter struct {
+	client    *gotsw.Client
+	projectID int64
+	regions   []string // regions to check availability in; all if empty
+	logger    *slog.Logger
+
+	mu        sync.Mutex
+	inventory []byte // metrics from the last successful collection
+
+	apiRequests *metric
+	apiErrors   *metric
+	apiDuration *metric
+
+	scrapes        *metric
+	scrapeErrors   *metric
+	scrapeDuration *metric
+	scrapeSuccess  *metric
+	lastSuccess    *metric
+}
+
+func newExporter(client *gotsw.Client, projectID int64, regions []string, logger *slog.Logger) *exporter {
+	e := &exporter{
+		client:    client,
+		projectID: projectID,
+		regions:   regions,
+		logger:    logger,
+
+		apiRequests: newMetric("tsw_api_requests_total", "counter", "API requests made, by operation."),
+		apiErrors:   newMetric("tsw_api_errors_total", "counter", "API requests which failed, by operation."),
+		apiDuration: newMetric("tsw_api_request_duration_seconds", "summary", "Latency of API requests, by operation."),
+
+		scrapes:        newMetric("tsw_scrapes_total", "counter", "Collections of inventory and capacity from the API."),
+		scrapeErrors:   newMetric("tsw_scrape_errors_total", "counter", "Collections which failed."),
+		scrapeDuration: newMetric("tsw_scrape_duration_seconds", "gauge", "Duration of the last collection."),
+		scrapeSuccess:  newMetric("tsw_scrape_success", "gauge", "Whether the last collection succeeded."),
+		lastSuccess:    newMetric("tsw_scrape_last_success_timestamp_seconds", "gauge", "Unix time of the last successful collection."),
+	}
+	client.Use(e.instrument)
+	return e
+}
+
+// instrument is middleware which counts API requests and their latency.
+func (e *exporter) instrument(next gotsw.Handler) gotsw.Handler {
+	return func(ctx context.Context, op *gotsw.Operation) error {
+		start := time.Now()
+		err := next(ctx, op)
+		elapsed := time.Since(start).Seconds()
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.apiRequests.add(1, "operation", op.Name)
+		failed := 0.0
+		if err != nil || op.StatusCode >= 400 {
+			failed = 1
+		}
+		e.apiErrors.add(failed, "operation", op.Name)
+		e.apiDuration.get("_sum", "operation", op.Name).value += elapsed
+		e.apiDuration.get("_count", "operation", op.Name).value++
+		return err
+	}
+}
+
+// run collects every interval until ctx is done.
+func (e *exporter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.scrape(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape runs one collection and records its outcome.
+func (e *exporter) scrape(ctx context.Context) {
+	start := time.Now()
+	inventory, err := e.collect(ctx)
+	elapsed := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scrapes.add(1)
+	e.scrapeErrors.add(0)
+	e.scrapeDuration.set(elapsed.Seconds())
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.WarnContext(ctx, "collection failed", "error", err)
+		}
+		e.scrapeErrors.add(1)
+		e.scrapeSuccess.set(0)
+		return
+	}
+	e.inventory = inventory
+	e.scrapeSuccess.set(1)
+	e.lastSuccess.set(float64(start.Unix()))
+}
+
+// collect lists metal services, tiers and availability and returns the
+// resulting metrics in the exposition format.
+func (e *exporter) collect(ctx context.Context) ([]byte, error) {
+	services, err := e.client.ListAllMetal(ctx, gotsw.ListMetalOptions{ProjectID: e.projectID})
+	if err != nil {
+		return nil, fmt.Errorf("list metal services: %w", err)
+	}
+	tiers, err := e.client.ListMetalTiers(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list metal tiers: %w", err)
+	}
+	if !tiers.Success {
+		return nil, fmt.Errorf("list metal tiers: %s", tiers.Message)
+	}
+
+	servers := newMetric("tsw_metal_servers", "gauge", "Metal services, by status, power state, region and tier.")
+	hourly := newMetric("tsw_metal_hourly_spend_dollars", "gauge", "Committed hourly spend on metal services, excluding terminated ones, by region and tier.")
+	monthly := newMetric("tsw_metal_monthly_spend_dollars", "gauge", "Committed monthly spend on metal services with a monthly price, excluding terminated ones, by region and tier.")
+	for _, m := range services {
+		servers.add(1, "status", string(m.Status), "power_state", string(m.PowerState), "region", m.RegionID, "tier", m.TierID)
+		// Terminated services are no longer billed, as in budget.Guard.
+		if m.Status == gotsw.StatusTerminated {
+			continue
+		}
+		hourly.add(m.HourlyPrice, "region", m.RegionID, "tier", m.TierID)
+		if m.MonthlyPrice != nil {
+			monthly.add(*m.MonthlyPrice, "region", m.RegionID, "tier", m.TierID)
+		}
+	}
+
+	maxQuantity := newMetric("tsw_metal_tier_max_quantity", "gauge", "Servers of each tier which can be ordered at once, by region.")
+	hourlyPrice := newMetric("tsw_metal_tier_hourly_price_dollars", "gauge", "Hourly list price of each tier.")
+	regions := map[string]bool{}
+	for _, t := range tiers.Result {
+		hourlyPrice.set(t.HourlyPrice, "tier", t.ID)
+		for region, avail := range t.Availability {
+			regions[region] = true
+			if avail != nil {
+				maxQuantity.set(float64(avail.MaxQuantity), "region", region, "tier", t.ID)
+			}
+		}
+	}
+
+	checked := e.regions
+	if len(checked) == 0 {
+		for region := range regions {
+			checked = append(checked, region)
+		}
+		sort.Strings(checked)
+	}
+	available := newMetric("tsw_metal_available", "gauge", "Servers available to order, by region, tier and memory.")
+	for _, region := range checked {
+		configs, err := e.client.GetMetalAvailability(ctx, e.projectID, region)
+		if err != nil {
+			return nil, fmt.Errorf("get availability in %s: %w", region, err)
+		}
+		// Configurations differ by disks too, but draw on the same
+		// machines, so report the largest quantity rather than the sum.
+		for _, c := range configs.Result {
+			s := available.get("", "region", region, "tier", c.Tier.ID, "memory_gb", strconv.Itoa(c.MemoryGB))
+			s.value = max(s.value, float64(c.Quantity))
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, m := range []*metric{servers, hourly, monthly, maxQuantity, hourlyPrice, available} {
+		if err := m.write(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// write writes the last collection and the exporter's own metrics.
+func (e *exporter) write(w io.Writer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := w.Write(e.inventory); err != nil {
+		return err
+	}
+	for _, m := range []*metric{
+		e.apiRequests, e.apiErrors, e.apiDuration,
+		e.scrapes, e.scrapeErrors, e.scrapeDuration, e.scrapeSuccess, e.lastSuccess,
+	} {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
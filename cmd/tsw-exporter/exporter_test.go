@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestCollect(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	price := func(v float64) *float64 { return &v }
+	services := []gotsw.Metal{
+		{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, RegionID: "PIT1", TierID: "7302p", HourlyPrice: 0.5, MonthlyPrice: price(349)},
+		{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOff, RegionID: "PIT1", TierID: "7302p", HourlyPrice: 0.25},
+		{Status: gotsw.StatusTerminated, PowerState: gotsw.PowerStateOff, RegionID: "PIT1", TierID: "7302p", HourlyPrice: 10, MonthlyPrice: price(5000)},
+		{Status: gotsw.StatusTerminated, PowerState: gotsw.PowerStateOff, RegionID: "LAX1", TierID: "2388g", HourlyPrice: 10, MonthlyPrice: price(5000)},
+	}
+	for _, m := range services {
+		srv.AddMetal(m)
+	}
+
+	e := newExporter(srv.Client(), srv.ProjectID(), []string{"PIT1"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	e.scrape(context.Background())
+	var buf bytes.Buffer
+	if err := e.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`tsw_metal_servers{status="Active",power_state="On",region="PIT1",tier="7302p"} 1`, true},
+		{`tsw_metal_servers{status="Terminated",power_state="Off",region="PIT1",tier="7302p"} 1`, true},
+		{`tsw_metal_hourly_spend_dollars{region="PIT1",tier="7302p"} 0.75`, true},
+		{`tsw_metal_monthly_spend_dollars{region="PIT1",tier="7302p"} 349`, true},
+		{`tsw_metal_hourly_spend_dollars{region="LAX1"`, false},
+		{`tsw_metal_monthly_spend_dollars{region="LAX1"`, false},
+		{`tsw_metal_tier_max_quantity{region="PIT1",tier="7302p"} 10`, true},
+		{`tsw_metal_available{region="PIT1",tier="7302p",memory_gb="128"} 10`, true},
+		{`tsw_metal_available{region="LAX1"`, false},
+		{`tsw_api_requests_total{operation="ListMetal"} 1`, true},
+		{`tsw_scrape_success 1`, true},
+	}
+	for _, tt := range tests {
+		if got := strings.Contains(out, tt.line); got != tt.want {
+			t.Errorf("output contains %s = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+	if t.Failed() {
+		t.Log(out)
+	}
+}
+
+func TestScrapeFailureKeepsLastCollection(t *testing.T) {
+	srv := gotswtest.NewServer()
+	srv.AddMetal(gotsw.Metal{Status: gotsw.StatusActive, RegionID: "PIT1", TierID: "7302p"})
+	e := newExporter(srv.Client(), srv.ProjectID(), []string{"PIT1"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	e.scrape(context.Background())
+	srv.Close()
+	e.scrape(context.Background())
+
+	var buf bytes.Buffer
+	if err := e.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, line := range []string{"tsw_metal_servers{", "tsw_scrape_success 0", "tsw_scrape_errors_total 1", "tsw_scrapes_total 2"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("output does not contain %s:\n%s", line, out)
+		}
+	}
+}
+
+func TestMetricWrite(t *testing.T) {
+	m := newMetric("m", "gauge", "Help.")
+	m.set(1, "b", "x")
+	m.set(2, "a", `q"\`+"\n")
+	var buf bytes.Buffer
+	if err := m.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP m Help.\n# TYPE m gauge\nm{a=\"q\\\"\\\\\\n\"} 2\nm{b=\"x\"} 1\n"
+	if buf.String() != want {
+		t.Errorf("write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+	buf.Reset()
+	newMetric("empty", "gauge", "").write(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("empty metric wrote %q", buf.String())
+	}
+}
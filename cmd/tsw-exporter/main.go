@@ -0,0 +1,114 @@
+// Command tsw-exporter exports Teraswitch metal inventory, spend and
+// capacity as Prometheus metrics.
+//
+//	TSW_API_KEY=tsw_... tsw-exporter -project 480 -listen :9787
+//
+// It collects in the background every -interval and serves the last
+// successful collection on /metrics:
+//
+//	tsw_metal_servers{status,power_state,region,tier}
+//	tsw_metal_hourly_spend_dollars{region,tier}
+//	tsw_metal_monthly_spend_dollars{region,tier}
+//	tsw_metal_tier_max_quantity{region,tier}
+//	tsw_metal_tier_hourly_price_dollars{tier}
+//	tsw_metal_available{region,tier,memory_gb}
+//	tsw_api_requests_total{operation}
+//	tsw_api_errors_total{operation}
+//	tsw_api_request_duration_seconds{operation}
+//	tsw_scrapes_total, tsw_scrape_errors_total, tsw_scrape_success,
+//	tsw_scrape_duration_seconds, tsw_scrape_last_success_timestamp_seconds
+//
+// For example, to alert on failed servers and stale data:
+//
+//	sum(tsw_metal_servers{status="Error"}) > 0
+//	time() - tsw_scrape_last_success_timestamp_seconds > 900
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func main() {
+	listen := flag.String("listen", ":9787", "address to serve metrics on")
+	interval := flag.Duration("interval", time.Minute, "how often to collect from the API")
+	project := flag.Int64("project", envInt("TSW_PROJECT_ID"), "project ID (default $TSW_PROJECT_ID)")
+	regions := flag.String("regions", "", "comma-separated regions to check availability in (default all)")
+	apiURL := flag.String("url", os.Getenv("TSW_API_URL"), "API base URL (default $TSW_API_URL or the public API)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := run(*listen, *interval, *project, *regions, *apiURL, logger); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(listen string, interval time.Duration, projectID int64, regions, apiURL string, logger *slog.Logger) error {
+	key := os.Getenv("TSW_API_KEY")
+	if key == "" {
+		return errors.New("TSW_API_KEY is not set")
+	}
+	if projectID == 0 {
+		return errors.New("no project: set -project or TSW_PROJECT_ID")
+	}
+	client := gotsw.New(key).SetLogger(logger)
+	if apiURL != "" {
+		u, err := url.Parse(apiURL)
+		if err != nil {
+			return fmt.Errorf("invalid -url: %w", err)
+		}
+		client.URL = u
+	}
+	var only []string
+	if regions != "" {
+		only = strings.Split(regions, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := newExporter(client, projectID, only, logger)
+	go e.run(ctx, interval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := e.write(w); err != nil {
+			logger.Warn("writing metrics failed", "error", err)
+		}
+	})
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<a href="/metrics">metrics</a>`)
+	})
+
+	srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	logger.Info("serving metrics", "listen", listen, "project", projectID)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func envInt(key string) int64 {
+	n, _ := strconv.ParseInt(os.Getenv(key), 10, 64)
+	return n
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metric is a metric family in the Prometheus text exposition format.
+type metric struct {
+	name    string
+	help    string
+	typ     string // "gauge", "counter" or "summary"
+	samples map[string]*sample
+}
+
+// sample is one labelled value of a metric. Suffix is appended to the
+// family name, for the _sum and _count series of a summary.
+type sample struct {
+	suffix string
+	labels []string // alternating names and values
+	value  float64
+}
+
+func newMetric(name, typ, help string) *metric {
+	return &metric{name: name, help: help, typ: typ, samples: map[string]*sample{}}
+}
+
+// get returns the sample with the given suffix and label pairs, creating
+// it if needed.
+func (m *metric) get(suffix string, labels ...string) *sample {
+	key := suffix + "\x00" + strings.Join(labels, "\x00")
+	s, ok := m.samples[key]
+	if !ok {
+		s = &sample{suffix: suffix, labels: labels}
+		m.samples[key] = s
+	}
+	return s
+}
+
+// set sets the value of the sample with the given labels.
+func (m *metric) set(v float64, labels ...string) {
+	m.get("", labels...).value = v
+}
+
+// add adds v to the sample with the given labels.
+func (m *metric) add(v float64, labels ...string) {
+	m.get("", labels...).value += v
+}
+
+// write writes m in the text exposition format, with samples sorted so
+// scrapes are stable.
+func (m *metric) write(w io.Writer) error {
+	if len(m.samples) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m.samples))
+	for k := range m.samples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+	for _, k := range keys {
+		s := m.samples[k]
+		b.WriteString(m.name + s.suffix)
+		if len(s.labels) > 0 {
+			b.WriteByte('{')
+			for i := 0; i+1 < len(s.labels); i += 2 {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", s.labels[i], labelEscaper.Replace(s.labels[i+1]))
+			}
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(formatValue(s.value))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the tsw configuration file. It is read from the path in
+// TSW_CONFIG, or tsw/config.yaml in the user's configuration directory:
+//
+//	profiles:
+//	  default:
+//	    api_key: tsw_...
+//	    project_id: 480
+//	  staging:
+//	    api_key: tsw_...
+//	    project_id: 512
+//	    url: https://staging.example.com/v2/
+//	    monthly_budget: 2000
+//	    policy: /etc/tsw/policy.yaml
+//	    audit_log: /var/log/tsw/audit.jsonl
+//
+// A profile with monthly_budget refuses to create services which would
+// take committed monthly spend over it, unless -override-budget is given.
+// A profile with policy checks creations and reinstalls against the rules
+// in that file (see policy.Rules). A profile with audit_log appends a
+// record of every mutating call to that file as JSON lines, attributed to
+// the -actor flag.
+type config struct {
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// profile is a named set of credentials and defaults.
+type profile struct {
+	APIKey    string `yaml:"api_key"`
+	ProjectID int64  `yaml:"project_id"`
+	URL       string `yaml:"url"`
+
+	MonthlyBudget float64 `yaml:"monthly_budget"`
+	Policy        string  `yaml:"policy"`
+	AuditLog      string  `yaml:"audit_log"`
+}
+
+// configPath returns the path of the configuration file.
+func configPath() (string, error) {
+	if p := os.Getenv("TSW_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tsw", "config.yaml"), nil
+}
+
+// loadProfile returns the named profile with environment overrides
+// applied. A missing configuration file is not an error, so the CLI can be
+// used with TSW_API_KEY alone.
+func loadProfile(name string) (profile, error) {
+	path, err := configPath()
+	if err != nil {
+		return profile{}, err
+	}
+
+	var cfg config
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return profile{}, err
+	default:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return profile{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	p, ok := cfg.Profiles[name]
+	if !ok && name != "default" {
+		return profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	if key := os.Getenv("TSW_API_KEY"); key != "" {
+		p.APIKey = key
+	}
+	return p, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/budget"
+	"github.com/teraswitch/gotsw/v2/policy"
+)
+
+// Exit codes.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitUsage        = 2
+	exitNotFound     = 3
+	exitUnauthorized = 4
+	exitInvalid      = 5
+	exitConflict     = 6
+	exitServer       = 7
+	exitAborted      = 8
+)
+
+const exitCodeHelp = `  0  success
+  1  other error
+  2  invalid usage or configuration
+  3  not found
+  4  unauthorized or forbidden
+  5  request rejected as invalid or by policy
+  6  conflict, such as insufficient capacity or an exceeded budget
+  7  API server error
+  8  aborted at a confirmation prompt
+`
+
+// errAborted is returned when the user declines a confirmation prompt.
+var errAborted = errors.New("aborted")
+
+// usageError marks an error in the command line.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// apiError is an error response from the API.
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.StatusCode)
+}
+
+// statusErrors is middleware which turns error responses into *apiError,
+// so commands exit with a code matching the failure.
+func statusErrors(next gotsw.Handler) gotsw.Handler {
+	return func(ctx context.Context, op *gotsw.Operation) error {
+		err := next(ctx, op)
+		if op.StatusCode < 400 {
+			return err
+		}
+		apiErr := &apiError{StatusCode: op.StatusCode}
+		if op.Result != nil {
+			var body struct {
+				Message string `json:"message"`
+			}
+			if data, err := json.Marshal(op.Result); err == nil && json.Unmarshal(data, &body) == nil {
+				apiErr.Message = body.Message
+			}
+		}
+		return apiErr
+	}
+}
+
+// exitCode maps err to the process exit code.
+func exitCode(err error) int {
+	var apiErr *apiError
+	var usageErr usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errAborted):
+		return exitAborted
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, errInvalid), errors.Is(err, policy.ErrPolicyViolation):
+		return exitInvalid
+	case errors.Is(err, budget.ErrBudgetExceeded):
+		return exitConflict
+	case errors.As(err, &apiErr):
+		switch code := apiErr.StatusCode; {
+		case code == http.StatusNotFound:
+			return exitNotFound
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return exitUnauthorized
+		case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+			return exitInvalid
+		case code == http.StatusConflict:
+			return exitConflict
+		case code >= 500:
+			return exitServer
+		}
+	}
+	return exitError
+}
+
+// check returns an error for an unsuccessful response which was not
+// reported as an error by the client.
+func check(success bool, message string) error {
+	if success {
+		return nil
+	}
+	if message == "" {
+		message = "request failed"
+	}
+	return errors.New(message)
+}
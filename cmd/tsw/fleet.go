@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/fleet"
+)
+
+var fleetCommands = map[string]*command{
+	"plan":      {usage: "plan -f FILE", summary: "Show the changes needed to match a fleet spec", run: fleetPlan},
+	"apply":     {usage: "apply -f FILE", summary: "Create, rename and reinstall services to match a fleet spec", run: fleetApply},
+	"power":     {usage: "power on|off [selector flags]", summary: "Power selected services on or off", run: fleetPower},
+	"rollout":   {usage: "rollout -image I [-batch N] [selector flags]", summary: "Reinstall selected services in health-checked batches", run: fleetRollout},
+	"reinstall": {usage: "reinstall -image I [selector flags]", summary: "Reinstall selected services, erasing their drives", run: fleetReinstall},
+}
+
+func fleetPlan(ctx context.Context, a *app, args []string) error {
+	plan, err := a.makePlan(ctx, "fleet plan", args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(a.stdout, plan)
+	return nil
+}
+
+func fleetApply(ctx context.Context, a *app, args []string) error {
+	plan, err := a.makePlan(ctx, "fleet apply", args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(a.stdout, plan)
+	if plan.Empty() {
+		return nil
+	}
+	if err := a.confirm("Apply %d changes?", len(plan.Changes)); err != nil {
+		return err
+	}
+
+	outcomes, err := fleet.Apply(ctx, a.client, plan, fleet.ApplyOptions{
+		Approve: func(c fleet.Change) bool {
+			return a.confirm("Reinstall %d %s, erasing all its data?", c.Current.ID, c.Current.DisplayName) == nil
+		},
+	})
+	for _, o := range outcomes {
+		switch {
+		case o.Err != nil:
+			fmt.Fprintf(a.stdout, "failed  %s\n", o.Change.String())
+		case o.Skipped:
+			fmt.Fprintf(a.stdout, "skipped %s\n", o.Change.String())
+		case o.Metal != nil && o.Change.Action == fleet.ActionCreate:
+			fmt.Fprintf(a.stdout, "done    %s: service %d\n", o.Change.String(), o.Metal.ID)
+		default:
+			fmt.Fprintf(a.stdout, "done    %s\n", o.Change.String())
+		}
+	}
+	return err
+}
+
+// makePlan loads the spec named by the -f flag and plans it against the
+// project.
+func (a *app) makePlan(ctx context.Context, name string, args []string) (*fleet.Plan, error) {
+	flags := newFlagSet(name)
+	file := flags.String("f", "", "fleet spec YAML file (required)")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return nil, err
+	}
+	if *file == "" {
+		flags.Usage()
+		return nil, usageError{errors.New("-f is required")}
+	}
+
+	spec, err := fleet.Load(*file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, usageError{err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	if spec.ProjectID == 0 {
+		if err := requireProject(a); err != nil {
+			return nil, err
+		}
+		spec.ProjectID = a.projectID
+	}
+	return fleet.MakePlan(ctx, a.client, spec)
+}
+
+func fleetPower(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("fleet power on|off")
+	batch := addBatchFlags(flags)
+	pos, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var command gotsw.PowerCommand
+	switch strings.ToLower(pos[0]) {
+	case "on":
+		command = gotsw.PowerCommandPowerOn
+	case "off":
+		command = gotsw.PowerCommandPowerOff
+	default:
+		return usageError{fmt.Errorf("unknown power state %q: want on or off", pos[0])}
+	}
+	return a.runBatch(ctx, batch, fleet.Power(command), command == gotsw.PowerCommandPowerOff, "Power "+strings.ToLower(pos[0]))
+}
+
+func fleetReinstall(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("fleet reinstall")
+	batch := addBatchFlags(flags)
+	var req gotsw.ReinstallMetalRequest
+	flags.StringVar(&req.ImageID, "image", "", "image ID (required)")
+	var sshKeys stringsFlag
+	flags.Var(&sshKeys, "ssh-key", "SSH key ID to install (repeatable)")
+	userData := flags.String("user-data", "", "file containing cloud-init user data")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if req.ImageID == "" {
+		flags.Usage()
+		return usageError{errors.New("-image is required")}
+	}
+	for _, v := range sshKeys {
+		keyID, err := parseID(v)
+		if err != nil {
+			return err
+		}
+		req.SSHKeyIDs = append(req.SSHKeyIDs, keyID)
+	}
+	if *userData != "" {
+		var err error
+		if req.UserData, err = readUserData(*userData); err != nil {
+			return err
+		}
+	}
+	return a.runBatch(ctx, batch, fleet.Reinstall(req), true, "Reinstall, erasing all data on")
+}
+
+// selectorFlags choose the services a batch command works on.
+type selectorFlags struct {
+	ids    stringsFlag
+	tags   stringsFlag
+	status string
+	region string
+	tier   string
+	all    bool
+}
+
+func addSelectorFlags(flags *flag.FlagSet) *selectorFlags {
+	b := &selectorFlags{}
+	flags.Var(&b.ids, "id", "service ID to select (repeatable)")
+	flags.Var(&b.tags, "tag", "select services with this tag (repeatable; all must match)")
+	flags.StringVar(&b.status, "status", "", "select services with this status, such as Active")
+	flags.StringVar(&b.region, "region", "", "select services in this region")
+	flags.StringVar(&b.tier, "tier", "", "select services of this tier")
+	flags.BoolVar(&b.all, "all", false, "select every service in the project")
+	return b
+}
+
+// batchFlags are the selector and concurrency flags of batch commands.
+type batchFlags struct {
+	*selectorFlags
+	concurrency int
+	retries     int
+}
+
+func addBatchFlags(flags *flag.FlagSet) *batchFlags {
+	b := &batchFlags{selectorFlags: addSelectorFlags(flags)}
+	flags.IntVar(&b.concurrency, "concurrency", fleet.DefaultConcurrency, "number of services to work on at once")
+	flags.IntVar(&b.retries, "retries", 0, "times to retry a failed service")
+	return b
+}
+
+func (b *selectorFlags) selector(a *app) (fleet.Selector, error) {
+	sel := fleet.Selector{
+		Tags: b.tags,
+		Filter: gotsw.ListMetalOptions{
+			ProjectID: a.projectID,
+			Status:    gotsw.Status(b.status),
+			Region:    b.region,
+			Tier:      b.tier,
+		},
+	}
+	for _, v := range b.ids {
+		id, err := parseID(v)
+		if err != nil {
+			return sel, err
+		}
+		sel.IDs = append(sel.IDs, id)
+	}
+	if !b.all && len(sel.IDs) == 0 && len(sel.Tags) == 0 && b.status == "" && b.region == "" && b.tier == "" {
+		return sel, usageError{errors.New("select services with -id, -tag, -status, -region or -tier, or pass -all")}
+	}
+	return sel, nil
+}
+
+// selectServices selects services and, for destructive commands,
+// confirms the selection.
+func (a *app) selectServices(ctx context.Context, b *selectorFlags, destructive bool, verb string) ([]gotsw.Metal, error) {
+	sel, err := b.selector(a)
+	if err != nil {
+		return nil, err
+	}
+	services, err := sel.Select(ctx, a.client)
+	if err != nil || len(services) == 0 || !destructive {
+		return services, err
+	}
+	names := make([]string, len(services))
+	for i, m := range services {
+		names[i] = fmt.Sprintf("%d (%s)", m.ID, m.DisplayName)
+	}
+	if err := a.confirm("%s %d services: %s?", verb, len(services), strings.Join(names, ", ")); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// runBatch selects services, confirms if destructive, runs task on them
+// and writes a report.
+func (a *app) runBatch(ctx context.Context, b *batchFlags, task fleet.Task, destructive bool, verb string) error {
+	services, err := a.selectServices(ctx, b.selectorFlags, destructive, verb)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		fmt.Fprintln(a.stderr, "No services selected.")
+		return nil
+	}
+	report := fleet.RunOn(ctx, a.client, services, task, fleet.RunOptions{
+		Concurrency: b.concurrency,
+		Retries:     b.retries,
+	})
+	if err := a.writeReport(report.Results, nil); err != nil {
+		return err
+	}
+	if failed := len(report.Failed()); failed > 0 {
+		return fmt.Errorf("%d of %d services failed: %w", failed, len(services), report.Err())
+	}
+	return nil
+}
+
+func fleetRollout(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet("fleet rollout")
+	sel := addSelectorFlags(flags)
+	var req gotsw.ReinstallMetalRequest
+	flags.StringVar(&req.ImageID, "image", "", "image ID (required)")
+	var sshKeys stringsFlag
+	flags.Var(&sshKeys, "ssh-key", "SSH key ID to install (repeatable)")
+	var opts fleet.RolloutOptions
+	flags.IntVar(&opts.BatchSize, "batch", 1, "number of services to reinstall at once")
+	flags.IntVar(&opts.MaxFailures, "max-failures", 0, "failures tolerated before the rollout halts")
+	flags.DurationVar(&opts.PollInterval, "poll", 15*time.Second, "how often to check progress")
+	flags.DurationVar(&opts.ReadyTimeout, "ready-timeout", 30*time.Minute, "time allowed for each service to become active")
+	checkPort := flags.Int("check-port", 22, "TCP port which must accept connections after reinstall; 0 to skip")
+	flags.DurationVar(&opts.HealthTimeout, "check-timeout", 5*time.Minute, "time allowed for the health check to pass")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if req.ImageID == "" {
+		flags.Usage()
+		return usageError{errors.New("-image is required")}
+	}
+	for _, v := range sshKeys {
+		keyID, err := parseID(v)
+		if err != nil {
+			return err
+		}
+		req.SSHKeyIDs = append(req.SSHKeyIDs, keyID)
+	}
+	if *checkPort > 0 {
+		opts.HealthCheck = fleet.DialCheck(*checkPort)
+	}
+	opts.OnResult = func(r fleet.Result) {
+		if r.Err != nil {
+			fmt.Fprintf(a.stderr, "%d (%s) failed: %v\n", r.Metal.ID, r.Metal.DisplayName, r.Err)
+		} else {
+			fmt.Fprintf(a.stderr, "%d (%s) done\n", r.Metal.ID, r.Metal.DisplayName)
+		}
+	}
+
+	services, err := a.selectServices(ctx, sel, true, "Reinstall in batches of "+strconv.Itoa(opts.BatchSize)+", erasing all data on")
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		fmt.Fprintln(a.stderr, "No services selected.")
+		return nil
+	}
+	report, err := fleet.RolloutOn(ctx, a.client, services, req, opts)
+	if werr := a.writeReport(report.Results, report.Remaining); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// writeReport writes the per-service results of a batch command, followed
+// by the services it did not reach.
+func (a *app) writeReport(results []fleet.Result, remaining []gotsw.Metal) error {
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tRESULT\tATTEMPTS\tDURATION")
+	for _, r := range results {
+		result := "ok"
+		if r.Err != nil {
+			result = "error: " + r.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", strconv.FormatInt(r.Metal.ID, 10), r.Metal.DisplayName, result, r.Attempts, r.Duration.Round(time.Millisecond))
+	}
+	for _, m := range remaining {
+		fmt.Fprintf(w, "%s\t%s\tnot started\t0\t-\n", strconv.FormatInt(m.ID, 10), m.DisplayName)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/inventory"
+)
+
+var inventoryCommands = map[string]*command{
+	"ansible":    {usage: "ansible [-host NAME] [selection]", summary: "Print an Ansible dynamic inventory", run: inventoryAnsible},
+	"discovery":  {usage: "discovery [-file F [-interval D]] [selection]", summary: "Write Prometheus file_sd or Consul targets", run: inventoryDiscovery},
+	"ssh-config": {usage: "ssh-config [-user U] [selection]", summary: "Print an OpenSSH config for services", run: inventorySSHConfig},
+}
+
+// inventoryAnsible prints an Ansible dynamic inventory. It accepts the
+// --list and --host options Ansible passes to inventory scripts, so a
+// two-line wrapper makes it one:
+//
+//	#!/bin/sh
+//	exec tsw inventory ansible "$@"
+func inventoryAnsible(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("inventory ansible")
+	fs.Bool("list", true, "print the whole inventory (the default)")
+	host := fs.String("host", "", "print the variables of one host")
+	sel := addSelectorFlags(fs)
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	services, err := a.inventoryServices(ctx, sel)
+	if err != nil {
+		return err
+	}
+	inv := inventory.Ansible(services)
+	var v any = inv
+	if *host != "" {
+		vars := inv.Host(*host)
+		if vars == nil {
+			vars = map[string]any{}
+		}
+		v = vars
+	}
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func inventorySSHConfig(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("inventory ssh-config")
+	var opts inventory.SSHOptions
+	fs.StringVar(&opts.User, "user", "", "User for every host")
+	fs.StringVar(&opts.IdentityFile, "identity-file", "", "IdentityFile for every host")
+	fs.IntVar(&opts.Port, "port", 0, "Port for every host")
+	fs.StringVar(&opts.Prefix, "prefix", "", "prefix for host aliases, such as tsw-")
+	sel := addSelectorFlags(fs)
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	services, err := a.inventoryServices(ctx, sel)
+	if err != nil {
+		return err
+	}
+	return inventory.WriteSSHConfig(a.stdout, services, opts)
+}
+
+// inventoryDiscovery prints service discovery targets, or writes them to
+// a file, once or every -interval until interrupted.
+func inventoryDiscovery(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("inventory discovery")
+	var opts inventory.DiscoveryOptions
+	format := fs.String("format", string(inventory.FileSD), "file_sd or consul")
+	fs.IntVar(&opts.Port, "port", inventory.DefaultTargetPort, "port of each target")
+	fs.StringVar(&opts.Service, "service", "node-exporter", "Consul service name")
+	file := fs.String("file", "", "file to replace atomically (default standard output)")
+	interval := fs.Duration("interval", 0, "rewrite -file this often until interrupted (default once)")
+	sel := addSelectorFlags(fs)
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	opts.Format = inventory.DiscoveryFormat(*format)
+	if sel.status != "" {
+		// Targets are Active services unless another status is selected.
+		opts.Statuses = []gotsw.Status{gotsw.Status(sel.status)}
+	}
+	if opts.Format != inventory.FileSD && opts.Format != inventory.Consul {
+		return usageError{errors.New("-format must be file_sd or consul")}
+	}
+	if *interval > 0 && *file == "" {
+		return usageError{errors.New("-interval requires -file")}
+	}
+
+	if *interval > 0 {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+		for {
+			services, err := a.inventoryServices(ctx, sel)
+			if err == nil {
+				err = inventory.WriteDiscovery(*file, services, opts)
+			}
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(a.stderr, "tsw inventory discovery: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+
+	services, err := a.inventoryServices(ctx, sel)
+	if err != nil {
+		return err
+	}
+	if *file != "" {
+		return inventory.WriteDiscovery(*file, services, opts)
+	}
+	var v any = inventory.Targets(services, opts)
+	if opts.Format == inventory.Consul {
+		v = inventory.ConsulServices(services, opts)
+	}
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// inventoryServices selects services for inventory. Unlike batch
+// commands, which must be told to work on every service, inventory
+// covers the whole project unless narrowed.
+func (a *app) inventoryServices(ctx context.Context, sel *selectorFlags) ([]gotsw.Metal, error) {
+	if err := requireProject(a); err != nil {
+		return nil, err
+	}
+	sel.all = true
+	return a.selectServices(ctx, sel, false, "")
+}
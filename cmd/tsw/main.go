@@ -0,0 +1,249 @@
+// Command tsw manages Teraswitch metal services and SSH keys from the
+// command line.
+//
+//	tsw [global flags] <command> <subcommand> [flags] [args]
+//
+// Credentials and defaults are read from profiles in
+// $XDG_CONFIG_HOME/tsw/config.yaml (see config.go). The TSW_API_KEY
+// environment variable overrides the profile's API key.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/budget"
+	"github.com/teraswitch/gotsw/v2/output"
+	"github.com/teraswitch/gotsw/v2/policy"
+)
+
+// app holds the state shared by every command.
+type app struct {
+	client    *gotsw.Client
+	projectID int64
+	output    output.Options
+	yes       bool
+
+	stdin  *bufio.Reader // shared so prompts do not lose buffered input
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a leaf command such as "metal list".
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+// commands maps group names, such as "metal", to their subcommands.
+var commands = map[string]map[string]*command{
+	"fleet":     fleetCommands,
+	"inventory": inventoryCommands,
+	"metal":     metalCommands,
+	"sshkey":    sshKeyCommands,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the process exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("tsw", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", envOr("TSW_PROFILE", "default"), "configuration profile to use")
+	project := fs.Int64("project", 0, "project ID (default from the profile)")
+	format := fs.String("output", "table", "output format: table, csv, json, yaml or template")
+	var columns stringsFlag
+	fs.Var(&columns, "columns", "comma-separated fields to show in table and csv output")
+	tmpl := fs.String("template", "", "Go template executed for each result; implies -output template")
+	noHeaders := fs.Bool("no-headers", false, "omit the header line from table and csv output")
+	yes := fs.Bool("yes", false, "do not prompt for confirmation of destructive actions")
+	overrideBudget := fs.Bool("override-budget", false, "create services even if they exceed the profile's monthly_budget")
+	actor := fs.String("actor", envOr("TSW_ACTOR", os.Getenv("USER")), "identity recorded in the profile's audit_log")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	group, name := fs.Arg(0), fs.Arg(1)
+	subcommands, ok := commands[group]
+	if !ok {
+		fs.Usage()
+		return exitUsage
+	}
+	cmd, ok := subcommands[name]
+	if !ok {
+		groupUsage(stderr, group)
+		return exitUsage
+	}
+	f, err := output.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "tsw: %v\n", err)
+		return exitUsage
+	}
+	out := output.Options{Format: f, Template: *tmpl, NoHeaders: *noHeaders}
+	for _, c := range columns {
+		out.Columns = append(out.Columns, strings.Split(c, ",")...)
+	}
+	if *tmpl != "" {
+		out.Format = output.Template
+	}
+	if out.Format == output.Template && *tmpl == "" {
+		fmt.Fprintf(stderr, "tsw: -output template requires -template\n")
+		return exitUsage
+	}
+
+	cfg, err := loadProfile(*profile)
+	if err != nil {
+		fmt.Fprintf(stderr, "tsw: %v\n", err)
+		return exitUsage
+	}
+	if *project != 0 {
+		cfg.ProjectID = *project
+	}
+	if cfg.APIKey == "" {
+		fmt.Fprintf(stderr, "tsw: no API key: set TSW_API_KEY or api_key in profile %q\n", *profile)
+		return exitUsage
+	}
+
+	client := gotsw.New(cfg.APIKey).Use(statusErrors)
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			fmt.Fprintf(stderr, "tsw: invalid url in profile %q: %v\n", *profile, err)
+			return exitUsage
+		}
+		client.URL = u
+	}
+	if cfg.AuditLog != "" {
+		auditor, err := gotsw.OpenAuditLog(cfg.AuditLog)
+		if err != nil {
+			fmt.Fprintf(stderr, "tsw: audit_log in profile %q: %v\n", *profile, err)
+			return exitUsage
+		}
+		defer auditor.Close()
+		client.SetAuditor(auditor)
+		ctx = gotsw.WithActor(ctx, *actor)
+	}
+	if cfg.Policy != "" {
+		rules, err := policy.LoadRules(cfg.Policy)
+		if err != nil {
+			fmt.Fprintf(stderr, "tsw: policy in profile %q: %v\n", *profile, err)
+			return exitUsage
+		}
+		client.Use(policy.Enforce(rules))
+	}
+	if cfg.MonthlyBudget > 0 {
+		guard := budget.NewGuard(client, budget.WithDefaultLimit(cfg.MonthlyBudget), budget.WithProjectID(cfg.ProjectID))
+		client.Use(guard.Middleware)
+	}
+	if *overrideBudget {
+		ctx = budget.Override(ctx)
+	}
+
+	a := &app{
+		client:    client,
+		projectID: cfg.ProjectID,
+		output:    out,
+		yes:       *yes,
+		stdin:     bufio.NewReader(stdin),
+		stdout:    stdout,
+		stderr:    stderr,
+	}
+	if err := cmd.run(ctx, a, fs.Args()[2:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "tsw %s %s: %v\n", group, name, err)
+		}
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage: tsw [global flags] <command> <subcommand> [flags] [args]\n\nCommands:\n")
+	for _, group := range sortedKeys(commands) {
+		for _, name := range sortedKeys(commands[group]) {
+			cmd := commands[group][name]
+			fmt.Fprintf(w, "  %-50s %s\n", group+" "+cmd.usage, cmd.summary)
+		}
+	}
+	fmt.Fprintf(w, "\nGlobal flags:\n")
+	fs.PrintDefaults()
+	fmt.Fprintf(w, "\nExit codes:\n%s", exitCodeHelp)
+}
+
+func groupUsage(w io.Writer, group string) {
+	fmt.Fprintf(w, "Usage: tsw %s <subcommand>\n\nSubcommands:\n", group)
+	for _, name := range sortedKeys(commands[group]) {
+		cmd := commands[group][name]
+		fmt.Fprintf(w, "  %-50s %s\n", group+" "+cmd.usage, cmd.summary)
+	}
+}
+
+// parseFlags parses args with fs, allowing flags to follow positional
+// arguments, and returns the positional arguments. want is the number of
+// positional arguments the command takes.
+func parseFlags(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usageError{err}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != want {
+		fs.Usage()
+		return nil, usageError{fmt.Errorf("expected %d arguments, got %d", want, len(positional))}
+	}
+	return positional, nil
+}
+
+// stringsFlag is a flag which may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
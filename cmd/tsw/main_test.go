@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+// testEnv is a fake API server and a configuration file pointing at it.
+type testEnv struct {
+	srv    *gotswtest.Server
+	config string
+}
+
+// newTestEnv starts a fake server and writes a default profile for it.
+// extra is appended to the profile, indented to match.
+func newTestEnv(t *testing.T, extra ...string) *testEnv {
+	t.Helper()
+	srv := gotswtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	lines := []string{
+		"profiles:",
+		"  default:",
+		"    api_key: test",
+		"    project_id: " + itoa(srv.ProjectID()),
+		"    url: " + srv.BaseURL().String(),
+	}
+	for _, l := range extra {
+		lines = append(lines, "    "+l)
+	}
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TSW_CONFIG", config)
+	t.Setenv("TSW_API_KEY", "")
+	t.Setenv("TSW_PROFILE", "")
+	return &testEnv{srv: srv, config: config}
+}
+
+// run runs tsw with args and returns its exit code and output.
+func (e *testEnv) run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func (e *testEnv) addMetal(name string) int64 {
+	return e.srv.AddMetal(gotsw.Metal{
+		DisplayName: name,
+		Status:      gotsw.StatusActive,
+		PowerState:  gotsw.PowerStateOn,
+		RegionID:    "PIT1",
+		TierID:      "7302p",
+		ImageID:     "debian-12",
+	})
+}
+
+func TestRunExitCodes(t *testing.T) {
+	env := newTestEnv(t)
+	id := env.addMetal("web-1")
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "list", args: []string{"metal", "list"}, code: exitOK, stdout: "web-1"},
+		{name: "get", args: []string{"-output", "json", "metal", "get", itoa(id)}, code: exitOK, stdout: `"name": "web-1"`},
+		{name: "template", args: []string{"-template", "{{.id}}:{{.name}}", "metal", "list"}, code: exitOK, stdout: itoa(id) + ":web-1\n"},
+		{name: "not found", args: []string{"metal", "get", "1"}, code: exitNotFound},
+		{name: "unknown group", args: []string{"nope"}, code: exitUsage},
+		{name: "unknown command", args: []string{"metal", "nope"}, code: exitUsage, stderr: "metal list"},
+		{name: "bad output", args: []string{"-output", "xml", "metal", "list"}, code: exitUsage, stderr: `unknown format "xml"`},
+		{name: "template without text", args: []string{"-output", "template", "metal", "list"}, code: exitUsage},
+		{name: "missing argument", args: []string{"metal", "get"}, code: exitUsage},
+		{name: "rename", args: []string{"metal", "rename", itoa(id), "web-2"}, code: exitOK, stdout: "web-2"},
+		{name: "destructive without -yes", args: []string{"metal", "power", itoa(id), "off"}, code: exitAborted},
+		{name: "destructive with -yes", args: []string{"-yes", "metal", "power", itoa(id), "off"}, code: exitOK},
+		{name: "estimate json", args: []string{"-output", "json", "metal", "estimate", "-tier", "7302p", "-quantity", "2"}, code: exitOK, stdout: `"quantity": 2`},
+		{name: "estimate unknown tier", args: []string{"metal", "estimate", "-tier", "nope"}, code: exitInvalid},
+		{name: "compare csv", args: []string{"-output", "csv", "metal", "compare", "-min-memory", "300"}, code: exitOK, stdout: "tier,cpu,cores"},
+		{name: "compare none", args: []string{"-output", "json", "metal", "compare", "-min-cores", "1000"}, code: exitOK, stdout: "[]", stderr: "No tier meets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := env.run(t, tt.args...)
+			if code != tt.code {
+				t.Fatalf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.code, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, tt.stdout)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestRunWithoutAPIKey(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "missing.yaml")
+	t.Setenv("TSW_CONFIG", config)
+	t.Setenv("TSW_API_KEY", "")
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"metal", "list"}, strings.NewReader(""), &bytes.Buffer{}, &stderr); code != exitUsage {
+		t.Errorf("exit code = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr.String(), "no API key") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func TestMetalListPages(t *testing.T) {
+	env := newTestEnv(t)
+	for i := 0; i < 150; i++ {
+		env.addMetal("web-" + strconv.Itoa(i))
+	}
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, 150},
+		{[]string{"-limit", "10"}, 10},
+		{[]string{"-skip", "140"}, 10},
+		{[]string{"-limit", "20", "-skip", "140"}, 10},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := env.run(t, append([]string{"metal", "list"}, tt.args...)...)
+		if code != exitOK {
+			t.Fatalf("%q: exit code = %d: %s", tt.args, code, stderr)
+		}
+		if got := strings.Count(stdout, "web-"); got != tt.want {
+			t.Errorf("%q listed %d services, want %d", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/cloudinit"
+	"github.com/teraswitch/gotsw/v2/output"
+)
+
+var metalCommands = map[string]*command{
+	"list":         {usage: "list [flags]", summary: "List metal services", run: metalList},
+	"estimate":     {usage: "estimate -tier T [flags]", summary: "Estimate the cost of a configuration", run: metalEstimate},
+	"get":          {usage: "get <id>", summary: "Show a metal service", run: metalGet},
+	"compare":      {usage: "compare [flags]", summary: "Compare the cheapest tiers meeting requirements", run: metalCompare},
+	"create":       {usage: "create -region R -tier T -image I [flags]", summary: "Create metal services", run: metalCreate},
+	"reinstall":    {usage: "reinstall <id> -image I [flags]", summary: "Reinstall a metal service, erasing its drives", run: metalReinstall},
+	"power":        {usage: "power <id> on|off", summary: "Power a metal service on or off", run: metalPower},
+	"rename":       {usage: "rename <id> <name>", summary: "Rename a metal service", run: metalRename},
+	"logs":         {usage: "logs <id>", summary: "Show the logs of a metal service", run: metalLogs},
+	"availability": {usage: "availability -region R", summary: "Show configurations available in a region", run: metalAvailability},
+	"tiers":        {usage: "tiers [-type compute|gpu]", summary: "List metal tiers", run: metalTiers},
+	"watch":        {usage: "watch [flags]", summary: "Print changes to metal services as they happen", run: metalWatch},
+}
+
+// errInvalid is returned when a request fails client-side validation.
+var errInvalid = errors.New("invalid request")
+
+func metalList(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal list")
+	opts := gotsw.ListMetalOptions{ProjectID: a.projectID}
+	fs.Func("status", "only list services with this status", func(v string) error {
+		opts.Status = gotsw.Status(v)
+		return nil
+	})
+	fs.StringVar(&opts.Region, "region", "", "only list services in this region")
+	fs.StringVar(&opts.Tier, "tier", "", "only list services of this tier")
+	fs.StringVar(&opts.Tag, "tag", "", "only list services with this tag")
+	limit := fs.Int("limit", 0, "maximum number of services to list (default all)")
+	skip := fs.Int("skip", 0, "number of services to skip")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	opts.Limit, opts.Skip = int32(*limit), int32(*skip)
+
+	if opts.Limit <= 0 {
+		services, err := a.client.ListAllMetal(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return a.write(services)
+	}
+	resp, err := a.client.ListMetal(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := check(resp.Success, resp.Message); err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalWatch(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal watch")
+	opts := gotsw.ListMetalOptions{ProjectID: a.projectID}
+	fs.Func("status", "only watch services with this status", func(v string) error {
+		opts.Status = gotsw.Status(v)
+		return nil
+	})
+	fs.StringVar(&opts.Region, "region", "", "only watch services in this region")
+	fs.StringVar(&opts.Tier, "tier", "", "only watch services of this tier")
+	fs.StringVar(&opts.Tag, "tag", "", "only watch services with this tag")
+	interval := fs.Duration("interval", gotsw.DefaultWatchInterval, "how often to poll")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	for change := range a.client.Watch(ctx, opts, *interval) {
+		fmt.Fprintf(a.stdout, "%s  %-20s  %d  %s\n", change.Time.Format(time.RFC3339), change.Type, change.ID, describeChange(change))
+	}
+	// The watch only ends when interrupted.
+	return nil
+}
+
+// describeChange returns the detail of a change for metal watch.
+func describeChange(c gotsw.MetalChange) string {
+	switch c.Type {
+	case gotsw.ChangeAdded:
+		return fmt.Sprintf("%s (%s, %s)", c.After.DisplayName, c.After.Status, c.After.TierID)
+	case gotsw.ChangeRemoved:
+		return c.Before.DisplayName
+	case gotsw.ChangeStatus:
+		return fmt.Sprintf("%s -> %s", c.Before.Status, c.After.Status)
+	case gotsw.ChangePowerState:
+		return fmt.Sprintf("%s -> %s", c.Before.PowerState, c.After.PowerState)
+	case gotsw.ChangeRenamed:
+		return fmt.Sprintf("%s -> %s", c.Before.DisplayName, c.After.DisplayName)
+	case gotsw.ChangeTags:
+		return fmt.Sprintf("%s -> %s", strings.Join(c.Before.Tags, ","), strings.Join(c.After.Tags, ","))
+	case gotsw.ChangeIPs:
+		return fmt.Sprintf("%d -> %d addresses", len(c.Before.IPAddresses), len(c.After.IPAddresses))
+	case gotsw.ChangeProvisioningEvent:
+		return fmt.Sprintf("%s: %s", c.Event.Body, c.Event.State)
+	}
+	return ""
+}
+
+func metalGet(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal get <id>")
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.GetMetalService(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalCreate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal create")
+	req := &gotsw.CreateBareMetalRequest{}
+	fs.StringVar(&req.DisplayName, "name", "", "display name")
+	fs.StringVar(&req.RegionID, "region", "", "region ID, such as PIT1 (required)")
+	fs.StringVar(&req.TierID, "tier", "", "tier ID, such as 7302p (required)")
+	fs.StringVar(&req.ImageID, "image", "", "image ID")
+	fs.IntVar(&req.MemoryGB, "memory", 0, "memory in GB (default from the tier)")
+	fs.IntVar(&req.Quantity, "quantity", 1, "number of services to create")
+	fs.BoolVar(&req.ReservePricing, "reserve", false, "reserve the services for a year at a discount")
+	var sshKeys, disks, tags stringsFlag
+	fs.Var(&sshKeys, "ssh-key", "SSH key ID to install (repeatable)")
+	fs.Var(&disks, "disk", "drive for a slot as slot=size, such as nvme0n1=960g (repeatable)")
+	fs.Var(&tags, "tag", "tag to add (repeatable)")
+	ipxeURL := fs.String("ipxe-url", "", "URL of an iPXE script to boot")
+	userData := fs.String("user-data", "", "file containing cloud-init user data")
+	passwordStdin := fs.Bool("password-stdin", false, "read the root password from stdin")
+	noValidate := fs.Bool("no-validate", false, "skip checking the request against the tier")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if req.RegionID == "" || req.TierID == "" {
+		fs.Usage()
+		return usageError{errors.New("-region and -tier are required")}
+	}
+	if err := requireProject(a); err != nil {
+		return err
+	}
+
+	for _, v := range sshKeys {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return usageError{fmt.Errorf("invalid SSH key ID %q", v)}
+		}
+		req.SSHKeyIDs = append(req.SSHKeyIDs, id)
+	}
+	d, err := parseDisks(disks)
+	if err != nil {
+		return err
+	}
+	req.Disks = d
+	req.Tags = tags
+	if *ipxeURL != "" {
+		req.IPXEUrl = ipxeURL
+	}
+	if *userData != "" {
+		data, err := readUserData(*userData)
+		if err != nil {
+			return err
+		}
+		req.UserData = &data
+	}
+	if *passwordStdin {
+		password, err := a.readLine()
+		if err != nil {
+			return err
+		}
+		req.Password = &password
+	}
+
+	if !*noValidate {
+		tiers, err := a.client.ListMetalTiers(ctx, "")
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, tier := range tiers.Result {
+			if tier.ID == req.TierID {
+				found = true
+				if err := req.Validate(tier); err != nil {
+					return fmt.Errorf("%w:\n%v", errInvalid, err)
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: unknown tier %q", errInvalid, req.TierID)
+		}
+	}
+
+	resp, err := a.client.CreateMetalService(ctx, a.projectID, req)
+	if err != nil {
+		return err
+	}
+	if err := check(resp.Success, resp.Message); err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalReinstall(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal reinstall <id>")
+	req := &gotsw.ReinstallMetalRequest{}
+	fs.StringVar(&req.DisplayName, "name", "", "new display name")
+	fs.StringVar(&req.ImageID, "image", "", "image ID")
+	fs.StringVar(&req.IPXEUrl, "ipxe-url", "", "URL of an iPXE script to boot")
+	var sshKeys stringsFlag
+	fs.Var(&sshKeys, "ssh-key", "SSH key ID to install (repeatable)")
+	userData := fs.String("user-data", "", "file containing cloud-init user data")
+	passwordStdin := fs.Bool("password-stdin", false, "read the root password from stdin")
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	for _, v := range sshKeys {
+		keyID, err := parseID(v)
+		if err != nil {
+			return err
+		}
+		req.SSHKeyIDs = append(req.SSHKeyIDs, keyID)
+	}
+	if *userData != "" {
+		if req.UserData, err = readUserData(*userData); err != nil {
+			return err
+		}
+	}
+	if *passwordStdin {
+		if req.Password, err = a.readLine(); err != nil {
+			return err
+		}
+	}
+
+	if !a.yes {
+		metal, err := a.client.GetMetalService(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := a.confirm("Reinstall metal service %d (%s)? All data on its drives will be erased.", id, metal.Result.DisplayName); err != nil {
+			return err
+		}
+	}
+
+	resp, err := a.client.ReinstallMetalService(ctx, id, req)
+	if err != nil {
+		return err
+	}
+	if err := check(resp.Success, resp.Message); err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalPower(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal power <id> on|off")
+	pos, err := parseFlags(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	var command gotsw.PowerCommand
+	switch strings.ToLower(pos[1]) {
+	case "on":
+		command = gotsw.PowerCommandPowerOn
+	case "off":
+		command = gotsw.PowerCommandPowerOff
+		if err := a.confirm("Power off metal service %d?", id); err != nil {
+			return err
+		}
+	default:
+		return usageError{fmt.Errorf("unknown power state %q: want on or off", pos[1])}
+	}
+
+	resp, err := a.client.SendPowerCommand(ctx, id, command)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalRename(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal rename <id> <name>")
+	pos, err := parseFlags(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.client.RenameMetalService(ctx, id, pos[1]); err != nil {
+		return err
+	}
+	resp, err := a.client.GetMetalService(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalLogs(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal logs <id>")
+	follow := fs.Bool("f", false, "follow the logs until provisioning finishes")
+	interval := fs.Duration("interval", gotsw.DefaultLogPollInterval, "how often to poll with -f")
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	if *follow {
+		stream := a.client.StreamMetalLogs(ctx, id, gotsw.StreamLogsOptions{Interval: *interval})
+		opts := a.output
+		for l := range stream.C {
+			if opts.Format == output.Table {
+				// Rows arrive one at a time, so they cannot be aligned.
+				fmt.Fprintf(a.stdout, "%s  %s  %s\n", l.Timestamp, l.Name, l.Message)
+				continue
+			}
+			if err := output.Write(a.stdout, l, opts); err != nil {
+				return err
+			}
+			opts.NoHeaders = true
+		}
+		return stream.Err()
+	}
+
+	resp, err := a.client.GetMetalLogs(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalAvailability(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal availability")
+	region := fs.String("region", "", "region ID, such as PIT1 (required)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *region == "" {
+		fs.Usage()
+		return usageError{errors.New("-region is required")}
+	}
+	if err := requireProject(a); err != nil {
+		return err
+	}
+
+	resp, err := a.client.GetMetalAvailability(ctx, a.projectID, *region)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+func metalTiers(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal tiers")
+	tierType := fs.String("type", "", "only list tiers of this type: compute or gpu")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	var typ gotsw.MetalTierType
+	switch strings.ToLower(*tierType) {
+	case "":
+	case "compute":
+		typ = gotsw.MetalTierTypeCompute
+	case "gpu":
+		typ = gotsw.MetalTierTypeGPU
+	default:
+		return usageError{fmt.Errorf("unknown tier type %q", *tierType)}
+	}
+
+	resp, err := a.client.ListMetalTiers(ctx, typ)
+	if err != nil {
+		return err
+	}
+	return a.write(resp.Result)
+}
+
+// parseDisks parses -disk flags of the form slot=size. It returns nil if
+// there are none, so the tier's defaults apply.
+func parseDisks(disks []string) (map[string]string, error) {
+	if len(disks) == 0 {
+		return nil, nil
+	}
+	m := map[string]string{}
+	for _, v := range disks {
+		slot, size, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, usageError{fmt.Errorf("invalid disk %q: want slot=size", v)}
+		}
+		m[slot] = size
+	}
+	return m, nil
+}
+
+// readUserData reads and validates cloud-init user data from path.
+func readUserData(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := cloudinit.Validate(string(data)); err != nil {
+		return "", fmt.Errorf("%w: %s: %v", errInvalid, path, err)
+	}
+	return string(data), nil
+}
+
+// readLine reads a single line, such as a password, from stdin.
+func (a *app) readLine() (string, error) {
+	line, err := a.stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func requireProject(a *app) error {
+	if a.projectID == 0 {
+		return usageError{errors.New("no project: set -project or project_id in the profile")}
+	}
+	return nil
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, usageError{fmt.Errorf("invalid ID %q", s)}
+	}
+	return id, nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tsw %s\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2/output"
+)
+
+// write writes v in the selected output format.
+func (a *app) write(v any) error {
+	return output.Write(a.stdout, v, a.output)
+}
+
+// confirm asks the user to confirm a destructive action, returning
+// errAborted unless they answer yes. The prompt is skipped with -yes.
+func (a *app) confirm(format string, args ...any) error {
+	if a.yes {
+		return nil
+	}
+	fmt.Fprintf(a.stderr, format+" [y/N] ", args...)
+	answer, _ := a.stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errAborted
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/output"
+	"github.com/teraswitch/gotsw/v2/pricing"
+)
+
+func metalEstimate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal estimate")
+	req := gotsw.CreateBareMetalRequest{}
+	fs.StringVar(&req.TierID, "tier", "", "tier ID, such as 7302p (required)")
+	fs.IntVar(&req.MemoryGB, "memory", 0, "memory in GB (default from the tier)")
+	fs.IntVar(&req.Quantity, "quantity", 1, "number of services")
+	var disks stringsFlag
+	fs.Var(&disks, "disk", "drive for a slot as slot=size, such as nvme0n1=960g (repeatable)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if req.TierID == "" {
+		fs.Usage()
+		return usageError{errors.New("-tier is required")}
+	}
+	d, err := parseDisks(disks)
+	if err != nil {
+		return err
+	}
+	req.Disks = d
+
+	tiers, err := a.client.ListMetalTiers(ctx, "")
+	if err != nil {
+		return err
+	}
+	quote, err := pricing.Estimate(req, tiers.Result)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	return a.write(quoteRecord(quote))
+}
+
+func metalCompare(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("metal compare")
+	var r pricing.Requirements
+	fs.IntVar(&r.MinCores, "min-cores", 0, "minimum CPU cores")
+	fs.IntVar(&r.MinThreads, "min-threads", 0, "minimum CPU threads")
+	fs.IntVar(&r.MinMemoryGB, "min-memory", 0, "minimum memory in GB")
+	fs.IntVar(&r.MinStorageGB, "min-storage", 0, "minimum total drive capacity in GB")
+	storageType := fs.String("storage-type", "", "count only drives of this type: HDD, SSD or NVME")
+	fs.StringVar(&r.Region, "region", "", "only tiers with capacity in this region")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	r.StorageType = gotsw.StorageType(strings.ToUpper(*storageType))
+
+	tiers, err := a.client.ListMetalTiers(ctx, "")
+	if err != nil {
+		return err
+	}
+	candidates := pricing.Compare(tiers.Result, r)
+	if len(candidates) == 0 {
+		fmt.Fprintln(a.stderr, "No tier meets the requirements.")
+	}
+	records := make([]output.Record, len(candidates))
+	for i, c := range candidates {
+		records[i] = candidateRecord(c)
+	}
+	return a.write(records)
+}
+
+// quoteRecord flattens a quote for the output package. Items maps each
+// component to the option chosen.
+func quoteRecord(q *pricing.Quote) output.Record {
+	items := map[string]string{}
+	for _, item := range q.Items {
+		items[item.Component] = item.Description
+	}
+	return output.Record{
+		{Name: "tier", Value: q.Tier.ID},
+		{Name: "quantity", Value: int64(q.Quantity)},
+		{Name: "items", Value: items},
+		{Name: "hourly_price", Value: q.Hourly},
+		{Name: "monthly_price", Value: q.Monthly},
+		{Name: "total_hourly_price", Value: q.TotalHourly()},
+		{Name: "total_monthly_price", Value: q.TotalMonthly()},
+	}
+}
+
+// candidateRecord flattens a candidate for the output package. Disks is
+// empty when the tier's default drives suffice.
+func candidateRecord(c pricing.Candidate) output.Record {
+	disks := c.Request.Disks
+	if disks == nil {
+		disks = map[string]string{}
+	}
+	return output.Record{
+		{Name: "tier", Value: c.Request.TierID},
+		{Name: "cpu", Value: c.Quote.Tier.CPU},
+		{Name: "cores", Value: int64(c.Cores)},
+		{Name: "threads", Value: int64(c.Threads)},
+		{Name: "memory_gb", Value: int64(c.MemoryGB)},
+		{Name: "storage_gb", Value: int64(c.StorageGB)},
+		{Name: "disks", Value: disks},
+		{Name: "hourly_price", Value: c.Quote.Hourly},
+		{Name: "monthly_price", Value: c.Quote.Monthly},
+	}
+}
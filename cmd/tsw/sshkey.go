@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+var sshKeyCommands = map[string]*command{
+	"list":   {usage: "list", summary: "List SSH keys", run: sshKeyList},
+	"get":    {usage: "get <id>", summary: "Show an SSH key", run: sshKeyGet},
+	"create": {usage: "create -name N -key-file F", summary: "Add an SSH public key", run: sshKeyCreate},
+}
+
+func sshKeyList(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("sshkey list")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+
+	keys, err := a.client.ListSshKeys(ctx)
+	if err != nil {
+		return err
+	}
+	return a.write(keys)
+}
+
+func sshKeyGet(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("sshkey get <id>")
+	pos, err := parseFlags(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	key, err := a.client.GetSshKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.write(key)
+}
+
+func sshKeyCreate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("sshkey create")
+	name := fs.String("name", "", "display name (required)")
+	keyFile := fs.String("key-file", "", "public key file, such as ~/.ssh/id_ed25519.pub (required)")
+	if _, err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" || *keyFile == "" {
+		fs.Usage()
+		return usageError{errors.New("-name and -key-file are required")}
+	}
+	if err := requireProject(a); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := a.client.CreateSshKey(ctx, a.projectID, gotsw.CreateSshKeyRequest{
+		DisplayName: *name,
+		Key:         strings.TrimSpace(string(data)),
+	})
+	if err != nil {
+		return err
+	}
+	return a.write(key)
+}
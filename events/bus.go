@@ -0,0 +1,296 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// DefaultInterval is how often a Bus polls unless configured otherwise.
+const DefaultInterval = time.Minute
+
+// Option configures a Bus.
+type Option func(*Bus)
+
+// WithSink registers a sink under a name. The name records which sinks
+// have accepted each pending event, so it must stay the same across
+// restarts.
+func WithSink(name string, sink Sink) Option {
+	return func(b *Bus) {
+		b.sinks = append(b.sinks, namedSink{name, sink})
+	}
+}
+
+// WithInterval sets how often the bus polls and retries failed
+// deliveries.
+func WithInterval(d time.Duration) Option {
+	return func(b *Bus) {
+		b.interval = d
+	}
+}
+
+// WithFilter limits the metal services watched.
+func WithFilter(opts gotsw.ListMetalOptions) Option {
+	return func(b *Bus) {
+		b.filter = opts
+	}
+}
+
+// WithoutSSHKeys stops the bus watching SSH keys.
+func WithoutSSHKeys() Option {
+	return func(b *Bus) {
+		b.sshKeys = false
+	}
+}
+
+// WithMaxPending caps the number of events awaiting delivery. When a poll
+// queues more, the oldest are dropped and counted in State.Dropped, so a
+// sink which is down for long does not grow the state without bound.
+// Without it, or with n <= 0, nothing is dropped.
+func WithMaxPending(n int) Option {
+	return func(b *Bus) {
+		b.maxPending = n
+	}
+}
+
+// WithLogger sets the logger for poll and delivery failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Bus) {
+		b.logger = logger
+	}
+}
+
+type namedSink struct {
+	name string
+	sink Sink
+}
+
+// Bus polls for changes and delivers them to sinks.
+type Bus struct {
+	client   *gotsw.Client
+	store    Store
+	sinks    []namedSink
+	interval time.Duration
+	filter   gotsw.ListMetalOptions
+	sshKeys  bool
+	logger   *slog.Logger
+	now      func() time.Time
+
+	maxPending int
+
+	state *State
+}
+
+// NewBus returns a Bus which keeps its state in store.
+func NewBus(client *gotsw.Client, store Store, opts ...Option) *Bus {
+	b := &Bus{
+		client:   client,
+		store:    store,
+		interval: DefaultInterval,
+		sshKeys:  true,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Run polls and delivers every interval until ctx is done, then returns
+// ctx.Err(). Poll and delivery failures are logged and retried; only a
+// failure to load or save state stops it.
+func (b *Bus) Run(ctx context.Context) error {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		if err := b.Poll(ctx); err != nil {
+			var se *storeError
+			if errors.As(err, &se) {
+				return err
+			}
+			if ctx.Err() == nil {
+				b.logger.WarnContext(ctx, "events: poll failed", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// storeError is a failure to load or save state.
+type storeError struct{ err error }
+
+func (e *storeError) Error() string { return "events: state: " + e.err.Error() }
+func (e *storeError) Unwrap() error { return e.err }
+
+// Poll takes one snapshot, queues the changes since the last and delivers
+// pending events. New events are saved before any delivery is attempted.
+func (b *Bus) Poll(ctx context.Context) error {
+	if b.state == nil {
+		state, err := b.store.Load()
+		if err != nil {
+			return &storeError{err}
+		}
+		b.state = state
+	}
+
+	snapErr := b.snapshot(ctx)
+	if snapErr == nil {
+		if err := b.store.Save(b.state); err != nil {
+			return &storeError{err}
+		}
+	}
+
+	b.deliver(ctx)
+	if err := b.store.Save(b.state); err != nil {
+		return &storeError{err}
+	}
+	return snapErr
+}
+
+// Pending returns the number of events awaiting delivery.
+func (b *Bus) Pending() int {
+	if b.state == nil {
+		return 0
+	}
+	return len(b.state.Pending)
+}
+
+// Dropped returns the number of events dropped by WithMaxPending.
+func (b *Bus) Dropped() int64 {
+	if b.state == nil {
+		return 0
+	}
+	return b.state.Dropped
+}
+
+// snapshot lists resources and queues an event for each change.
+func (b *Bus) snapshot(ctx context.Context) error {
+	metal, err := b.client.ListAllMetal(ctx, b.filter)
+	if err != nil {
+		return err
+	}
+	var keys []gotsw.SSHKey
+	if b.sshKeys {
+		if keys, err = b.client.ListSshKeys(ctx); err != nil {
+			return err
+		}
+	}
+
+	now := b.now()
+	s := b.state
+	nextMetal := make(map[int64]gotsw.Metal, len(metal))
+	for _, m := range metal {
+		nextMetal[m.ID] = m
+	}
+	nextKeys := make(map[int64]gotsw.SSHKey, len(keys))
+	for _, k := range keys {
+		nextKeys[k.ID] = k
+	}
+
+	if s.Initialized {
+		var events []Event
+		for _, id := range unionKeys(s.Metal, nextMetal) {
+			for _, c := range gotsw.DiffMetal(ptr(s.Metal, id), ptr(nextMetal, id)) {
+				events = append(events, metalEvent(c, now))
+			}
+		}
+		if b.sshKeys {
+			for _, id := range unionKeys(s.SSHKeys, nextKeys) {
+				events = append(events, diffSSHKey(ptr(s.SSHKeys, id), ptr(nextKeys, id), now)...)
+			}
+		}
+		for _, e := range events {
+			s.Pending = append(s.Pending, Pending{Event: e})
+		}
+		b.trim(ctx)
+	}
+
+	s.Initialized = true
+	s.Metal = nextMetal
+	if b.sshKeys {
+		s.SSHKeys = nextKeys
+	}
+	return nil
+}
+
+// trim drops the oldest pending events beyond the WithMaxPending cap.
+func (b *Bus) trim(ctx context.Context) {
+	s := b.state
+	n := len(s.Pending) - b.maxPending
+	if b.maxPending <= 0 || n <= 0 {
+		return
+	}
+	for _, p := range s.Pending[:n] {
+		b.logger.WarnContext(ctx, "events: dropped undelivered event",
+			"event", p.Event.ID, "type", p.Event.Type, "attempts", p.Attempts, "lastError", p.LastError)
+	}
+	s.Pending = slices.Clone(s.Pending[n:])
+	s.Dropped += int64(n)
+}
+
+// deliver offers pending events to each sink in order. A sink which fails
+// gets no further events this round, so it never sees them out of order.
+// Events every sink has accepted are dropped.
+func (b *Bus) deliver(ctx context.Context) {
+	for _, ns := range b.sinks {
+		for i := range b.state.Pending {
+			p := &b.state.Pending[i]
+			if slices.Contains(p.Delivered, ns.name) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			p.Attempts++
+			if err := ns.sink.Deliver(ctx, p.Event); err != nil {
+				p.LastError = err.Error()
+				b.logger.WarnContext(ctx, "events: delivery failed",
+					"sink", ns.name, "event", p.Event.ID, "type", p.Event.Type, "attempts", p.Attempts, "error", err)
+				break
+			}
+			p.Delivered = append(p.Delivered, ns.name)
+		}
+	}
+
+	b.state.Pending = slices.DeleteFunc(b.state.Pending, func(p Pending) bool {
+		for _, ns := range b.sinks {
+			if !slices.Contains(p.Delivered, ns.name) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func unionKeys[V any](a, b map[int64]V) []int64 {
+	ids := make([]int64, 0, len(b))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func ptr[V any](m map[int64]V, id int64) *V {
+	v, ok := m[id]
+	if !ok {
+		return nil
+	}
+	return &v
+}
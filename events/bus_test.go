@@ -0,0 +1,188 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+// recorder is a sink which records the events it accepts and fails while
+// fail is set.
+type recorder struct {
+	types []string
+	fail  bool
+}
+
+func (r *recorder) Deliver(_ context.Context, e Event) error {
+	if r.fail {
+		return errors.New("sink down")
+	}
+	r.types = append(r.types, e.Type)
+	return nil
+}
+
+func newMetal(name string) gotsw.Metal {
+	return gotsw.Metal{DisplayName: name, Status: gotsw.StatusActive, RegionID: "PIT1", TierID: "7302p"}
+}
+
+func TestBusPoll(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	id := srv.AddMetal(newMetal("web-1"))
+
+	a, b := &recorder{}, &recorder{}
+	store := &MemoryStore{}
+	bus := NewBus(srv.Client(), store, WithSink("a", a), WithSink("b", b))
+	steps := []struct {
+		name    string
+		change  func()
+		bDown   bool
+		a, b    []string // events each sink has accepted so far
+		pending int
+	}{
+		{name: "baseline", change: func() {}},
+		{
+			name:   "added",
+			change: func() { srv.AddMetal(newMetal("web-2")) },
+			a:      []string{MetalAdded},
+			b:      []string{MetalAdded},
+		},
+		{
+			name: "sink down",
+			change: func() {
+				srv.Client().RenameMetalService(ctx, id, "web-1a")
+				srv.AddSshKey(gotsw.SSHKey{DisplayName: "laptop", Key: "ssh-ed25519 AAAA"})
+			},
+			bDown:   true,
+			a:       []string{MetalAdded, MetalRenamed, SSHKeyAdded},
+			b:       []string{MetalAdded},
+			pending: 2,
+		},
+		{
+			name:   "sink back",
+			change: func() {},
+			a:      []string{MetalAdded, MetalRenamed, SSHKeyAdded},
+			b:      []string{MetalAdded, MetalRenamed, SSHKeyAdded},
+		},
+	}
+	for _, step := range steps {
+		step.change()
+		b.fail = step.bDown
+		if err := bus.Poll(ctx); err != nil {
+			t.Fatalf("%s: Poll() = %v", step.name, err)
+		}
+		if !slices.Equal(a.types, step.a) || !slices.Equal(b.types, step.b) {
+			t.Errorf("%s: sinks got %q and %q, want %q and %q", step.name, a.types, b.types, step.a, step.b)
+		}
+		if bus.Pending() != step.pending {
+			t.Errorf("%s: Pending() = %d, want %d", step.name, bus.Pending(), step.pending)
+		}
+	}
+
+	// A new bus on the same store carries on from the saved snapshot.
+	c := &recorder{}
+	bus = NewBus(srv.Client(), store, WithSink("c", c))
+	srv.Client().RenameMetalService(ctx, id, "web-1b")
+	if err := bus.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(c.types, []string{MetalRenamed}) {
+		t.Errorf("after restart got %q, want one rename", c.types)
+	}
+}
+
+func TestBusMaxPending(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	sink := &recorder{fail: true}
+	bus := NewBus(srv.Client(), &MemoryStore{}, WithSink("s", sink), WithMaxPending(2), WithoutSSHKeys())
+	if err := bus.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int64
+	for _, name := range []string{"a", "b", "c", "d"} {
+		ids = append(ids, srv.AddMetal(newMetal(name)))
+		if err := bus.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bus.Pending() != 2 || bus.Dropped() != 2 {
+		t.Fatalf("Pending() = %d, Dropped() = %d, want 2 and 2", bus.Pending(), bus.Dropped())
+	}
+	for i, p := range bus.state.Pending {
+		if want := ids[i+2]; p.Event.ResourceID != want {
+			t.Errorf("pending[%d] is about %d, want %d: the oldest should be dropped", i, p.Event.ResourceID, want)
+		}
+	}
+
+	sink.fail = false
+	if err := bus.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.types) != 2 || bus.Pending() != 0 || bus.Dropped() != 2 {
+		t.Errorf("delivered %d, Pending() = %d, Dropped() = %d, want 2, 0 and 2", len(sink.types), bus.Pending(), bus.Dropped())
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	state, err := store.Load()
+	if err != nil || state.Initialized {
+		t.Fatalf("Load() of a missing file = %+v, %v", state, err)
+	}
+	state = &State{
+		Initialized: true,
+		Metal:       map[int64]gotsw.Metal{1: {ID: 1, DisplayName: "web-1"}},
+		Pending:     []Pending{{Event: Event{ID: "x", Type: MetalAdded}, Delivered: []string{"a"}, Attempts: 2}},
+		Dropped:     3,
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Initialized || got.Metal[1].DisplayName != "web-1" || len(got.Pending) != 1 || got.Pending[0].Attempts != 2 || got.Dropped != 3 {
+		t.Errorf("Load() = %+v", got)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	secret := "s3cret"
+	status := http.StatusOK
+	var verified bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = VerifySignature([]byte(secret), body, r.Header.Get(HeaderSignature)) &&
+			r.Header.Get(HeaderEvent) == MetalAdded && r.Header.Get(HeaderDelivery) == "e1"
+		w.WriteHeader(status)
+		io.WriteString(w, "nope")
+	}))
+	defer srv.Close()
+
+	sink := Webhook(srv.URL, secret)
+	e := Event{ID: "e1", Type: MetalAdded, ResourceID: 7}
+	if err := sink.Deliver(context.Background(), e); err != nil || !verified {
+		t.Errorf("Deliver() = %v, verified = %v", err, verified)
+	}
+	status = http.StatusInternalServerError
+	if err := sink.Deliver(context.Background(), e); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Deliver() to a failing endpoint = %v", err)
+	}
+	if VerifySignature([]byte("other"), []byte("{}"), Sign([]byte(secret), []byte("{}"))) {
+		t.Error("VerifySignature accepted the wrong secret")
+	}
+}
@@ -0,0 +1,119 @@
+// Package events turns polling into change notifications.
+//
+// Teraswitch has no webhooks, so a Bus polls metal services and SSH keys,
+// compares them with what it saw last, and delivers an Event for each
+// change to every registered Sink: an HTTP endpoint (Webhook), a local
+// command (Exec) or a Go function (SinkFunc).
+//
+// Delivery is at least once. Events are saved to the Bus's Store before
+// they are delivered and removed only once every sink has accepted them;
+// a sink which fails is retried from the same event on the next poll, so
+// each sink sees events in order. The Store also holds the last snapshot,
+// so a restarted Bus reports only what changed while it was down.
+// WithMaxPending bounds the events kept for a failing sink, dropping the
+// oldest and counting them, at the cost of the at-least-once guarantee:
+//
+//	bus := events.NewBus(client, events.NewFileStore("/var/lib/tsw-events.json"),
+//		events.WithFilter(gotsw.ListMetalOptions{ProjectID: 480}),
+//		events.WithMaxPending(10000),
+//		events.WithSink("hook", events.Webhook("https://example.com/hook", secret)),
+//		events.WithSink("log", events.SinkFunc(func(ctx context.Context, e events.Event) error {
+//			log.Print(e.Type, e.ResourceID)
+//			return nil
+//		})),
+//	)
+//	err := bus.Run(ctx)
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Event types. Metal events are named after the gotsw.ChangeType they
+// come from.
+const (
+	MetalAdded             = "metal." + string(gotsw.ChangeAdded)
+	MetalRemoved           = "metal." + string(gotsw.ChangeRemoved)
+	MetalStatusChanged     = "metal." + string(gotsw.ChangeStatus)
+	MetalPowerStateChanged = "metal." + string(gotsw.ChangePowerState)
+	MetalRenamed           = "metal." + string(gotsw.ChangeRenamed)
+	MetalTagsChanged       = "metal." + string(gotsw.ChangeTags)
+	MetalIPsChanged        = "metal." + string(gotsw.ChangeIPs)
+	MetalProvisioningEvent = "metal." + string(gotsw.ChangeProvisioningEvent)
+
+	SSHKeyAdded   = "sshkey.Added"
+	SSHKeyRemoved = "sshkey.Removed"
+	SSHKeyRenamed = "sshkey.Renamed"
+)
+
+// Event is a change notification. It is delivered to webhooks and
+// commands as JSON.
+type Event struct {
+	ID         string    `json:"id"` // unique; the same on every delivery attempt
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"` // when the change was seen
+	ResourceID int64     `json:"resourceId"`
+
+	// Before and After are snapshots of the resource either side of the
+	// change. Before is nil when it was added and After when removed.
+	Before *Snapshot `json:"before,omitempty"`
+	After  *Snapshot `json:"after,omitempty"`
+
+	// ProvisioningEvent is set for MetalProvisioningEvent.
+	ProvisioningEvent *gotsw.ProvisioningEvent `json:"provisioningEvent,omitempty"`
+}
+
+// Snapshot is the state of the resource an event is about. Exactly one
+// field is set.
+type Snapshot struct {
+	Metal  *gotsw.Metal  `json:"metal,omitempty"`
+	SSHKey *gotsw.SSHKey `json:"sshKey,omitempty"`
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// metalEvent converts a change reported by gotsw.DiffMetal.
+func metalEvent(c gotsw.MetalChange, now time.Time) Event {
+	e := Event{
+		ID:                newID(),
+		Type:              "metal." + string(c.Type),
+		Time:              now,
+		ResourceID:        c.ID,
+		ProvisioningEvent: c.Event,
+	}
+	if c.Before != nil {
+		e.Before = &Snapshot{Metal: c.Before}
+	}
+	if c.After != nil {
+		e.After = &Snapshot{Metal: c.After}
+	}
+	return e
+}
+
+// diffSSHKey returns the events between two snapshots of an SSH key,
+// either of which may be nil.
+func diffSSHKey(before, after *gotsw.SSHKey, now time.Time) []Event {
+	e := Event{ID: newID(), Time: now}
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		e.Type, e.ResourceID, e.After = SSHKeyAdded, after.ID, &Snapshot{SSHKey: after}
+	case after == nil:
+		e.Type, e.ResourceID, e.Before = SSHKeyRemoved, before.ID, &Snapshot{SSHKey: before}
+	case before.DisplayName != after.DisplayName:
+		e.Type, e.ResourceID = SSHKeyRenamed, after.ID
+		e.Before, e.After = &Snapshot{SSHKey: before}, &Snapshot{SSHKey: after}
+	default:
+		return nil
+	}
+	return []Event{e}
+}
@@ -0,0 +1,158 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sink receives events. Deliver must return nil only once the event has
+// been accepted; an error causes it to be delivered again later.
+type Sink interface {
+	Deliver(ctx context.Context, e Event) error
+}
+
+// SinkFunc is a function used as a Sink.
+type SinkFunc func(ctx context.Context, e Event) error
+
+// Deliver calls f.
+func (f SinkFunc) Deliver(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Headers set on webhook requests.
+const (
+	HeaderEvent     = "X-Gotsw-Event"     // the event type
+	HeaderDelivery  = "X-Gotsw-Delivery"  // the event ID, for deduplication
+	HeaderSignature = "X-Gotsw-Signature" // "sha256=" and the hex HMAC-SHA256 of the body
+)
+
+// WebhookSink posts events as JSON to a URL.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// Webhook returns a sink which posts each event to url. If secret is not
+// empty, requests are signed with it; receivers check the signature with
+// VerifySignature. Any response other than 2xx is a failure.
+func Webhook(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetClient sets the HTTP client used to post events.
+func (s *WebhookSink) SetClient(client *http.Client) *WebhookSink {
+	s.client = client
+	return s
+}
+
+// Deliver posts e.
+func (s *WebhookSink) Deliver(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, e.Type)
+	req.Header.Set(HeaderDelivery, e.ID)
+	if len(s.secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(s.secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Sign returns the signature of a webhook body, as sent in the
+// X-Gotsw-Signature header.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature, the X-Gotsw-Signature
+// header of a webhook request, matches its body.
+func VerifySignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// ExecSink runs a command for each event.
+type ExecSink struct {
+	name    string
+	args    []string
+	timeout time.Duration
+}
+
+// Exec returns a sink which runs the named command for each event, with
+// the event as JSON on its standard input and GOTSW_EVENT_ID,
+// GOTSW_EVENT_TYPE and GOTSW_RESOURCE_ID in its environment. A non-zero
+// exit status is a failure. Commands are killed after a minute.
+func Exec(name string, args ...string) *ExecSink {
+	return &ExecSink{name: name, args: args, timeout: time.Minute}
+}
+
+// SetTimeout sets how long the command may run.
+func (s *ExecSink) SetTimeout(d time.Duration) *ExecSink {
+	s.timeout = d
+	return s
+}
+
+// Deliver runs the command for e.
+func (s *ExecSink) Deliver(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.name, s.args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"GOTSW_EVENT_ID="+e.ID,
+		"GOTSW_EVENT_TYPE="+e.Type,
+		"GOTSW_RESOURCE_ID="+strconv.FormatInt(e.ResourceID, 10),
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > 512 {
+				msg = msg[:512]
+			}
+			return fmt.Errorf("exec %s: %w: %s", s.name, err, msg)
+		}
+		return fmt.Errorf("exec %s: %w", s.name, err)
+	}
+	return nil
+}
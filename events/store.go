@@ -0,0 +1,124 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// State is what a Bus persists between polls: the last snapshot of each
+// resource, which acts as its cursor, and the events not yet delivered to
+// every sink.
+type State struct {
+	// Initialized is false until the first snapshot is taken. The first
+	// poll records a baseline without reporting events.
+	Initialized bool                   `json:"initialized"`
+	Metal       map[int64]gotsw.Metal  `json:"metal"`
+	SSHKeys     map[int64]gotsw.SSHKey `json:"sshKeys"`
+	Pending     []Pending              `json:"pending"`
+
+	// Dropped counts the events discarded undelivered because more than
+	// the bus's WithMaxPending were waiting.
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// Pending is an event awaiting delivery.
+type Pending struct {
+	Event     Event    `json:"event"`
+	Delivered []string `json:"delivered,omitempty"` // names of sinks which accepted it
+	Attempts  int      `json:"attempts"`
+	LastError string   `json:"lastError,omitempty"`
+}
+
+// Store persists a Bus's state.
+type Store interface {
+	// Load returns the saved state, or a zero State if none was saved.
+	Load() (*State, error)
+	Save(*State) error
+}
+
+// FileStore keeps state in a JSON file.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a Store which keeps state in the file at path. The
+// file is replaced atomically on each save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the state file. A missing file is a zero State.
+func (s *FileStore) Load() (*State, error) {
+	state := &State{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save writes the state file.
+func (s *FileStore) Save(state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// MemoryStore keeps state in memory, so it is lost on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state []byte
+}
+
+// Load returns the last saved state.
+func (s *MemoryStore) Load() (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := &State{}
+	if s.state == nil {
+		return state, nil
+	}
+	return state, json.Unmarshal(s.state, state)
+}
+
+// Save keeps a copy of state.
+func (s *MemoryStore) Save(state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = data
+	return nil
+}
@@ -6,8 +6,8 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/output"
 )
 
 func main() {
@@ -57,5 +57,7 @@ func main() {
 		return
 	}
 
-	spew.Dump(resp)
+	if err := output.Write(os.Stdout, resp.Result, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 }
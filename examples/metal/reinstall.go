@@ -6,8 +6,9 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/cloudinit"
+	"github.com/teraswitch/gotsw/v2/output"
 )
 
 func main() {
@@ -20,55 +21,66 @@ func main() {
 			slog.New(slog.NewTextHandler(os.Stdout, nil)),
 		)
 
+	metal, err := client.GetMetalService(ctx, 10346)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Mirror the two NVMe drives, with the root filesystem on the first
+	// 100GB and the rest of the space mounted at /data.
+	partitions, arrays, err := gotsw.NewStorageLayout().
+		Mirror([]string{"nvme0n1", "nvme1n1"}, 100*gotsw.Gigabyte, gotsw.FileSystemExt4, "/").
+		Mirror([]string{"nvme0n1", "nvme1n1"}, gotsw.Remainder, gotsw.FileSystemXfs, "/data").
+		Build(metal.Result.StorageDevices)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userData, err := cloudinit.New().
+		AddConfig(&cloudinit.Config{
+			WriteFiles: []cloudinit.File{
+				{Path: "/etc/motd", Content: "Hello, world!\n"},
+			},
+		}).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	resp, err := client.ReinstallMetalService(ctx, 10346, &gotsw.ReinstallMetalRequest{
 		DisplayName: "test-metal-service",
 		ImageID:     "ubuntu-noble",
 		SSHKeyIDs:   []int64{588},
-		UserData: "#cloud-config\n echo 'Hello, world!' > /etc/motd\n",
-		Partitions: []gotsw.Partition{
-			{Name: "nvme0n1-part1", Device: "nvme0n1"},
-			{Name: "nvme1n1-part1", Device: "nvme1n1"},
-		},
-		RaidArrays: []gotsw.RaidArray{
-			{
-				Name:       "md0",
-				Type:       gotsw.RaidTypeRaid1,
-				Members:    []string{"nvme0n1-part1", "nvme1n1-part1"},
-				FileSystem: gotsw.FileSystemExt4,
-				MountPoint: "/",
-			},
-		},
+		UserData:    userData,
+		Partitions:  partitions,
+		RaidArrays:  arrays,
 	})
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	spew.Dump(resp)
+	if err := output.Write(os.Stdout, resp.Result, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 
 	respWithPassword, err := client.ReinstallMetalService(ctx, 10346, &gotsw.ReinstallMetalRequest{
 		DisplayName: "test-metal-service",
 		ImageID:     "ubuntu-noble",
 		Password:    "MySecurePassword123!",
-		UserData: "#cloud-config\n echo 'Hello, world!' > /etc/motd\n",
-		Partitions: []gotsw.Partition{
-			{Name: "nvme0n1-part1", Device: "nvme0n1"},
-			{Name: "nvme1n1-part1", Device: "nvme1n1"},
-		},
-		RaidArrays: []gotsw.RaidArray{
-			{
-				Name:       "md0",
-				Type:       gotsw.RaidTypeRaid1,
-				Members:    []string{"nvme0n1-part1", "nvme1n1-part1"},
-				FileSystem: gotsw.FileSystemExt4,
-				MountPoint: "/",
-			},
-		},
+		UserData:    userData,
+		Partitions:  partitions,
+		RaidArrays:  arrays,
 	})
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	spew.Dump(respWithPassword)
+	if err := output.Write(os.Stdout, respWithPassword.Result, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 }
@@ -6,8 +6,8 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/output"
 )
 
 func main() {
@@ -20,11 +20,19 @@ func main() {
 			slog.New(slog.NewTextHandler(os.Stdout, nil)),
 		)
 
-	rename, err := client.RenameMetalService(ctx, 10346, "test")
+	if _, err := client.RenameMetalService(ctx, 10346, "test"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Renaming returns no result, so show the renamed service.
+	metal, err := client.GetMetalService(ctx, 10346)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	spew.Dump(rename)
+	if err := output.Write(os.Stdout, metal.Result, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 }
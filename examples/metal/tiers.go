@@ -6,8 +6,8 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/output"
 )
 
 func main() {
@@ -26,5 +26,7 @@ func main() {
 		return
 	}
 
-	spew.Dump(tiers)
+	if err := output.Write(os.Stdout, tiers.Result, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 }
@@ -6,8 +6,8 @@ import (
 	"log/slog"
 	"os"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/output"
 )
 
 func main() {
@@ -26,7 +26,9 @@ func main() {
 		return
 	}
 
-	spew.Dump(sshKeys)
+	if err := output.Write(os.Stdout, sshKeys, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 
 	if len(sshKeys) == 0 {
 		return
@@ -38,5 +40,7 @@ func main() {
 		return
 	}
 
-	spew.Dump(sshKey)
+	if err := output.Write(os.Stdout, sshKey, output.Options{Format: output.JSON}); err != nil {
+		fmt.Println(err)
+	}
 }
@@ -0,0 +1,102 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// ApplyOptions control how a plan is applied.
+type ApplyOptions struct {
+	// Approve is called before each destructive change, which is made
+	// only if it returns true. If Approve is nil, destructive changes
+	// are skipped.
+	Approve func(Change) bool
+}
+
+// Outcome is the result of applying one change.
+type Outcome struct {
+	Change  Change
+	Metal   *gotsw.Metal // the service after the change, if known
+	Skipped bool         // a destructive change was not approved
+	Err     error
+}
+
+// Apply makes the changes in plan in order. A failed change does not stop
+// later ones; the returned error joins every failure.
+func Apply(ctx context.Context, client *gotsw.Client, plan *Plan, opts ApplyOptions) ([]Outcome, error) {
+	outcomes := make([]Outcome, 0, len(plan.Changes))
+	var errs []error
+	for _, c := range plan.Changes {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		o := Outcome{Change: c}
+		if c.Destructive() && (opts.Approve == nil || !opts.Approve(c)) {
+			o.Skipped = true
+			outcomes = append(outcomes, o)
+			continue
+		}
+		o.Metal, o.Err = apply(ctx, client, plan.ProjectID, &c)
+		if o.Err != nil {
+			errs = append(errs, fmt.Errorf("fleet: %s: %w", c.String(), o.Err))
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, errors.Join(errs...)
+}
+
+func apply(ctx context.Context, client *gotsw.Client, projectID int64, c *Change) (*gotsw.Metal, error) {
+	srv := c.Server
+	switch c.Action {
+	case ActionCreate:
+		keys := make([]int, len(srv.SSHKeys))
+		for i, id := range srv.SSHKeys {
+			keys[i] = int(id)
+		}
+		resp, err := client.CreateMetalService(ctx, projectID, &gotsw.CreateBareMetalRequest{
+			Quantity:    1,
+			DisplayName: srv.Name,
+			RegionID:    srv.Region,
+			TierID:      srv.Tier,
+			MemoryGB:    srv.MemoryGB,
+			ImageID:     srv.Image,
+			Tags:        srv.Tags,
+			SSHKeyIDs:   keys,
+			Disks:       srv.Disks,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, errors.New(resp.Message)
+		}
+		return &resp.Result, nil
+
+	case ActionRename:
+		if _, err := client.RenameMetalService(ctx, c.Current.ID, srv.Name); err != nil {
+			return nil, err
+		}
+		m := *c.Current
+		m.DisplayName = srv.Name
+		return &m, nil
+
+	case ActionReinstall:
+		resp, err := client.ReinstallMetalService(ctx, c.Current.ID, &gotsw.ReinstallMetalRequest{
+			DisplayName: srv.Name,
+			ImageID:     srv.Image,
+			SSHKeyIDs:   srv.SSHKeys,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, errors.New(resp.Message)
+		}
+		return &resp.Result, nil
+	}
+	return nil, fmt.Errorf("unknown action %q", c.Action)
+}
@@ -0,0 +1,263 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Selector chooses the metal services a batch operation runs on.
+type Selector struct {
+	// IDs, if set, limits the selection to these services. Every ID
+	// must match.
+	IDs []int64
+
+	// Filter is passed to ListMetal. Skip and Limit are ignored.
+	Filter gotsw.ListMetalOptions
+
+	// Tags, if set, limits the selection to services with all of these
+	// tags.
+	Tags []string
+}
+
+// Select returns the services matching s, terminated services excluded.
+func (s *Selector) Select(ctx context.Context, client *gotsw.Client) ([]gotsw.Metal, error) {
+	filter := s.Filter
+	filter.Skip, filter.Limit = 0, 0
+	all, err := client.ListAllMetal(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []gotsw.Metal
+	found := map[int64]bool{}
+	for _, m := range all {
+		if m.Status == gotsw.StatusTerminated || m.Deleted != nil {
+			continue
+		}
+		if len(s.IDs) > 0 && !slices.Contains(s.IDs, m.ID) {
+			continue
+		}
+		if !hasTags(m.Tags, s.Tags) {
+			continue
+		}
+		found[m.ID] = true
+		selected = append(selected, m)
+	}
+
+	var missing []error
+	for _, id := range s.IDs {
+		if !found[id] {
+			missing = append(missing, fmt.Errorf("fleet: service %d does not match the selector", id))
+		}
+	}
+	if err := errors.Join(missing...); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+func hasTags(have, want []string) bool {
+	for _, t := range want {
+		if !slices.Contains(have, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Task is an operation on one metal service.
+type Task func(ctx context.Context, client *gotsw.Client, m gotsw.Metal) error
+
+// Power returns a Task which sends cmd to each service.
+func Power(cmd gotsw.PowerCommand) Task {
+	return func(ctx context.Context, client *gotsw.Client, m gotsw.Metal) error {
+		_, err := client.SendPowerCommand(ctx, m.ID, cmd)
+		return err
+	}
+}
+
+// Reinstall returns a Task which reinstalls each service with req. The
+// service keeps its display name unless req sets one.
+func Reinstall(req gotsw.ReinstallMetalRequest) Task {
+	return func(ctx context.Context, client *gotsw.Client, m gotsw.Metal) error {
+		r := req
+		if r.DisplayName == "" {
+			r.DisplayName = m.DisplayName
+		}
+		resp, err := client.ReinstallMetalService(ctx, m.ID, &r)
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return errors.New(resp.Message)
+		}
+		return nil
+	}
+}
+
+// DefaultConcurrency is the number of services a batch works on at once
+// unless configured otherwise.
+const DefaultConcurrency = 4
+
+// RunOptions control a batch operation.
+type RunOptions struct {
+	// Concurrency is the number of services worked on at once. It
+	// defaults to DefaultConcurrency.
+	Concurrency int
+
+	// Retries is the number of times a failed task is retried on the
+	// same service. Retrying a reinstall may reinstall twice if the
+	// first attempt succeeded but its response was lost.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// retry after. It defaults to one second.
+	Backoff time.Duration
+
+	// Retryable reports whether a failure should be retried. By default
+	// every error except context cancellation is.
+	Retryable func(error) bool
+
+	// OnResult, if set, is called as each service finishes. Calls may
+	// come from several goroutines at once.
+	OnResult func(Result)
+}
+
+// Result is the outcome of a batch operation on one service.
+type Result struct {
+	Metal    gotsw.Metal
+	Attempts int // 0 if the task never started
+	Duration time.Duration
+	Err      error
+}
+
+// Report is the outcome of a batch operation, with one Result per
+// selected service in selection order.
+type Report struct {
+	Results []Result
+}
+
+// Succeeded returns the results without an error.
+func (r *Report) Succeeded() []Result {
+	var ok []Result
+	for _, res := range r.Results {
+		if res.Err == nil {
+			ok = append(ok, res)
+		}
+	}
+	return ok
+}
+
+// Failed returns the results with an error.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns the failures joined, or nil if every service succeeded.
+func (r *Report) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		errs = append(errs, fmt.Errorf("service %d (%s): %w", res.Metal.ID, res.Metal.DisplayName, res.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs task on every service chosen by sel. A failure on one service
+// does not stop the others; check the report, or the returned error,
+// which is the report's Err. The report is nil only if selection failed.
+func Run(ctx context.Context, client *gotsw.Client, sel Selector, task Task, opts RunOptions) (*Report, error) {
+	services, err := sel.Select(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	report := RunOn(ctx, client, services, task, opts)
+	return report, report.Err()
+}
+
+// RunOn runs task on each of services. When ctx is cancelled, services
+// not yet started fail with ctx.Err().
+func RunOn(ctx context.Context, client *gotsw.Client, services []gotsw.Metal, task Task, opts RunOptions) *Report {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	report := &Report{Results: make([]Result, len(services))}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(services)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := runOne(ctx, client, services[i], task, &opts)
+				report.Results[i] = res
+				if opts.OnResult != nil {
+					opts.OnResult(res)
+				}
+			}
+		}()
+	}
+
+	for i, m := range services {
+		if ctx.Err() != nil {
+			report.Results[i] = Result{Metal: m, Err: ctx.Err()}
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			report.Results[i] = Result{Metal: m, Err: ctx.Err()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return report
+}
+
+func runOne(ctx context.Context, client *gotsw.Client, m gotsw.Metal, task Task, opts *RunOptions) Result {
+	retryable := opts.Retryable
+	if retryable == nil {
+		retryable = func(err error) bool {
+			return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	start := time.Now()
+	res := Result{Metal: m}
+	for {
+		res.Attempts++
+		res.Err = task(ctx, client, m)
+		if res.Err == nil || res.Attempts > opts.Retries || !retryable(res.Err) {
+			break
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			res.Err = errors.Join(res.Err, ctx.Err())
+			res.Duration = time.Since(start)
+			return res
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+	res.Duration = time.Since(start)
+	return res
+}
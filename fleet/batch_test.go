@@ -0,0 +1,221 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestSelect(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	add := func(name, region string, status gotsw.Status, tags ...string) int64 {
+		m := testMetal(0, name)
+		m.RegionID, m.Status, m.Tags = region, status, tags
+		return srv.AddMetal(m)
+	}
+	web1 := add("web-1", "PIT1", gotsw.StatusActive, "web", "prod")
+	web2 := add("web-2", "LAX1", gotsw.StatusActive, "web")
+	db := add("db-1", "PIT1", gotsw.StatusActive, "db", "prod")
+	gone := add("web-3", "PIT1", gotsw.StatusTerminated, "web")
+
+	tests := []struct {
+		name string
+		sel  Selector
+		want []int64
+		err  string
+	}{
+		{name: "all", want: []int64{web1, web2, db}},
+		{name: "tags", sel: Selector{Tags: []string{"web", "prod"}}, want: []int64{web1}},
+		{name: "ids", sel: Selector{IDs: []int64{db, web2}}, want: []int64{web2, db}},
+		{name: "region", sel: Selector{Filter: gotsw.ListMetalOptions{Region: "PIT1"}}, want: []int64{web1, db}},
+		{name: "terminated id", sel: Selector{IDs: []int64{web1, gone}}, err: fmt.Sprintf("service %d does not match", gone)},
+		{name: "id excluded by tags", sel: Selector{IDs: []int64{db}, Tags: []string{"web"}}, err: fmt.Sprintf("service %d does not match", db)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := tt.sel.Select(context.Background(), srv.Client())
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Select() = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, m := range services {
+				got = append(got, m.ID)
+			}
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	var ids []int64
+	for i := range 3 {
+		ids = append(ids, srv.AddMetal(testMetal(0, fmt.Sprintf("web-%d", i))))
+	}
+
+	var results atomic.Int32
+	report, err := Run(context.Background(), srv.Client(), Selector{IDs: ids}, Power(gotsw.PowerCommandPowerOff), RunOptions{
+		OnResult: func(Result) { results.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded()) != 3 || results.Load() != 3 {
+		t.Errorf("succeeded = %d, OnResult calls = %d, want 3", len(report.Succeeded()), results.Load())
+	}
+	for _, id := range ids {
+		if m, _ := srv.Metal(id); m.PowerState != gotsw.PowerStateOff {
+			t.Errorf("service %d power = %s, want Off", id, m.PowerState)
+		}
+	}
+
+	report, err = Run(context.Background(), srv.Client(), Selector{IDs: ids[:1]}, Reinstall(gotsw.ReinstallMetalRequest{ImageID: "ubuntu-noble"}), RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := srv.Metal(ids[0]); m.ImageID != "ubuntu-noble" || m.DisplayName != "web-0" {
+		t.Errorf("after reinstall image = %s, name = %s", m.ImageID, m.DisplayName)
+	}
+}
+
+func TestRunOn(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	errFatal := errors.New("fatal")
+	services := make([]gotsw.Metal, 10)
+	for i := range services {
+		services[i] = testMetal(int64(i+1), fmt.Sprintf("web-%d", i))
+	}
+
+	tests := []struct {
+		name     string
+		opts     RunOptions
+		fail     func(m gotsw.Metal, attempt int) error
+		failed   []int64
+		attempts int // total task calls
+	}{
+		{
+			name:     "all succeed",
+			fail:     func(gotsw.Metal, int) error { return nil },
+			attempts: 10,
+		},
+		{
+			name: "retry until success",
+			opts: RunOptions{Retries: 2},
+			fail: func(m gotsw.Metal, attempt int) error {
+				if m.ID == 3 && attempt < 3 {
+					return errFlaky
+				}
+				return nil
+			},
+			attempts: 12,
+		},
+		{
+			name: "retries exhausted",
+			opts: RunOptions{Retries: 1},
+			fail: func(m gotsw.Metal, _ int) error {
+				if m.ID == 3 {
+					return errFlaky
+				}
+				return nil
+			},
+			failed:   []int64{3},
+			attempts: 11,
+		},
+		{
+			name: "not retryable",
+			opts: RunOptions{Retries: 5, Retryable: func(err error) bool { return err != errFatal }},
+			fail: func(m gotsw.Metal, _ int) error {
+				if m.ID%5 == 0 {
+					return errFatal
+				}
+				return nil
+			},
+			failed:   []int64{5, 10},
+			attempts: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			attempts := map[int64]int{}
+			var running, peak atomic.Int32
+			task := func(ctx context.Context, _ *gotsw.Client, m gotsw.Metal) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				attempts[m.ID]++
+				a := attempts[m.ID]
+				mu.Unlock()
+				return tt.fail(m, a)
+			}
+			opts := tt.opts
+			opts.Concurrency = 3
+			opts.Backoff = time.Millisecond
+			report := RunOn(context.Background(), nil, services, task, opts)
+
+			var failed []int64
+			for _, r := range report.Failed() {
+				failed = append(failed, r.Metal.ID)
+			}
+			if !slices.Equal(failed, tt.failed) {
+				t.Errorf("failed = %v, want %v", failed, tt.failed)
+			}
+			total := 0
+			for _, n := range attempts {
+				total += n
+			}
+			if total != tt.attempts {
+				t.Errorf("attempts = %d, want %d", total, tt.attempts)
+			}
+			if p := peak.Load(); p > 3 {
+				t.Errorf("%d tasks ran at once, want at most 3", p)
+			}
+			if (report.Err() != nil) != (len(tt.failed) > 0) {
+				t.Errorf("Err() = %v", report.Err())
+			}
+		})
+	}
+}
+
+func TestRunOnCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	services := []gotsw.Metal{testMetal(1, "a"), testMetal(2, "b")}
+	report := RunOn(ctx, nil, services, func(context.Context, *gotsw.Client, gotsw.Metal) error {
+		t.Error("task ran after cancellation")
+		return nil
+	}, RunOptions{})
+	for _, r := range report.Results {
+		if !errors.Is(r.Err, context.Canceled) || r.Attempts != 0 {
+			t.Errorf("result = %+v, want a cancelled task that never started", r)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Action is a kind of change.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionRename    Action = "rename"
+	ActionReinstall Action = "reinstall"
+)
+
+// Diff is a field whose current value differs from the spec.
+type Diff struct {
+	Field   string
+	Current string
+	Desired string
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Field, quote(d.Current), quote(d.Desired))
+}
+
+// Change is a step needed to bring a service in line with the spec.
+type Change struct {
+	Action  Action
+	Server  Server       // desired state, with defaults applied
+	Current *gotsw.Metal // nil for ActionCreate
+	Diffs   []Diff
+}
+
+// Destructive reports whether the change erases data on the server.
+// Apply asks for approval before making destructive changes.
+func (c *Change) Destructive() bool {
+	return c.Action == ActionReinstall
+}
+
+func (c *Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		return fmt.Sprintf("create %s (%s, %s, %s)", c.Server.Name, c.Server.Region, c.Server.Tier, c.Server.Image)
+	case ActionRename:
+		return fmt.Sprintf("rename %d %s -> %s", c.Current.ID, quote(c.Current.DisplayName), quote(c.Server.Name))
+	default:
+		return fmt.Sprintf("%s %d %s", c.Action, c.Current.ID, c.Server.Name)
+	}
+}
+
+// Drift is a difference between a service and the spec which cannot be
+// fixed in place, such as a different region or tier. Resolving it means
+// replacing the service by hand.
+type Drift struct {
+	Server  Server
+	Current gotsw.Metal
+	Diffs   []Diff
+}
+
+// Plan is the set of changes which reconcile a project with a spec.
+type Plan struct {
+	ProjectID int64
+	Changes   []Change
+	Drift     []Drift
+
+	// Unmanaged lists services in the project which the spec does not
+	// mention. Plans never delete services.
+	Unmanaged []gotsw.Metal
+}
+
+// Empty reports whether the plan has no changes to apply.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Destructive reports whether any change in the plan is destructive.
+func (p *Plan) Destructive() bool {
+	for i := range p.Changes {
+		if p.Changes[i].Destructive() {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a human-readable diff of the plan. Lines start with +
+// for creations, ~ for renames, ! for destructive changes and ? for drift.
+func (p *Plan) String() string {
+	var b strings.Builder
+	counts := map[Action]int{}
+	for i := range p.Changes {
+		c := &p.Changes[i]
+		counts[c.Action]++
+		switch {
+		case c.Action == ActionCreate:
+			b.WriteString("+ ")
+		case c.Destructive():
+			b.WriteString("! ")
+		default:
+			b.WriteString("~ ")
+		}
+		b.WriteString(c.String())
+		if c.Destructive() {
+			b.WriteString(" (erases all data on the server)")
+		}
+		b.WriteByte('\n')
+		for _, d := range c.Diffs {
+			fmt.Fprintf(&b, "    %s\n", d)
+		}
+	}
+	for _, d := range p.Drift {
+		fmt.Fprintf(&b, "? drift %d %s cannot be fixed in place\n", d.Current.ID, d.Server.Name)
+		for _, diff := range d.Diffs {
+			fmt.Fprintf(&b, "    %s\n", diff)
+		}
+	}
+	for _, m := range p.Unmanaged {
+		fmt.Fprintf(&b, "  unmanaged %d %s\n", m.ID, m.DisplayName)
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to rename, %d to reinstall; %d drifted, %d unmanaged.\n",
+		counts[ActionCreate], counts[ActionRename], counts[ActionReinstall], len(p.Drift), len(p.Unmanaged))
+	return b.String()
+}
+
+// MakePlan compares spec with the services in its project and returns the
+// changes needed to reconcile them. Terminated services are ignored.
+func MakePlan(ctx context.Context, client *gotsw.Client, spec *Spec) (*Plan, error) {
+	if spec.ProjectID == 0 {
+		return nil, errors.New("fleet: spec has no project_id")
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	services, err := client.ListAllMetal(ctx, gotsw.ListMetalOptions{ProjectID: spec.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+	return Compare(spec, services)
+}
+
+// Compare returns the plan which reconciles services, the current
+// services in the spec's project, with spec.
+func Compare(spec *Spec, services []gotsw.Metal) (*Plan, error) {
+	byID := map[int64]*gotsw.Metal{}
+	byName := map[string][]*gotsw.Metal{}
+	for i := range services {
+		m := &services[i]
+		if m.Status == gotsw.StatusTerminated || m.Deleted != nil {
+			continue
+		}
+		byID[m.ID] = m
+		byName[m.DisplayName] = append(byName[m.DisplayName], m)
+	}
+
+	plan := &Plan{ProjectID: spec.ProjectID}
+	claimed := map[int64]bool{}
+	var errs []error
+	for _, srv := range spec.Resolved() {
+		var current *gotsw.Metal
+		if srv.ID != 0 {
+			if current = byID[srv.ID]; current == nil {
+				errs = append(errs, fmt.Errorf("fleet: server %q: service %d not found", srv.Name, srv.ID))
+				continue
+			}
+		} else {
+			switch matches := byName[srv.Name]; len(matches) {
+			case 0:
+			case 1:
+				current = matches[0]
+			default:
+				errs = append(errs, fmt.Errorf("fleet: server %q: %d services have this name; set id to choose one", srv.Name, len(matches)))
+				continue
+			}
+		}
+
+		if current == nil {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Server: srv})
+			continue
+		}
+		if claimed[current.ID] {
+			errs = append(errs, fmt.Errorf("fleet: server %q: service %d is already matched by another server", srv.Name, current.ID))
+			continue
+		}
+		claimed[current.ID] = true
+
+		if current.DisplayName != srv.Name {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionRename,
+				Server:  srv,
+				Current: current,
+				Diffs:   []Diff{{"name", current.DisplayName, srv.Name}},
+			})
+		}
+		if current.ImageID != srv.Image {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  ActionReinstall,
+				Server:  srv,
+				Current: current,
+				Diffs:   []Diff{{"image", current.ImageID, srv.Image}},
+			})
+		}
+		if diffs := drift(srv, current); len(diffs) > 0 {
+			plan.Drift = append(plan.Drift, Drift{Server: srv, Current: *current, Diffs: diffs})
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for i := range services {
+		m := services[i]
+		if byID[m.ID] != nil && !claimed[m.ID] {
+			plan.Unmanaged = append(plan.Unmanaged, m)
+		}
+	}
+	return plan, nil
+}
+
+// drift returns the differences between srv and m which no API call can
+// fix.
+func drift(srv Server, m *gotsw.Metal) []Diff {
+	var diffs []Diff
+	if m.RegionID != srv.Region {
+		diffs = append(diffs, Diff{"region", m.RegionID, srv.Region})
+	}
+	if m.TierID != srv.Tier {
+		diffs = append(diffs, Diff{"tier", m.TierID, srv.Tier})
+	}
+	if srv.MemoryGB != 0 && int(m.MemoryGB) != srv.MemoryGB {
+		diffs = append(diffs, Diff{"memory_gb", fmt.Sprint(m.MemoryGB), fmt.Sprint(srv.MemoryGB)})
+	}
+	slots := make([]string, 0, len(srv.Disks))
+	for slot := range srv.Disks {
+		slots = append(slots, slot)
+	}
+	slices.Sort(slots)
+	for _, slot := range slots {
+		if current := m.StorageDevices[slot].Name; current != srv.Disks[slot] {
+			diffs = append(diffs, Diff{"disks." + slot, current, srv.Disks[slot]})
+		}
+	}
+	if srv.Tags != nil && !sameSet(m.Tags, srv.Tags) {
+		diffs = append(diffs, Diff{"tags", strings.Join(m.Tags, ","), strings.Join(srv.Tags, ",")})
+	}
+	return diffs
+}
+
+func sameSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
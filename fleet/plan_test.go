@@ -0,0 +1,245 @@
+package fleet
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(`
+project_id: 480
+defaults:
+  region: LAX1
+  tier: 2388g
+  image: ubuntu-noble
+  tags: [web]
+servers:
+  - name: web-1
+  - name: db-1
+    tier: 7302p
+    tags: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := spec.Resolved()
+	if len(servers) != 2 {
+		t.Fatalf("Resolved() = %d servers, want 2", len(servers))
+	}
+	if s := servers[0]; s.Region != "LAX1" || s.Tier != "2388g" || s.Image != "ubuntu-noble" || len(s.Tags) != 1 {
+		t.Errorf("servers[0] = %+v, want defaults applied", s)
+	}
+	if s := servers[1]; s.Tier != "7302p" || s.Tags == nil || len(s.Tags) != 0 {
+		t.Errorf("servers[1] = %+v, want its own tier and no tags", s)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown field", "servers:\n  - name: a\n    colour: red\n", "colour"},
+		{"missing fields", "servers:\n  - name: a\n", `server "a": region is required`},
+		{"missing name", "defaults: {region: r, tier: t, image: i}\nservers:\n  - tier: t\n", "servers[0]: name is required"},
+		{"duplicate name", "defaults: {region: r, tier: t, image: i}\nservers:\n  - name: a\n  - name: a\n", `server "a": duplicate name`},
+		{"duplicate id", "defaults: {region: r, tier: t, image: i}\nservers:\n  - {name: a, id: 1}\n  - {name: b, id: 1}\n", `server "b": duplicate id 1`},
+		{"negative memory", "defaults: {region: r, tier: t, image: i}\nservers:\n  - {name: a, memory_gb: -1}\n", "invalid memory_gb -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func testSpec(servers ...Server) *Spec {
+	return &Spec{
+		ProjectID: 1,
+		Defaults:  Server{Region: "PIT1", Tier: "7302p", Image: "debian-12"},
+		Servers:   servers,
+	}
+}
+
+func testMetal(id int64, name string) gotsw.Metal {
+	return gotsw.Metal{ID: id, DisplayName: name, Status: gotsw.StatusActive, RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12"}
+}
+
+func TestCompare(t *testing.T) {
+	reimaged := testMetal(3, "web-3")
+	reimaged.ImageID = "ubuntu-noble"
+	moved := testMetal(4, "web-4")
+	moved.RegionID = "LAX1"
+	terminated := testMetal(5, "web-5")
+	terminated.Status = gotsw.StatusTerminated
+
+	tests := []struct {
+		name     string
+		spec     *Spec
+		services []gotsw.Metal
+		want     string // Plan.String()
+		err      string
+	}{
+		{
+			name:     "in sync",
+			spec:     testSpec(Server{Name: "web-1"}),
+			services: []gotsw.Metal{testMetal(1, "web-1")},
+			want:     "Plan: 0 to create, 0 to rename, 0 to reinstall; 0 drifted, 0 unmanaged.\n",
+		},
+		{
+			name:     "create, ignoring terminated services",
+			spec:     testSpec(Server{Name: "web-5"}),
+			services: []gotsw.Metal{terminated},
+			want:     "+ create web-5 (PIT1, 7302p, debian-12)\nPlan: 1 to create, 0 to rename, 0 to reinstall; 0 drifted, 0 unmanaged.\n",
+		},
+		{
+			name:     "rename by id",
+			spec:     testSpec(Server{ID: 1, Name: "api-1"}),
+			services: []gotsw.Metal{testMetal(1, "web-1"), testMetal(2, "web-2")},
+			want: "~ rename 1 web-1 -> api-1\n    name: web-1 -> api-1\n  unmanaged 2 web-2\n" +
+				"Plan: 0 to create, 1 to rename, 0 to reinstall; 0 drifted, 1 unmanaged.\n",
+		},
+		{
+			name:     "reinstall",
+			spec:     testSpec(Server{Name: "web-3"}),
+			services: []gotsw.Metal{reimaged},
+			want: "! reinstall 3 web-3 (erases all data on the server)\n    image: ubuntu-noble -> debian-12\n" +
+				"Plan: 0 to create, 0 to rename, 1 to reinstall; 0 drifted, 0 unmanaged.\n",
+		},
+		{
+			name:     "drift",
+			spec:     testSpec(Server{Name: "web-4", Tags: []string{"web"}}),
+			services: []gotsw.Metal{moved},
+			want: "? drift 4 web-4 cannot be fixed in place\n    region: LAX1 -> PIT1\n    tags: \"\" -> web\n" +
+				"Plan: 0 to create, 0 to rename, 0 to reinstall; 1 drifted, 0 unmanaged.\n",
+		},
+		{
+			name:     "missing id",
+			spec:     testSpec(Server{ID: 9, Name: "web-9"}),
+			services: []gotsw.Metal{testMetal(1, "web-1")},
+			err:      `server "web-9": service 9 not found`,
+		},
+		{
+			name:     "ambiguous name",
+			spec:     testSpec(Server{Name: "web-1"}),
+			services: []gotsw.Metal{testMetal(1, "web-1"), testMetal(2, "web-1")},
+			err:      `server "web-1": 2 services have this name`,
+		},
+		{
+			name:     "service matched twice",
+			spec:     testSpec(Server{Name: "web-1"}, Server{ID: 1, Name: "web-2"}),
+			services: []gotsw.Metal{testMetal(1, "web-1")},
+			err:      `server "web-2": service 1 is already matched`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := Compare(tt.spec, tt.services)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Compare() = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plan.String(); got != tt.want {
+				t.Errorf("plan =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	old := testMetal(0, "old-name")
+	old.ProjectID = srv.ProjectID()
+	oldID := srv.AddMetal(old)
+	reimaged := testMetal(0, "web-3")
+	reimaged.ImageID = "ubuntu-noble"
+	reimagedID := srv.AddMetal(reimaged)
+
+	spec := testSpec(
+		Server{Name: "web-1"},
+		Server{ID: oldID, Name: "web-2"},
+		Server{Name: "web-3"},
+	)
+	spec.ProjectID = srv.ProjectID()
+	plan, err := MakePlan(ctx, client, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 3 || !plan.Destructive() {
+		t.Fatalf("plan =\n%s", plan)
+	}
+
+	// Without approval the reinstall is skipped.
+	outcomes, err := Apply(ctx, client, plan, ApplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outcomes {
+		if o.Skipped != o.Change.Destructive() {
+			t.Errorf("%s: skipped = %v", o.Change.String(), o.Skipped)
+		}
+	}
+	if m, _ := srv.Metal(oldID); m.DisplayName != "web-2" {
+		t.Errorf("renamed service is called %q, want web-2", m.DisplayName)
+	}
+	if m, _ := srv.Metal(reimagedID); m.ImageID != "ubuntu-noble" {
+		t.Errorf("unapproved reinstall changed the image to %s", m.ImageID)
+	}
+
+	plan, err = MakePlan(ctx, client, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != ActionReinstall {
+		t.Fatalf("second plan =\n%s", plan)
+	}
+	var approved []string
+	_, err = Apply(ctx, client, plan, ApplyOptions{Approve: func(c Change) bool {
+		approved = append(approved, c.String())
+		return true
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approved) != 1 {
+		t.Errorf("Approve called for %q, want one reinstall", approved)
+	}
+	if m, _ := srv.Metal(reimagedID); m.ImageID != "debian-12" {
+		t.Errorf("image after reinstall = %s, want debian-12", m.ImageID)
+	}
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	srv.SetStock("PIT1", "7302p", 0)
+
+	plan := &Plan{ProjectID: srv.ProjectID(), Changes: []Change{
+		{Action: ActionCreate, Server: Server{Name: "a", Region: "PIT1", Tier: "7302p", Image: "debian-12"}},
+		{Action: ActionCreate, Server: Server{Name: "b", Region: "LAX1", Tier: "7302p", Image: "debian-12"}},
+	}}
+	outcomes, err := Apply(context.Background(), srv.Client(), plan, ApplyOptions{})
+	if err == nil || !strings.Contains(err.Error(), "create a (PIT1") {
+		t.Fatalf("Apply() = %v, want the failed creation", err)
+	}
+	if outcomes[0].Err == nil || outcomes[1].Err != nil || outcomes[1].Metal == nil {
+		t.Errorf("outcomes = %+v, want only the first to fail", outcomes)
+	}
+}
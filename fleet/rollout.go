@@ -0,0 +1,207 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// ErrRolloutHalted is returned by Rollout when more services fail than
+// RolloutOptions.MaxFailures allows.
+var ErrRolloutHalted = errors.New("fleet: rollout halted")
+
+// HealthCheck reports whether a reinstalled service is healthy. It is
+// called with the service as it was once ready.
+type HealthCheck func(ctx context.Context, m gotsw.Metal) error
+
+// DialCheck returns a HealthCheck which connects to port on the service's
+// first IP address, such as 22 for SSH.
+func DialCheck(port int) HealthCheck {
+	return func(ctx context.Context, m gotsw.Metal) error {
+		if len(m.IPAddresses) == 0 {
+			return errors.New("service has no IP address")
+		}
+		addr := net.JoinHostPort(m.IPAddresses[0].String(), strconv.Itoa(port))
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// RolloutOptions control a rolling reinstall.
+type RolloutOptions struct {
+	// BatchSize is the number of services reinstalled at once. It
+	// defaults to 1.
+	BatchSize int
+
+	// MaxFailures is the number of failed services tolerated. The
+	// rollout halts after the batch in which it is exceeded.
+	MaxFailures int
+
+	// PollInterval is how often a service is checked while waiting for
+	// its reinstall to start and for it to become ready and healthy. It
+	// defaults to 15 seconds.
+	PollInterval time.Duration
+
+	// ReadyTimeout bounds the wait for a service to become ready after
+	// its reinstall is requested. It defaults to 30 minutes.
+	ReadyTimeout time.Duration
+
+	// HealthCheck, if set, must pass before a service counts as done.
+	// It is retried every PollInterval until it passes or HealthTimeout
+	// has elapsed, which defaults to 5 minutes.
+	HealthCheck   HealthCheck
+	HealthTimeout time.Duration
+
+	// OnResult, if set, is called as each service finishes.
+	OnResult func(Result)
+}
+
+// RolloutReport is the outcome of a rolling reinstall.
+type RolloutReport struct {
+	Report
+
+	// Halted reports whether the rollout stopped early, and Remaining
+	// lists the services it did not reach.
+	Halted    bool
+	Remaining []gotsw.Metal
+}
+
+// Rollout reinstalls the services chosen by sel with req in batches. Each
+// service in a batch is reinstalled, waited on until it has left its old
+// state and is then Active with every provisioning event complete, and
+// health checked; the next batch starts once all have finished. If the
+// total number of failures exceeds opts.MaxFailures, the rollout halts
+// with ErrRolloutHalted.
+func Rollout(ctx context.Context, client *gotsw.Client, sel Selector, req gotsw.ReinstallMetalRequest, opts RolloutOptions) (*RolloutReport, error) {
+	services, err := sel.Select(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return RolloutOn(ctx, client, services, req, opts)
+}
+
+// RolloutOn is like Rollout, but on the given services.
+func RolloutOn(ctx context.Context, client *gotsw.Client, services []gotsw.Metal, req gotsw.ReinstallMetalRequest, opts RolloutOptions) (*RolloutReport, error) {
+	size := opts.BatchSize
+	if size <= 0 {
+		size = 1
+	}
+	task := reinstallAndCheck(req, &opts)
+
+	report := &RolloutReport{}
+	failures := 0
+	for start := 0; start < len(services); start += size {
+		if err := ctx.Err(); err != nil {
+			report.Halted = true
+			report.Remaining = services[start:]
+			return report, err
+		}
+		batch := services[start:min(start+size, len(services))]
+		r := RunOn(ctx, client, batch, task, RunOptions{Concurrency: size, OnResult: opts.OnResult})
+		report.Results = append(report.Results, r.Results...)
+		failures += len(r.Failed())
+		if failures > opts.MaxFailures {
+			report.Halted = true
+			report.Remaining = services[start+len(batch):]
+			return report, fmt.Errorf("%w after %d failures: %w", ErrRolloutHalted, failures, report.Err())
+		}
+	}
+	return report, report.Err()
+}
+
+// reinstallAndCheck returns the Task run on each service of a rollout.
+func reinstallAndCheck(req gotsw.ReinstallMetalRequest, opts *RolloutOptions) Task {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	readyTimeout := opts.ReadyTimeout
+	if readyTimeout <= 0 {
+		readyTimeout = 30 * time.Minute
+	}
+	healthTimeout := opts.HealthTimeout
+	if healthTimeout <= 0 {
+		healthTimeout = 5 * time.Minute
+	}
+	reinstall := Reinstall(req)
+
+	return func(ctx context.Context, client *gotsw.Client, m gotsw.Metal) error {
+		before, err := client.GetMetalService(ctx, m.ID)
+		if err != nil {
+			return fmt.Errorf("reinstall: %w", err)
+		}
+		if err := reinstall(ctx, client, before.Result); err != nil {
+			return fmt.Errorf("reinstall: %w", err)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, readyTimeout)
+		ready, err := func() (*gotsw.Metal, error) {
+			if err := waitStarted(waitCtx, client, &before.Result, interval); err != nil {
+				return nil, err
+			}
+			return client.WaitMetalReady(waitCtx, m.ID, interval)
+		}()
+		cancel()
+		if err != nil {
+			return fmt.Errorf("wait for ready: %w", err)
+		}
+
+		if opts.HealthCheck == nil {
+			return nil
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+		defer cancel()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := opts.HealthCheck(checkCtx, *ready)
+			if err == nil {
+				return nil
+			}
+			select {
+			case <-checkCtx.Done():
+				return fmt.Errorf("health check: %w", err)
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// waitStarted polls the service every interval until its reinstall has
+// visibly started: it has left Active, or its provisioning events differ
+// from before. Until then the API may still report the old installation,
+// which WaitMetalReady would take as ready.
+func waitStarted(ctx context.Context, client *gotsw.Client, before *gotsw.Metal, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		resp, err := client.GetMetalService(ctx, before.ID)
+		if err != nil {
+			return err
+		}
+		if m := &resp.Result; m.Status != gotsw.StatusActive || !sameEvents(m.Events, before.Events) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for service %d to start reinstalling: %w", before.ID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func sameEvents(a, b []gotsw.ProvisioningEvent) bool {
+	return slices.EqualFunc(a, b, func(x, y gotsw.ProvisioningEvent) bool {
+		return x.Priority == y.Priority && x.Body == y.Body && x.State == y.State && x.Timestamp.Equal(y.Timestamp)
+	})
+}
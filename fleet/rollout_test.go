@@ -0,0 +1,137 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+// staleReads returns a middleware which answers the first n
+// GetMetalService calls after each reinstall with the service as it was
+// in services, as the API may while a reinstall is being queued. A
+// negative n keeps answering with the old state.
+func staleReads(n int, services []gotsw.Metal) gotsw.Middleware {
+	var mu sync.Mutex
+	old := map[int64]gotsw.Metal{}
+	for _, m := range services {
+		old[m.ID] = m
+	}
+	left := map[int64]int{}
+	return func(next gotsw.Handler) gotsw.Handler {
+		return func(ctx context.Context, op *gotsw.Operation) error {
+			err := next(ctx, op)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			resp, ok := op.Result.(*gotsw.MetalResponse)
+			if !ok {
+				return nil
+			}
+			switch op.Name {
+			case "GetMetalService":
+				id := resp.Result.ID
+				if left[id] != 0 {
+					left[id]--
+					resp.Result = old[id]
+				}
+			case "ReinstallMetalService":
+				left[resp.Result.ID] = n
+			}
+			return nil
+		}
+	}
+}
+
+func TestRollout(t *testing.T) {
+	errUnhealthy := errors.New("unhealthy")
+	tests := []struct {
+		name      string
+		stale     int
+		opts      RolloutOptions
+		unhealthy string // display name of a service failing its health check
+		failed    int
+		halted    bool
+		remaining int
+		err       string
+	}{
+		{name: "ready", opts: RolloutOptions{BatchSize: 2}},
+		{name: "old state reported after the reinstall", stale: 3, opts: RolloutOptions{BatchSize: 2}},
+		{
+			name:  "reinstall never starts",
+			stale: -1,
+			opts:  RolloutOptions{BatchSize: 3, MaxFailures: 3, ReadyTimeout: 20 * time.Millisecond},
+			// Every service fails, but within the failures allowed.
+			failed: 3,
+			err:    "wait for ready",
+		},
+		{
+			name:      "halt",
+			opts:      RolloutOptions{HealthTimeout: 5 * time.Millisecond},
+			unhealthy: "web-0",
+			failed:    1,
+			halted:    true,
+			remaining: 2,
+			err:       "rollout halted after 1 failures",
+		},
+		{
+			name:      "failure tolerated",
+			opts:      RolloutOptions{MaxFailures: 1, HealthTimeout: 5 * time.Millisecond},
+			unhealthy: "web-1",
+			failed:    1,
+			err:       "unhealthy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := gotswtest.NewServer(gotswtest.WithProvisionDelay(0))
+			defer srv.Close()
+			var services []gotsw.Metal
+			for _, name := range []string{"web-0", "web-1", "web-2"} {
+				m := testMetal(0, name)
+				m.Events = []gotsw.ProvisioningEvent{{Body: "Installing operating system", State: gotsw.EventStateComplete}}
+				m.ID = srv.AddMetal(m)
+				services = append(services, m)
+			}
+			client := srv.Client().Use(staleReads(tt.stale, services))
+
+			opts := tt.opts
+			opts.PollInterval = time.Millisecond
+			opts.HealthCheck = func(_ context.Context, m gotsw.Metal) error {
+				if m.ImageID != "ubuntu-noble" {
+					t.Errorf("service %d was health checked with image %s, before its reinstall", m.ID, m.ImageID)
+				}
+				if m.DisplayName == tt.unhealthy {
+					return errUnhealthy
+				}
+				return nil
+			}
+			report, err := RolloutOn(context.Background(), client, services, gotsw.ReinstallMetalRequest{ImageID: "ubuntu-noble"}, opts)
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("RolloutOn() = %v, want an error containing %q", err, tt.err)
+			}
+			if tt.stale < 0 && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("RolloutOn() = %v, want a timeout", err)
+			}
+			if tt.halted != errors.Is(err, ErrRolloutHalted) {
+				t.Errorf("RolloutOn() = %v, halted = %v", err, tt.halted)
+			}
+			if got := len(report.Failed()); got != tt.failed {
+				t.Errorf("%d failed, want %d", got, tt.failed)
+			}
+			if report.Halted != tt.halted || len(report.Remaining) != tt.remaining {
+				t.Errorf("Halted = %v with %d remaining, want %v with %d", report.Halted, len(report.Remaining), tt.halted, tt.remaining)
+			}
+		})
+	}
+}
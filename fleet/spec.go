@@ -0,0 +1,176 @@
+// Package fleet reconciles metal services with a declarative spec.
+//
+// A Spec lists the servers a project should have. MakePlan compares it
+// with the services that exist and returns the changes needed: servers to
+// create, rename or reinstall, and drift which cannot be fixed in place.
+// Apply carries out a plan, asking before each destructive change:
+//
+//	spec, err := fleet.Load("fleet.yaml")
+//	...
+//	plan, err := fleet.MakePlan(ctx, client, spec)
+//	...
+//	fmt.Print(plan)
+//	outcomes, err := fleet.Apply(ctx, client, plan, fleet.ApplyOptions{
+//		Approve: func(c fleet.Change) bool { return askUser(c) },
+//	})
+//
+// Run performs a batch operation, such as a power command or reinstall,
+// on the services chosen by a Selector, with bounded concurrency and
+// retries, and reports the outcome for each service:
+//
+//	report, err := fleet.Run(ctx, client, fleet.Selector{Tags: []string{"web"}},
+//		fleet.Power(gotsw.PowerCommandPowerOff), fleet.RunOptions{Concurrency: 8})
+package fleet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the desired state of a project's metal services:
+//
+//	project_id: 480
+//	defaults:
+//	  region: LAX1
+//	  tier: 2388g
+//	  image: ubuntu-noble
+//	  ssh_keys: [42]
+//	servers:
+//	  - name: web-1
+//	  - name: web-2
+//	    tags: [web]
+//	  - name: db-1
+//	    tier: 7302p
+//	    memory_gb: 256
+//	    disks: {nvme0n1: 3.84t, nvme1n1: 3.84t}
+//
+// Fields left empty on a server are taken from Defaults.
+type Spec struct {
+	ProjectID int64    `yaml:"project_id"`
+	Defaults  Server   `yaml:"defaults,omitempty"`
+	Servers   []Server `yaml:"servers"`
+}
+
+// Server is the desired state of one metal service. Servers are matched
+// with existing services by display name, or by ID if one is given, which
+// lets a spec adopt and rename a service.
+type Server struct {
+	ID       int64             `yaml:"id,omitempty"`
+	Name     string            `yaml:"name"`
+	Region   string            `yaml:"region,omitempty"`
+	Tier     string            `yaml:"tier,omitempty"`
+	MemoryGB int               `yaml:"memory_gb,omitempty"` // 0 selects the tier's default
+	Disks    map[string]string `yaml:"disks,omitempty"`     // drive slot to drive option
+	Image    string            `yaml:"image,omitempty"`
+	Tags     []string          `yaml:"tags,omitempty"`
+	SSHKeys  []int64           `yaml:"ssh_keys,omitempty"` // used when creating or reinstalling
+}
+
+// Load reads and validates the spec in the YAML file at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Parse parses and validates a YAML spec. Unknown fields are an error.
+func Parse(data []byte) (*Spec, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("fleet: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks that every server, with defaults applied, names a
+// region, tier and image, and that names and IDs are unique.
+func (s *Spec) Validate() error {
+	var errs []error
+	names := map[string]bool{}
+	ids := map[int64]bool{}
+	for i, srv := range s.Resolved() {
+		where := fmt.Sprintf("servers[%d]", i)
+		if srv.Name == "" {
+			errs = append(errs, fmt.Errorf("fleet: %s: name is required", where))
+		} else {
+			where = fmt.Sprintf("server %q", srv.Name)
+		}
+		if strings.ContainsAny(srv.Name, "\r\n") {
+			errs = append(errs, fmt.Errorf("fleet: %s: name contains a line break", where))
+		}
+		if srv.Name != "" && names[srv.Name] {
+			errs = append(errs, fmt.Errorf("fleet: %s: duplicate name", where))
+		}
+		names[srv.Name] = true
+		if srv.ID < 0 {
+			errs = append(errs, fmt.Errorf("fleet: %s: invalid id %d", where, srv.ID))
+		}
+		if srv.ID > 0 && ids[srv.ID] {
+			errs = append(errs, fmt.Errorf("fleet: %s: duplicate id %d", where, srv.ID))
+		}
+		ids[srv.ID] = true
+		if srv.Region == "" {
+			errs = append(errs, fmt.Errorf("fleet: %s: region is required", where))
+		}
+		if srv.Tier == "" {
+			errs = append(errs, fmt.Errorf("fleet: %s: tier is required", where))
+		}
+		if srv.Image == "" {
+			errs = append(errs, fmt.Errorf("fleet: %s: image is required", where))
+		}
+		if srv.MemoryGB < 0 {
+			errs = append(errs, fmt.Errorf("fleet: %s: invalid memory_gb %d", where, srv.MemoryGB))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resolved returns the servers with Defaults applied.
+func (s *Spec) Resolved() []Server {
+	servers := make([]Server, len(s.Servers))
+	for i, srv := range s.Servers {
+		servers[i] = srv.withDefaults(s.Defaults)
+	}
+	return servers
+}
+
+func (s Server) withDefaults(d Server) Server {
+	if s.Region == "" {
+		s.Region = d.Region
+	}
+	if s.Tier == "" {
+		s.Tier = d.Tier
+	}
+	if s.MemoryGB == 0 {
+		s.MemoryGB = d.MemoryGB
+	}
+	if s.Disks == nil {
+		s.Disks = d.Disks
+	}
+	if s.Image == "" {
+		s.Image = d.Image
+	}
+	if s.Tags == nil {
+		s.Tags = d.Tags
+	}
+	if s.SSHKeys == nil {
+		s.SSHKeys = d.SSHKeys
+	}
+	return s
+}
@@ -2,4 +2,4 @@ module github.com/teraswitch/gotsw/v2
 
 go 1.22
 
-require github.com/davecgh/go-spew v1.1.1
+require gopkg.in/yaml.v3 v3.0.1
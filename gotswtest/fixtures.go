@@ -0,0 +1,131 @@
+package gotswtest
+
+import "github.com/teraswitch/gotsw/v2"
+
+// Image is an OS image served by the fake server.
+type Image struct {
+	ID                         string `json:"id"`
+	DisplayName                string `json:"displayName"`
+	OperatingSystemName        string `json:"operatingSystemName"`
+	OperatingSystemVersion     string `json:"operatingSystemVersion"`
+	DisableCustomizableStorage bool   `json:"disableCustomizableStorage"`
+}
+
+// regions are the regions known to the fake server.
+var regions = map[string]gotsw.Region{
+	"LAX1": newRegion("LAX1", "Los Angeles", "US", "Los Angeles, CA"),
+	"PIT1": newRegion("PIT1", "Pittsburgh", "US", "Pittsburgh, PA"),
+	"SLC1": newRegion("SLC1", "Salt Lake City", "US", "Salt Lake City, UT"),
+}
+
+func newRegion(id, city, country, location string) gotsw.Region {
+	return gotsw.Region{
+		ID:       &id,
+		Name:     &id,
+		Country:  &country,
+		City:     &city,
+		Location: &location,
+	}
+}
+
+// DefaultImages returns the OS images served by a server created without
+// WithImages.
+func DefaultImages() []Image {
+	return []Image{
+		{ID: "ubuntu-noble", DisplayName: "Ubuntu 24.04 LTS", OperatingSystemName: "Ubuntu", OperatingSystemVersion: "24.04 LTS"},
+		{ID: "ubuntu-jammy", DisplayName: "Ubuntu 22.04 LTS", OperatingSystemName: "Ubuntu", OperatingSystemVersion: "22.04 LTS"},
+		{ID: "debian-12", DisplayName: "Debian 12", OperatingSystemName: "Debian", OperatingSystemVersion: "12"},
+		{ID: "windows-2022", DisplayName: "Windows Server 2022", OperatingSystemName: "Windows Server", OperatingSystemVersion: "2022 Standard", DisableCustomizableStorage: true},
+		{ID: "ipxe", DisplayName: "Custom iPXE", OperatingSystemName: "iPXE", DisableCustomizableStorage: true},
+	}
+}
+
+// DefaultTiers returns the metal tiers served by a server created without
+// WithTiers.
+func DefaultTiers() []gotsw.MetalTier {
+	nvme := func(name string, capacityGB int, monthly float64, def bool) gotsw.MetalStorageDevice {
+		return gotsw.MetalStorageDevice{
+			Name:         name,
+			Default:      def,
+			Type:         gotsw.StorageTypeNVME,
+			CapacityGB:   capacityGB,
+			MonthlyPrice: monthly,
+			HourlyPrice:  monthly / 730,
+		}
+	}
+	slots := func(required ...bool) []gotsw.DriveSlot {
+		var out []gotsw.DriveSlot
+		for i, req := range required {
+			out = append(out, gotsw.DriveSlot{
+				ID:       "nvme" + string(rune('0'+i)) + "n1",
+				Default:  "960g",
+				Required: req,
+				Options: []gotsw.MetalStorageDevice{
+					nvme("960g", 960, 0, true),
+					nvme("1.92t", 1920, 20, false),
+					nvme("3.84t", 3840, 45, false),
+				},
+			})
+		}
+		return out
+	}
+	network := []gotsw.NetworkOption{
+		{SpeedGbps: 10, Default: true},
+		{SpeedGbps: 25, MonthlyPrice: 50, HourlyPrice: 50.0 / 730},
+	}
+
+	return []gotsw.MetalTier{
+		{
+			ID:             "2388g",
+			CPU:            "Intel Xeon E-2388G",
+			CPUDescription: "8c / 16t",
+			Availability: map[string]*gotsw.ServiceAvailability{
+				"LAX1": {MaxQuantity: 5},
+				"SLC1": {MaxQuantity: 2},
+			},
+			MemoryOptions: []gotsw.MemoryOption{
+				{GB: 64, Default: true},
+				{GB: 128, MonthlyPrice: 40, HourlyPrice: 40.0 / 730},
+			},
+			DriveSlots:     slots(true, false),
+			NetworkOptions: network,
+			MonthlyPrice:   199,
+			HourlyPrice:    199.0 / 730,
+			TierType:       gotsw.MetalTierTypeCompute,
+		},
+		{
+			ID:             "7302p",
+			CPU:            "AMD EPYC 7302P",
+			CPUDescription: "16c / 32t",
+			Availability: map[string]*gotsw.ServiceAvailability{
+				"LAX1": {MaxQuantity: 3},
+				"PIT1": {MaxQuantity: 10},
+			},
+			MemoryOptions: []gotsw.MemoryOption{
+				{GB: 128, Default: true},
+				{GB: 256, MonthlyPrice: 80, HourlyPrice: 80.0 / 730},
+			},
+			DriveSlots:     slots(true, true),
+			NetworkOptions: network,
+			MonthlyPrice:   349,
+			HourlyPrice:    349.0 / 730,
+			TierType:       gotsw.MetalTierTypeCompute,
+		},
+		{
+			ID:             "l40s",
+			CPU:            "AMD EPYC 9354",
+			CPUDescription: "32c / 64t",
+			Availability: map[string]*gotsw.ServiceAvailability{
+				"PIT1": {MaxQuantity: 0},
+			},
+			MemoryOptions: []gotsw.MemoryOption{
+				{GB: 384, Default: true},
+			},
+			DriveSlots:     slots(true, true),
+			NetworkOptions: network,
+			MonthlyPrice:   1899,
+			HourlyPrice:    1899.0 / 730,
+			TierType:       gotsw.MetalTierTypeGPU,
+		},
+	}
+}
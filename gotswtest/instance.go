@@ -0,0 +1,212 @@
+package gotswtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Instance is a cloud service (virtual machine) served by the fake server.
+type Instance struct {
+	ID          int64            `json:"id"`
+	Created     string           `json:"created"`
+	Deleted     *string          `json:"deleted"`
+	ProjectID   int64            `json:"projectId"`
+	Status      gotsw.Status     `json:"status"`
+	RegionID    string           `json:"regionId"`
+	TierID      string           `json:"tierId"`
+	DisplayName string           `json:"displayName"`
+	Tags        []string         `json:"tags"`
+	ObjectType  string           `json:"objectType"`
+	ImageID     string           `json:"imageId"`
+	PowerState  gotsw.PowerState `json:"powerState"`
+	IPAddresses []netip.Addr     `json:"ipAddresses"`
+	Tier        CloudTier        `json:"tier"`
+}
+
+// CloudTier is an instance tier served by the fake server.
+type CloudTier struct {
+	ID       string `json:"id"`
+	Memory   int32  `json:"memory"`
+	VCPUs    int32  `json:"vcpus"`
+	Transfer int32  `json:"transfer"`
+	Hidden   bool   `json:"hidden"`
+}
+
+// cloudTiers are the instance tiers known to the fake server.
+var cloudTiers = []CloudTier{
+	{ID: "c1.small", Memory: 2, VCPUs: 1, Transfer: 1000},
+	{ID: "c1.medium", Memory: 4, VCPUs: 2, Transfer: 2000},
+	{ID: "c1.large", Memory: 8, VCPUs: 4, Transfer: 4000},
+}
+
+type instanceRecord struct {
+	instance Instance
+	readyAt  time.Time
+}
+
+// refreshInstance activates rec once its provisioning delay has passed.
+// Callers must hold s.mu.
+func (s *Server) refreshInstance(rec *instanceRecord) {
+	if rec.instance.Status == gotsw.StatusPending && !s.now().Before(rec.readyAt) {
+		rec.instance.Status = gotsw.StatusActive
+		rec.instance.PowerState = gotsw.PowerStateOn
+	}
+}
+
+// lookupInstance returns the refreshed record for the id path value,
+// writing an error response and returning nil if it does not exist. Callers
+// must hold s.mu.
+func (s *Server) lookupInstance(w http.ResponseWriter, r *http.Request) *instanceRecord {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil
+	}
+	rec, ok := s.instances[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("instance %d not found", id))
+		return nil
+	}
+	s.refreshInstance(rec)
+	return rec
+}
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := query(r, "Status")
+	region := query(r, "Region")
+	tag := query(r, "Tag")
+
+	items := []Instance{}
+	for _, rec := range s.instances {
+		s.refreshInstance(rec)
+		in := rec.instance
+		switch {
+		case status != "" && string(in.Status) != status,
+			region != "" && in.RegionID != region,
+			tag != "" && !contains(in.Tags, tag):
+			continue
+		}
+		items = append(items, in)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	writeList(w, r, items)
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.lookupInstance(w, r); rec != nil {
+		writeResult(w, rec.instance)
+	}
+}
+
+func (s *Server) createInstance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName string   `json:"displayName"`
+		RegionID    string   `json:"regionId"`
+		TierID      string   `json:"tierId"`
+		ProjectID   int64    `json:"projectId"`
+		ImageID     string   `json:"imageId"`
+		Tags        []string `json:"tags"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := regions[req.RegionID]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown region %q", req.RegionID))
+		return
+	}
+	var tier *CloudTier
+	for i := range cloudTiers {
+		if cloudTiers[i].ID == req.TierID {
+			tier = &cloudTiers[i]
+		}
+	}
+	if tier == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown tier %q", req.TierID))
+		return
+	}
+
+	id := s.newID()
+	rec := &instanceRecord{
+		instance: Instance{
+			ID:          id,
+			Created:     s.now().Format(time.RFC3339),
+			ProjectID:   req.ProjectID,
+			Status:      gotsw.StatusPending,
+			RegionID:    req.RegionID,
+			TierID:      tier.ID,
+			DisplayName: req.DisplayName,
+			Tags:        append([]string{}, req.Tags...),
+			ObjectType:  "CLD",
+			ImageID:     req.ImageID,
+			PowerState:  gotsw.PowerStateOff,
+			IPAddresses: fakeAddrs(id),
+			Tier:        *tier,
+		},
+		readyAt: s.now().Add(s.provisionDelay),
+	}
+	if rec.instance.ProjectID == 0 {
+		rec.instance.ProjectID = s.projectID
+	}
+	s.instances[id] = rec
+	s.refreshInstance(rec)
+
+	writeResult(w, rec.instance)
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.lookupInstance(w, r)
+	if rec == nil {
+		return
+	}
+	deleted := s.now().Format(time.RFC3339)
+	rec.instance.Deleted = &deleted
+	rec.instance.Status = gotsw.StatusTerminated
+	rec.instance.PowerState = gotsw.PowerStateOff
+
+	writeResult(w, struct{}{})
+}
+
+func (s *Server) instancePowerCommand(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.lookupInstance(w, r)
+	if rec == nil {
+		return
+	}
+	if rec.instance.Status != gotsw.StatusActive {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot send power commands to an instance in status %s", rec.instance.Status))
+		return
+	}
+
+	state, ok := powerStateFor(query(r, "command"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown power command %q", query(r, "command")))
+		return
+	}
+	rec.instance.PowerState = state
+
+	writeResult(w, rec.instance)
+}
+
+func (s *Server) listInstanceTiers(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, append([]CloudTier{}, cloudTiers...))
+}
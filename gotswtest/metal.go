@@ -0,0 +1,510 @@
+package gotswtest
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// provisioningStages are the provisioning events a metal service goes
+// through before becoming active.
+var provisioningStages = []string{
+	"Order received",
+	"Allocating hardware",
+	"Installing operating system",
+	"Applying network configuration",
+}
+
+type metalRecord struct {
+	metal gotsw.Metal
+	logs  []gotsw.LogMessage
+
+	// provisioning is set while the service is being installed. Progress is
+	// derived from the time elapsed since provisionStart.
+	provisioning   bool
+	provisionStart time.Time
+	loggedStages   int
+}
+
+// AddMetal inserts a metal service directly into the server state, bypassing
+// capacity checks and provisioning. The assigned ID is returned.
+func (s *Server) AddMetal(m gotsw.Metal) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m.ID == 0 {
+		m.ID = s.newID()
+	}
+	if m.ProjectID == 0 {
+		m.ProjectID = s.projectID
+	}
+	if m.Created == "" {
+		m.Created = s.now().Format(time.RFC3339)
+	}
+	if m.ObjectType == "" {
+		m.ObjectType = "MTL"
+	}
+	s.metal[m.ID] = &metalRecord{metal: m}
+	return m.ID
+}
+
+// Metal returns the current state of a metal service.
+func (s *Server) Metal(id int64) (gotsw.Metal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.metal[id]
+	if !ok {
+		return gotsw.Metal{}, false
+	}
+	s.refreshMetal(rec)
+	return rec.metal, true
+}
+
+// refreshMetal progresses any in-flight provisioning of rec to the current
+// server time. Callers must hold s.mu.
+func (s *Server) refreshMetal(rec *metalRecord) {
+	if !rec.provisioning {
+		return
+	}
+
+	now := s.now()
+	stage := time.Duration(0)
+	completed := len(provisioningStages)
+	if s.provisionDelay > 0 {
+		stage = s.provisionDelay / time.Duration(len(provisioningStages))
+		completed = int(now.Sub(rec.provisionStart) / stage)
+	}
+
+	events := make([]gotsw.ProvisioningEvent, len(provisioningStages))
+	for i, name := range provisioningStages {
+		ts := rec.provisionStart.Add(time.Duration(i) * stage)
+		state := gotsw.EventStatePending
+		switch {
+		case i < completed:
+			state = gotsw.EventStateComplete
+		case i == completed:
+			state = gotsw.EventStateInProgress
+		}
+		events[i] = gotsw.ProvisioningEvent{
+			Priority:  int32(i),
+			Body:      name,
+			Timestamp: ts,
+			State:     state,
+		}
+
+		if state != gotsw.EventStatePending && i >= rec.loggedStages {
+			rec.logs = append(rec.logs, gotsw.LogMessage{
+				Timestamp: ts.Format(time.RFC3339Nano),
+				Name:      "provisioning",
+				Message:   name,
+			})
+			rec.loggedStages = i + 1
+		}
+	}
+	rec.metal.Events = events
+
+	if completed >= len(provisioningStages) {
+		rec.provisioning = false
+		rec.metal.Status = gotsw.StatusActive
+		rec.metal.PowerState = gotsw.PowerStateOn
+		rec.logs = append(rec.logs, gotsw.LogMessage{
+			Timestamp: rec.provisionStart.Add(s.provisionDelay).Format(time.RFC3339Nano),
+			Name:      "provisioning",
+			Message:   "Provisioning complete",
+		})
+	}
+}
+
+// startProvisioning resets rec to the beginning of provisioning. Callers
+// must hold s.mu.
+func (s *Server) startProvisioning(rec *metalRecord) {
+	rec.provisioning = true
+	rec.provisionStart = s.now()
+	rec.loggedStages = 0
+	rec.metal.Status = gotsw.StatusPending
+	rec.metal.PowerState = gotsw.PowerStateOff
+	s.refreshMetal(rec)
+}
+
+// lookupMetal returns the refreshed record for the id path value, writing an
+// error response and returning nil if it does not exist. Callers must hold
+// s.mu.
+func (s *Server) lookupMetal(w http.ResponseWriter, r *http.Request) *metalRecord {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil
+	}
+	rec, ok := s.metal[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("metal service %d not found", id))
+		return nil
+	}
+	s.refreshMetal(rec)
+	return rec
+}
+
+// tier returns the tier with the given ID, with availability reflecting the
+// current stock. Callers must hold s.mu.
+func (s *Server) tier(id string) (gotsw.MetalTier, bool) {
+	for _, t := range s.tiers {
+		if t.ID != id {
+			continue
+		}
+		t.Availability = map[string]*gotsw.ServiceAvailability{}
+		for k, qty := range s.stock {
+			if k.tier == id {
+				t.Availability[k.region] = &gotsw.ServiceAvailability{MaxQuantity: qty}
+			}
+		}
+		return t, true
+	}
+	return gotsw.MetalTier{}, false
+}
+
+func (s *Server) listMetal(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := query(r, "Status")
+	region := query(r, "Region")
+	tier := query(r, "Tier")
+	tag := query(r, "Tag")
+	projectID := query(r, "ProjectId")
+	tierType := query(r, "MetalTierType")
+
+	items := []gotsw.Metal{}
+	for _, rec := range s.metal {
+		s.refreshMetal(rec)
+		m := rec.metal
+		switch {
+		case status != "" && string(m.Status) != status,
+			region != "" && m.RegionID != region,
+			tier != "" && m.TierID != tier,
+			tag != "" && !contains(m.Tags, tag),
+			projectID != "" && fmt.Sprint(m.ProjectID) != projectID:
+			continue
+		}
+		if tierType != "" {
+			if t, ok := s.tier(m.TierID); !ok || string(t.TierType) != tierType {
+				continue
+			}
+		}
+		items = append(items, m)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	writeList(w, r, items)
+}
+
+func (s *Server) getMetal(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.lookupMetal(w, r); rec != nil {
+		writeResult(w, rec.metal)
+	}
+}
+
+func (s *Server) createMetal(w http.ResponseWriter, r *http.Request) {
+	req := &gotsw.CreateBareMetalRequest{}
+	if !readJSON(w, r, req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	region, ok := regions[req.RegionID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown region %q", req.RegionID))
+		return
+	}
+	tier, ok := s.tier(req.TierID)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown tier %q", req.TierID))
+		return
+	}
+
+	// Options the tier does not offer are refused, as by the API.
+	price := tier.MonthlyPrice
+	memory, ok := memoryOption(tier, req.MemoryGB)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("memory %dGB is not offered on tier %s", req.MemoryGB, tier.ID))
+		return
+	}
+	memoryGB := memory.GB
+	price += memory.MonthlyPrice
+
+	devices := map[string]gotsw.MetalStorageDevice{}
+	slots := map[string]bool{}
+	for _, slot := range tier.DriveSlots {
+		slots[slot.ID] = true
+		name, ok := req.Disks[slot.ID]
+		if !ok {
+			name = slot.Default
+		}
+		if name == "" {
+			continue
+		}
+		i := slices.IndexFunc(slot.Options, func(d gotsw.MetalStorageDevice) bool { return d.Name == name })
+		if i < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("drive %q is not offered in slot %s of tier %s", name, slot.ID, tier.ID))
+			return
+		}
+		opt := slot.Options[i]
+		opt.ID = slot.ID
+		opt.Details.DeviceName = slot.ID
+		devices[slot.ID] = opt
+		price += opt.MonthlyPrice
+	}
+	for slot := range req.Disks {
+		if !slots[slot] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%q is not a drive slot on tier %s", slot, tier.ID))
+			return
+		}
+	}
+
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	key := stockKey{req.RegionID, req.TierID}
+	if s.stock[key] < quantity {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("insufficient capacity: %d of tier %s available in %s", s.stock[key], req.TierID, req.RegionID))
+		return
+	}
+
+	projectID, ok := queryProjectID(w, r, s.projectID)
+	if !ok {
+		return
+	}
+
+	s.stock[key] -= quantity
+
+	var first *metalRecord
+	for i := 0; i < quantity; i++ {
+		id := s.newID()
+		monthly := price
+		rec := &metalRecord{
+			metal: gotsw.Metal{
+				ID:             id,
+				Created:        s.now().Format(time.RFC3339),
+				ObjectType:     "MTL",
+				ProjectID:      projectID,
+				DisplayName:    req.DisplayName,
+				RegionID:       req.RegionID,
+				Region:         region,
+				TierID:         tier.ID,
+				Tier:           tier,
+				MemoryGB:       int32(memoryGB),
+				ImageID:        req.ImageID,
+				StorageDevices: maps.Clone(devices),
+				IPAddresses:    fakeAddrs(id),
+				MonthlyPrice:   &monthly,
+				HourlyPrice:    price / 730,
+				Tags:           append([]string{}, req.Tags...),
+			},
+		}
+		s.metal[id] = rec
+		s.startProvisioning(rec)
+		if first == nil {
+			first = rec
+		}
+	}
+
+	writeResult(w, first.metal)
+}
+
+func (s *Server) reinstallMetal(w http.ResponseWriter, r *http.Request) {
+	req := &gotsw.ReinstallMetalRequest{}
+	if !readJSON(w, r, req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.lookupMetal(w, r)
+	if rec == nil {
+		return
+	}
+	if rec.metal.Status == gotsw.StatusTerminated {
+		writeError(w, http.StatusBadRequest, "cannot reinstall a terminated service")
+		return
+	}
+
+	if req.DisplayName != "" {
+		rec.metal.DisplayName = req.DisplayName
+	}
+	if req.ImageID != "" {
+		rec.metal.ImageID = req.ImageID
+	}
+	s.startProvisioning(rec)
+
+	writeResult(w, rec.metal)
+}
+
+func (s *Server) metalPowerCommand(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.lookupMetal(w, r)
+	if rec == nil {
+		return
+	}
+	if rec.metal.Status != gotsw.StatusActive {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot send power commands to a service in status %s", rec.metal.Status))
+		return
+	}
+
+	state, ok := powerStateFor(query(r, "command"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown power command %q", query(r, "command")))
+		return
+	}
+	rec.metal.PowerState = state
+
+	writeResult(w, rec.metal)
+}
+
+func (s *Server) metalLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.lookupMetal(w, r); rec != nil {
+		writeResult(w, append([]gotsw.LogMessage{}, rec.logs...))
+	}
+}
+
+func (s *Server) renameMetal(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec := s.lookupMetal(w, r); rec != nil {
+		rec.metal.DisplayName = req.Name
+		writeResult(w, struct{}{})
+	}
+}
+
+func (s *Server) listMetalTiers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tierType := query(r, "metalTierType")
+	items := []gotsw.MetalTier{}
+	for _, t := range s.tiers {
+		if tierType != "" && string(t.TierType) != tierType {
+			continue
+		}
+		t, _ = s.tier(t.ID)
+		items = append(items, t)
+	}
+	writeResult(w, items)
+}
+
+func (s *Server) listMetalTemplates(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, []struct{}{})
+}
+
+func (s *Server) metalAvailability(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	region := query(r, "Region")
+	tierType := query(r, "MetalTierType")
+
+	items := []gotsw.MetalConfiguration{}
+	for _, t := range s.tiers {
+		if tierType != "" && string(t.TierType) != tierType {
+			continue
+		}
+		qty, ok := s.stock[stockKey{region, t.ID}]
+		if !ok {
+			continue
+		}
+		t, _ = s.tier(t.ID)
+
+		config := gotsw.MetalConfiguration{
+			Disks:    map[string]string{},
+			Tier:     t,
+			Quantity: qty,
+		}
+		for _, opt := range t.MemoryOptions {
+			if opt.Default {
+				config.MemoryGB = opt.GB
+			}
+		}
+		for _, slot := range t.DriveSlots {
+			config.Disks[slot.ID] = slot.Default
+		}
+		items = append(items, config)
+	}
+	writeResult(w, items)
+}
+
+// powerStateFor returns the power state a service ends up in after command.
+func powerStateFor(command string) (gotsw.PowerState, bool) {
+	switch command {
+	case string(gotsw.PowerCommandPowerOn), "Reboot", "Reset":
+		return gotsw.PowerStateOn, true
+	case string(gotsw.PowerCommandPowerOff):
+		return gotsw.PowerStateOff, true
+	}
+	return "", false
+}
+
+// fakeNets are the IPv4 documentation ranges (RFC 5737) fake addresses
+// are taken from, in order.
+var fakeNets = [][3]byte{
+	{198, 51, 100},
+	{192, 0, 2},
+	{203, 0, 113},
+}
+
+// fakeAddrs returns documentation-range addresses derived from id.
+func fakeAddrs(id int64) []netip.Addr {
+	net := fakeNets[id/254%int64(len(fakeNets))]
+	v4 := netip.AddrFrom4([4]byte{net[0], net[1], net[2], byte(id%254 + 1)})
+	v6 := netip.AddrFrom16([16]byte{0x20, 0x01, 0x0d, 0xb8, 14: byte(id >> 8), 15: byte(id)})
+	return []netip.Addr{v4, v6}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryOption returns the memory option of gb on tier, or its default if
+// gb is zero. A tier without memory options offers only its base memory.
+func memoryOption(tier gotsw.MetalTier, gb int) (gotsw.MemoryOption, bool) {
+	if len(tier.MemoryOptions) == 0 {
+		return gotsw.MemoryOption{}, gb == 0
+	}
+	for _, opt := range tier.MemoryOptions {
+		if (gb == 0 && opt.Default) || (gb != 0 && opt.GB == gb) {
+			return opt, true
+		}
+	}
+	if gb == 0 {
+		return tier.MemoryOptions[0], true
+	}
+	return gotsw.MemoryOption{}, false
+}
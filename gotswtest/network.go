@@ -0,0 +1,139 @@
+package gotswtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Network is a private network served by the fake server.
+type Network struct {
+	ID              string `json:"id"`
+	RegionID        string `json:"regionId"`
+	DateTimeCreated string `json:"dateTimeCreated"`
+	DisplayName     string `json:"displayName"`
+	V4Subnet        string `json:"v4Subnet"`
+	V4SubnetMask    string `json:"v4SubnetMask"`
+
+	projectID int64
+}
+
+func (s *Server) listNetworks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, ok := queryProjectID(w, r, s.projectID)
+	if !ok {
+		return
+	}
+	var regionIDs []string
+	if v := query(r, "RegionId"); v != "" {
+		regionIDs = strings.Split(v, ",")
+	}
+
+	items := []Network{}
+	for _, n := range s.networks {
+		if n.projectID != projectID {
+			continue
+		}
+		if len(regionIDs) > 0 && !contains(regionIDs, n.RegionID) {
+			continue
+		}
+		items = append(items, *n)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	writeList(w, r, items)
+}
+
+func (s *Server) getNetwork(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := s.lookupNetwork(w, r.PathValue("networkId")); n != nil {
+		writeResult(w, *n)
+	}
+}
+
+func (s *Server) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RegionID     string `json:"regionId"`
+		DisplayName  string `json:"displayName"`
+		V4Subnet     string `json:"v4Subnet"`
+		V4SubnetMask string `json:"v4SubnetMask"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, ok := queryProjectID(w, r, s.projectID)
+	if !ok {
+		return
+	}
+	if _, ok := regions[req.RegionID]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown region %q", req.RegionID))
+		return
+	}
+
+	n := &Network{
+		ID:              fmt.Sprintf("net-%d", s.newID()),
+		RegionID:        req.RegionID,
+		DateTimeCreated: s.now().Format(time.RFC3339),
+		DisplayName:     req.DisplayName,
+		V4Subnet:        req.V4Subnet,
+		V4SubnetMask:    req.V4SubnetMask,
+		projectID:       projectID,
+	}
+	s.networks[n.ID] = n
+
+	writeResult(w, *n)
+}
+
+func (s *Server) updateNetwork(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DisplayName string `json:"displayName"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.lookupNetwork(w, query(r, "networkId"))
+	if n == nil {
+		return
+	}
+	n.DisplayName = req.DisplayName
+
+	writeResult(w, req)
+}
+
+func (s *Server) deleteNetwork(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.lookupNetwork(w, query(r, "networkId"))
+	if n == nil {
+		return
+	}
+	delete(s.networks, n.ID)
+
+	writeResult(w, struct{}{})
+}
+
+// lookupNetwork returns the network with the given ID, writing an error
+// response and returning nil if it does not exist. Callers must hold s.mu.
+func (s *Server) lookupNetwork(w http.ResponseWriter, id string) *Network {
+	n, ok := s.networks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("network %q not found", id))
+		return nil
+	}
+	return n
+}
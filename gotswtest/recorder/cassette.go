@@ -0,0 +1,162 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cassette is the on-disk representation of a set of recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded form of an http.Request.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is the recorded form of an http.Response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette from path.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path, creating parent directories as needed.
+func (c *Cassette) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// normalizeQuery returns the query string with its keys sorted.
+func normalizeQuery(rawQuery string) string {
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return q.Encode()
+}
+
+// normalizeBody compacts JSON bodies and sorts their object keys so that
+// semantically equal payloads compare equal. Non-JSON bodies are returned
+// unchanged.
+func normalizeBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(trimmed, &v); err != nil {
+		return string(body)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+// redacted replaces scrubbed values in cassettes.
+const redacted = "REDACTED"
+
+// scrubHeader returns a copy of h with the named headers redacted.
+func scrubHeader(h http.Header, names []string) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := h.Clone()
+	for _, name := range names {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// scrubBody redacts the named JSON object fields, at any depth, of a
+// normalized body. Field names are matched case-insensitively.
+func scrubBody(body string, fields []string) string {
+	if body == "" || len(fields) == 0 {
+		return body
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+	out, err := json.Marshal(scrubValue(v, fields))
+	if err != nil {
+		return body
+	}
+	return string(out)
+}
+
+func scrubValue(v any, fields []string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if child != nil && containsFold(fields, k) {
+				val[k] = redacted
+				continue
+			}
+			val[k] = scrubValue(child, fields)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = scrubValue(child, fields)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoMatch is returned by the recorder in replay mode when a request does
+// not match any recorded interaction.
+var ErrNoMatch = errors.New("recorder: no matching interaction")
@@ -0,0 +1,293 @@
+// Package recorder provides an http.RoundTripper that records interactions
+// with the Teraswitch API into cassette files and replays them in tests.
+//
+// A typical test records once against the real API and replays from then on:
+//
+//	rec, err := recorder.New("testdata/create.json", recorder.ModeAuto)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Save()
+//
+//	client := gotsw.New(os.Getenv("TSW_API_KEY")).
+//		SetClient(&http.Client{Transport: rec})
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Mode controls whether the recorder talks to the network.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the
+	// network.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request to the underlying transport and records
+	// the interaction.
+	ModeRecord
+	// ModeAuto replays when the cassette file exists and records otherwise.
+	ModeAuto
+)
+
+// DefaultScrubHeaders are the headers redacted from recorded interactions.
+var DefaultScrubHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// DefaultScrubFields are the JSON body fields redacted from recorded
+// interactions.
+var DefaultScrubFields = []string{"password"}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to record real interactions. It
+// defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithStrict makes replay fail on any request that does not exactly match
+// an unused interaction on method, path, query and body. Without it, a
+// request falls back to matching on method and path alone, and the last
+// matching interaction may be replayed more than once.
+func WithStrict() Option {
+	return func(r *Recorder) {
+		r.strict = true
+	}
+}
+
+// WithScrubHeaders adds headers to redact from recorded interactions.
+func WithScrubHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrubHeaders = append(r.scrubHeaders, names...)
+	}
+}
+
+// WithScrubFields adds JSON body fields to redact from recorded
+// interactions.
+func WithScrubFields(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrubFields = append(r.scrubFields, names...)
+	}
+}
+
+// Recorder is an http.RoundTripper which records or replays interactions.
+type Recorder struct {
+	path         string
+	mode         Mode
+	strict       bool
+	transport    http.RoundTripper
+	scrubHeaders []string
+	scrubFields  []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+var _ http.RoundTripper = &Recorder{}
+
+// New creates a Recorder backed by the cassette at path. In ModeReplay the
+// cassette must already exist.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         mode,
+		transport:    http.DefaultTransport,
+		scrubHeaders: append([]string{}, DefaultScrubHeaders...),
+		scrubFields:  append([]string{}, DefaultScrubFields...),
+		cassette:     &Cassette{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, fmt.Errorf("load cassette: %w", err)
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the mode the recorder is operating in. ModeAuto is resolved
+// to either ModeReplay or ModeRecord by New.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Save writes recorded interactions to the cassette file. It is a no-op when
+// replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Unused returns the interactions which have not been replayed yet. Strict
+// tests can assert this is empty once they finish. It returns nil when
+// recording.
+func (r *Recorder) Unused() []*Interaction {
+	if r.mode != ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []*Interaction
+	for i, in := range r.cassette.Interactions {
+		if !r.used[i] {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	recReq := Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  normalizeQuery(req.URL.RawQuery),
+		Header: scrubHeader(req.Header, r.scrubHeaders),
+		Body:   scrubBody(normalizeBody(reqBody), r.scrubFields),
+	}
+
+	if r.mode == ModeReplay {
+		in, err := r.match(recReq)
+		if err != nil {
+			return nil, err
+		}
+		return in.Response.toHTTP(req), nil
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recReq,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header, r.scrubHeaders),
+			Body:       scrubBody(normalizeBody(respBody), r.scrubFields),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// match finds the interaction to replay for req and marks it used.
+func (r *Recorder) match(req Request) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exact := func(in *Interaction) bool {
+		return in.Request.Method == req.Method &&
+			in.Request.Path == req.Path &&
+			in.Request.Query == req.Query &&
+			in.Request.Body == req.Body
+	}
+	loose := func(in *Interaction) bool {
+		return in.Request.Method == req.Method && in.Request.Path == req.Path
+	}
+
+	matchers := []func(*Interaction) bool{exact}
+	if !r.strict {
+		matchers = append(matchers, loose)
+	}
+
+	for _, matches := range matchers {
+		last := -1
+		for i, in := range r.cassette.Interactions {
+			if !matches(in) {
+				continue
+			}
+			if !r.used[i] {
+				r.used[i] = true
+				return in, nil
+			}
+			last = i
+		}
+		if last >= 0 && !r.strict {
+			return r.cassette.Interactions[last], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s %s?%s", ErrNoMatch, req.Method, req.Path, req.Query)
+}
+
+func (resp Response) toHTTP(req *http.Request) *http.Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// Bodies are normalized when recorded, so the original length no longer
+	// applies.
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(resp.Body))),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
+
+// readBody drains *body and replaces it with an in-memory copy.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil && !errors.Is(err, fs.ErrClosed) {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
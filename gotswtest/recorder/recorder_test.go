@@ -0,0 +1,174 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, `{"path":"`+r.URL.Path+`","echo":`+string(body)+`}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, rt http.RoundTripper, url, body string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer key")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	upstream := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := New(path, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("Mode() = %v, want ModeRecord", rec.Mode())
+	}
+	if _, err := post(t, rec, upstream.URL+"/v2/Metal", `{"b":1, "a":2}`); err != nil {
+		t.Fatal(err)
+	}
+	if unused := rec.Unused(); unused != nil {
+		t.Errorf("Unused() while recording = %v, want nil", unused)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := c.Interactions[0]
+	if got := in.Request.Header.Get("Authorization"); got != redacted {
+		t.Errorf("recorded Authorization = %q, want %q", got, redacted)
+	}
+	if got := in.Response.Header.Get("Set-Cookie"); got != redacted {
+		t.Errorf("recorded Set-Cookie = %q, want %q", got, redacted)
+	}
+	if in.Request.Body != `{"a":2,"b":1}` {
+		t.Errorf("recorded body = %s, want it normalized", in.Request.Body)
+	}
+
+	upstream.Close()
+	replay, err := New(path, ModeAuto, WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Mode() != ModeReplay {
+		t.Fatalf("Mode() = %v, want ModeReplay", replay.Mode())
+	}
+	if len(replay.Unused()) != 1 {
+		t.Fatalf("Unused() before replay = %d interactions, want 1", len(replay.Unused()))
+	}
+	body, err := post(t, replay, upstream.URL+"/v2/Metal", `{"a":2,"b":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, `"path":"/v2/Metal"`) {
+		t.Errorf("replayed body = %s", body)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("Unused() after replay = %d interactions, want 0", len(unused))
+	}
+	if _, err := post(t, replay, upstream.URL+"/v2/Metal", `{"a":2,"b":1}`); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("strict replay of a used interaction: err = %v, want ErrNoMatch", err)
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	cassette := &Cassette{Interactions: []*Interaction{
+		{Request: Request{Method: "POST", Path: "/v2/Metal", Body: `{"n":1}`}, Response: Response{StatusCode: 200, Body: "first"}},
+		{Request: Request{Method: "POST", Path: "/v2/Metal", Body: `{"n":2}`}, Response: Response{StatusCode: 200, Body: "second"}},
+	}}
+	tests := []struct {
+		name    string
+		strict  bool
+		bodies  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "exact", bodies: []string{`{"n":2}`, `{"n":1}`}, want: []string{"second", "first"}},
+		{name: "loose falls back to path", bodies: []string{`{"n":3}`, `{"n":3}`, `{"n":3}`}, want: []string{"first", "second", "second"}},
+		{name: "strict rejects other bodies", strict: true, bodies: []string{`{"n":3}`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			if err := cassette.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			var opts []Option
+			if tt.strict {
+				opts = append(opts, WithStrict())
+			}
+			rec, err := New(path, ModeReplay, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, body := range tt.bodies {
+				got, err := post(t, rec, "http://api.invalid/v2/Metal", body)
+				if tt.wantErr {
+					if !errors.Is(err, ErrNoMatch) {
+						t.Fatalf("err = %v, want ErrNoMatch", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want[i] {
+					t.Errorf("request %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); err == nil {
+		t.Error("New in ModeReplay without a cassette succeeded")
+	}
+}
+
+func TestScrubBody(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{``, ``},
+		{`not json`, `not json`},
+		{`{"password":"hunter2","name":"x"}`, `{"name":"x","password":"REDACTED"}`},
+		{`{"Password":null}`, `{"Password":null}`},
+		{`{"nested":[{"PASSWORD":"a"}]}`, `{"nested":[{"PASSWORD":"REDACTED"}]}`},
+	}
+	for _, tt := range tests {
+		if got := scrubBody(tt.body, DefaultScrubFields); got != tt.want {
+			t.Errorf("scrubBody(%s) = %s, want %s", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package gotswtest provides an in-memory fake of the Teraswitch API for
+// integration tests.
+//
+// The fake serves the endpoints described in swagger.json over an
+// httptest.Server and keeps realistic state: metal services move from
+// Pending to Active as provisioning events complete, power commands flip the
+// power state and creating services consumes available capacity.
+// Creations are refused, as by the API, when capacity runs out or a
+// memory size or drive is not offered by the tier.
+//
+//	srv := gotswtest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.Client()
+//	resp, err := client.CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{...})
+package gotswtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// DefaultProvisionDelay is how long a new or reinstalled metal service takes
+// to become active.
+const DefaultProvisionDelay = 2 * time.Second
+
+// DefaultProjectID is the project the fake server operates on unless
+// configured otherwise.
+const DefaultProjectID int64 = 1
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithClock sets the function used to read the current time. The offset
+// applied by Server.Advance is added on top of it.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.clock = now
+	}
+}
+
+// WithProvisionDelay sets how long provisioning takes.
+func WithProvisionDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.provisionDelay = d
+	}
+}
+
+// WithProjectID sets the project the server operates on.
+func WithProjectID(id int64) Option {
+	return func(s *Server) {
+		s.projectID = id
+	}
+}
+
+// WithTiers replaces the default metal tiers. The availability of each tier
+// seeds the capacity of the server.
+func WithTiers(tiers ...gotsw.MetalTier) Option {
+	return func(s *Server) {
+		s.tiers = tiers
+	}
+}
+
+// WithImages replaces the default OS images.
+func WithImages(images ...Image) Option {
+	return func(s *Server) {
+		s.images = images
+	}
+}
+
+// Server is a fake Teraswitch API.
+type Server struct {
+	*httptest.Server
+
+	clock          func() time.Time
+	provisionDelay time.Duration
+	projectID      int64
+
+	mu        sync.Mutex
+	offset    time.Duration
+	nextID    int64
+	tiers     []gotsw.MetalTier
+	images    []Image
+	stock     map[stockKey]int
+	metal     map[int64]*metalRecord
+	sshKeys   map[int64]*gotsw.SSHKey
+	instances map[int64]*instanceRecord
+	networks  map[string]*Network
+	volumes   map[string]*Volume
+}
+
+type stockKey struct {
+	region string
+	tier   string
+}
+
+// NewServer starts a fake API server. Callers must Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		clock:          time.Now,
+		provisionDelay: DefaultProvisionDelay,
+		projectID:      DefaultProjectID,
+		nextID:         1000,
+		tiers:          DefaultTiers(),
+		images:         DefaultImages(),
+		stock:          map[stockKey]int{},
+		metal:          map[int64]*metalRecord{},
+		sshKeys:        map[int64]*gotsw.SSHKey{},
+		instances:      map[int64]*instanceRecord{},
+		networks:       map[string]*Network{},
+		volumes:        map[string]*Volume{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, tier := range s.tiers {
+		for region, avail := range tier.Availability {
+			if avail != nil {
+				s.stock[stockKey{region, tier.ID}] = avail.MaxQuantity
+			}
+		}
+	}
+
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// Client returns a gotsw client configured to talk to the fake server.
+func (s *Server) Client() *gotsw.Client {
+	client := gotsw.New("test:secret").SetClient(s.Server.Client())
+	client.URL = s.BaseURL()
+	return client
+}
+
+// BaseURL returns the API base URL of the fake server, including the /v2/
+// prefix.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL + "/v2/")
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// ProjectID returns the project the server operates on.
+func (s *Server) ProjectID() int64 {
+	return s.projectID
+}
+
+// Advance moves the server clock forward by d, progressing any in-flight
+// provisioning.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// SetStock sets the number of servers of tier available in region.
+func (s *Server) SetStock(region, tier string, quantity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stock[stockKey{region, tier}] = quantity
+}
+
+// Stock returns the number of servers of tier available in region.
+func (s *Server) Stock(region, tier string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stock[stockKey{region, tier}]
+}
+
+// now returns the current server time. Callers must hold s.mu.
+func (s *Server) now() time.Time {
+	return s.clock().Add(s.offset).UTC()
+}
+
+// newID returns a fresh numeric identifier. Callers must hold s.mu.
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v2/Metal", s.listMetal)
+	mux.HandleFunc("POST /v2/Metal", s.createMetal)
+	mux.HandleFunc("GET /v2/Metal/templates", s.listMetalTemplates)
+	mux.HandleFunc("GET /v2/Metal/tiers", s.listMetalTiers)
+	mux.HandleFunc("GET /v2/Metal/Availability", s.metalAvailability)
+	mux.HandleFunc("GET /v2/Metal/{id}", s.getMetal)
+	mux.HandleFunc("POST /v2/Metal/{id}/Reinstall", s.reinstallMetal)
+	mux.HandleFunc("POST /v2/Metal/{id}/PowerCommand", s.metalPowerCommand)
+	mux.HandleFunc("GET /v2/Metal/{id}/Logs", s.metalLogs)
+	mux.HandleFunc("POST /v2/Metal/{id}/rename", s.renameMetal)
+
+	mux.HandleFunc("GET /v2/SshKey", s.listSshKeys)
+	mux.HandleFunc("POST /v2/SshKey", s.createSshKey)
+	mux.HandleFunc("GET /v2/SshKey/{id}", s.getSshKey)
+
+	mux.HandleFunc("GET /v2/Image", s.listImages)
+
+	mux.HandleFunc("GET /v2/Instance", s.listInstances)
+	mux.HandleFunc("POST /v2/Instance", s.createInstance)
+	mux.HandleFunc("GET /v2/Instance/tiers", s.listInstanceTiers)
+	mux.HandleFunc("GET /v2/Instance/{id}", s.getInstance)
+	mux.HandleFunc("DELETE /v2/Instance/{id}", s.deleteInstance)
+	mux.HandleFunc("POST /v2/Instance/{id}/PowerCommand", s.instancePowerCommand)
+
+	mux.HandleFunc("GET /v2/Network", s.listNetworks)
+	mux.HandleFunc("POST /v2/Network", s.createNetwork)
+	mux.HandleFunc("PUT /v2/Network", s.updateNetwork)
+	mux.HandleFunc("DELETE /v2/Network", s.deleteNetwork)
+	mux.HandleFunc("GET /v2/Network/{networkId}", s.getNetwork)
+
+	mux.HandleFunc("GET /v2/Volume", s.listVolumes)
+	mux.HandleFunc("POST /v2/Volume", s.createVolume)
+	mux.HandleFunc("DELETE /v2/Volume", s.deleteVolume)
+	mux.HandleFunc("PUT /v2/Volume/attach", s.attachVolume)
+	mux.HandleFunc("PUT /v2/Volume/detach", s.detachVolume)
+	mux.HandleFunc("PUT /v2/Volume/extend", s.extendVolume)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not implemented by the fake server", r.Method, r.URL.Path))
+	})
+
+	return mux
+}
+
+// writeResult writes a successful API response wrapping result.
+func writeResult[T any](w http.ResponseWriter, result T) {
+	writeJSON(w, http.StatusOK, gotsw.Result[T]{Success: true, Result: result})
+}
+
+// writeList writes a successful, paginated API response. The page is taken
+// from items according to the skip and limit query parameters.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	skip := queryInt(r, "skip", 0)
+	limit := queryInt(r, "limit", 100)
+
+	page := items
+	if skip < len(page) {
+		page = page[skip:]
+	} else {
+		page = nil
+	}
+	if limit > 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	if page == nil {
+		page = []T{}
+	}
+
+	writeJSON(w, http.StatusOK, gotsw.Result[[]T]{
+		Success: true,
+		Metadata: gotsw.ListMetadata{
+			TotalCount: int32(len(items)),
+			Limit:      int32(limit),
+			Skip:       int64(skip),
+		},
+		Result: page,
+	})
+}
+
+// writeError writes a failed API response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, gotsw.Result[struct{}]{Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// readJSON decodes the request body into v, writing a 400 response and
+// returning false if it is malformed.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// pathID parses the named numeric path value, writing a 400 response and
+// returning false if it is malformed.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s %q", name, r.PathValue(name)))
+		return 0, false
+	}
+	return id, true
+}
+
+// query returns the named query parameter, matching the name
+// case-insensitively as the real API does.
+func query(r *http.Request, name string) string {
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 && strings.EqualFold(k, name) {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func queryInt(r *http.Request, name string, def int) int {
+	n, err := strconv.Atoi(query(r, name))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// queryProjectID parses the projectId query parameter, falling back to def
+// when it is absent. A 400 response is written and false returned if it is
+// malformed.
+func queryProjectID(w http.ResponseWriter, r *http.Request, def int64) (int64, bool) {
+	p := query(r, "projectId")
+	if p == "" {
+		return def, true
+	}
+	id, err := strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid projectId %q", p))
+		return 0, false
+	}
+	return id, true
+}
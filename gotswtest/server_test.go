@@ -0,0 +1,214 @@
+package gotswtest
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func TestFakeAddrs(t *testing.T) {
+	tests := []struct {
+		id   int64
+		want string
+	}{
+		{0, "198.51.100.1"},
+		{253, "198.51.100.254"},
+		{254, "192.0.2.1"},
+		{508, "203.0.113.1"},
+		{1001, "198.51.100.240"},
+		{762, "198.51.100.1"},
+	}
+	docNets := []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+	}
+	for _, tt := range tests {
+		addrs := fakeAddrs(tt.id)
+		if got := addrs[0].String(); got != tt.want {
+			t.Errorf("fakeAddrs(%d) IPv4 = %s, want %s", tt.id, got, tt.want)
+		}
+		inDocs := false
+		for _, p := range docNets {
+			inDocs = inDocs || p.Contains(addrs[0])
+		}
+		if !inDocs {
+			t.Errorf("fakeAddrs(%d) IPv4 %s is outside the documentation ranges", tt.id, addrs[0])
+		}
+		if !netip.MustParsePrefix("2001:db8::/32").Contains(addrs[1]) {
+			t.Errorf("fakeAddrs(%d) IPv6 %s is outside 2001:db8::/32", tt.id, addrs[1])
+		}
+	}
+}
+
+func TestCreateMetal(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       gotsw.CreateBareMetalRequest
+		wantErr   bool
+		wantMsg   string
+		wantStock int
+	}{
+		{
+			name:      "single",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "2388g", ImageID: "debian-12"},
+			wantStock: 4,
+		},
+		{
+			name:      "quantity",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "2388g", ImageID: "debian-12", Quantity: 3},
+			wantStock: 2,
+		},
+		{
+			name:      "insufficient capacity",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "SLC1", TierID: "2388g", ImageID: "debian-12", Quantity: 3},
+			wantErr:   true,
+			wantStock: 2,
+		},
+		{
+			name:    "unknown tier",
+			req:     gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "nope", ImageID: "debian-12"},
+			wantErr: true,
+		},
+		{
+			name:      "options",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "7302p", ImageID: "debian-12", MemoryGB: 256, Disks: map[string]string{"nvme1n1": "3.84t"}},
+			wantStock: 2,
+		},
+		{
+			name:      "memory not offered",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "7302p", ImageID: "debian-12", MemoryGB: 512},
+			wantErr:   true,
+			wantMsg:   "memory 512GB is not offered on tier 7302p",
+			wantStock: 3,
+		},
+		{
+			name:      "drive not offered",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "7302p", ImageID: "debian-12", Disks: map[string]string{"nvme0n1": "8t"}},
+			wantErr:   true,
+			wantMsg:   `drive "8t" is not offered in slot nvme0n1 of tier 7302p`,
+			wantStock: 3,
+		},
+		{
+			name:      "unknown slot",
+			req:       gotsw.CreateBareMetalRequest{RegionID: "LAX1", TierID: "7302p", ImageID: "debian-12", Disks: map[string]string{"sda": "960g"}},
+			wantErr:   true,
+			wantMsg:   `"sda" is not a drive slot on tier 7302p`,
+			wantStock: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer()
+			defer srv.Close()
+			resp, err := srv.Client().CreateMetalService(context.Background(), srv.ProjectID(), &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Success == tt.wantErr {
+				t.Fatalf("success = %v (%s), wantErr %v", resp.Success, resp.Message, tt.wantErr)
+			}
+			if !strings.Contains(resp.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want %q", resp.Message, tt.wantMsg)
+			}
+			if tt.wantStock != 0 {
+				if got := srv.Stock(tt.req.RegionID, tt.req.TierID); got != tt.wantStock {
+					t.Errorf("stock = %d, want %d", got, tt.wantStock)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateMetalQuantityDoesNotShareDevices(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	_, err := srv.Client().CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{
+		RegionID: "LAX1", TierID: "2388g", ImageID: "debian-12", Quantity: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().ListMetal(ctx, gotsw.ListMetalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := resp.Result
+	if len(services) != 2 {
+		t.Fatalf("got %d services, want 2", len(services))
+	}
+
+	srv.mu.Lock()
+	a := srv.metal[services[0].ID].metal.StorageDevices
+	b := srv.metal[services[1].ID].metal.StorageDevices
+	for k := range a {
+		delete(a, k)
+	}
+	n := len(b)
+	srv.mu.Unlock()
+	if n == 0 {
+		t.Error("services share one storage devices map")
+	}
+}
+
+func TestProvisioning(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := NewServer(WithClock(func() time.Time { return start }), WithProvisionDelay(4*time.Minute))
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.Client()
+
+	resp, err := client.CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{
+		RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := resp.Result.ID
+
+	steps := []struct {
+		advance   time.Duration
+		status    gotsw.Status
+		power     gotsw.PowerState
+		completed int
+	}{
+		{0, gotsw.StatusPending, gotsw.PowerStateOff, 0},
+		{time.Minute, gotsw.StatusPending, gotsw.PowerStateOff, 1},
+		{2 * time.Minute, gotsw.StatusPending, gotsw.PowerStateOff, 3},
+		{time.Minute, gotsw.StatusActive, gotsw.PowerStateOn, 4},
+	}
+	for i, step := range steps {
+		srv.Advance(step.advance)
+		m, ok := srv.Metal(id)
+		if !ok {
+			t.Fatalf("step %d: service %d not found", i, id)
+		}
+		if m.Status != step.status || m.PowerState != step.power {
+			t.Errorf("step %d: status %s, power %s; want %s, %s", i, m.Status, m.PowerState, step.status, step.power)
+		}
+		completed := 0
+		for _, e := range m.Events {
+			if e.State == gotsw.EventStateComplete {
+				completed++
+			}
+		}
+		if completed != step.completed {
+			t.Errorf("step %d: %d events complete, want %d", i, completed, step.completed)
+		}
+	}
+
+	if _, err := client.SendPowerCommand(ctx, id, gotsw.PowerCommandPowerOff); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := srv.Metal(id); m.PowerState != gotsw.PowerStateOff {
+		t.Errorf("power state after PowerOff = %s, want Off", m.PowerState)
+	}
+	if _, err := client.GetMetalService(ctx, 1); err == nil {
+		t.Error("GetMetalService of an unknown service succeeded")
+	}
+}
@@ -0,0 +1,96 @@
+package gotswtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// AddSshKey inserts an SSH key directly into the server state. The assigned
+// ID is returned.
+func (s *Server) AddSshKey(key gotsw.SSHKey) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key.ID == 0 {
+		key.ID = s.newID()
+	}
+	if key.ProjectID == 0 {
+		key.ProjectID = s.projectID
+	}
+	if key.Created == "" {
+		key.Created = s.now().Format(time.RFC3339)
+	}
+	key.ObjectType = "KEY"
+	s.sshKeys[key.ID] = &key
+	return key.ID
+}
+
+func (s *Server) listSshKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []gotsw.SSHKey{}
+	for _, key := range s.sshKeys {
+		items = append(items, *key)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	writeList(w, r, items)
+}
+
+func (s *Server) getSshKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.sshKeys[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("ssh key %d not found", id))
+		return
+	}
+	writeResult(w, *key)
+}
+
+func (s *Server) createSshKey(w http.ResponseWriter, r *http.Request) {
+	req := &gotsw.CreateSshKeyRequest{}
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, "key is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := &gotsw.SSHKey{
+		ID:          s.newID(),
+		Created:     s.now().Format(time.RFC3339),
+		ObjectType:  "KEY",
+		ProjectID:   req.ProjectID,
+		DisplayName: req.DisplayName,
+		Key:         req.Key,
+	}
+	if key.ProjectID == 0 {
+		key.ProjectID = s.projectID
+	}
+	s.sshKeys[key.ID] = key
+
+	writeResult(w, *key)
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeResult(w, append([]Image{}, s.images...))
+}
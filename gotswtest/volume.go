@@ -0,0 +1,243 @@
+package gotswtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Volume is a block storage volume served by the fake server.
+type Volume struct {
+	VolumeID    string             `json:"volumeId"`
+	DisplayName string             `json:"displayName"`
+	Region      string             `json:"region"`
+	Size        int32              `json:"size"`
+	Description string             `json:"description"`
+	VolumeType  string             `json:"volumeType"`
+	CreatedAt   string             `json:"createdAt"`
+	UpdatedAt   string             `json:"updatedAt"`
+	Status      string             `json:"status"`
+	Attachments []VolumeAttachment `json:"attachments"`
+
+	projectID int64
+}
+
+// VolumeAttachment records a volume being attached to an instance.
+type VolumeAttachment struct {
+	ID           string `json:"id"`
+	ServerID     int64  `json:"serverId"`
+	AttachmentID string `json:"attachmentId"`
+	AttachedAt   string `json:"attachedAt"`
+	VolumeID     string `json:"volumeId"`
+	Device       string `json:"device"`
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, ok := queryProjectID(w, r, s.projectID)
+	if !ok {
+		return
+	}
+	region := query(r, "regionId")
+
+	items := []Volume{}
+	for _, v := range s.volumes {
+		if v.projectID != projectID || (region != "" && v.Region != region) {
+			continue
+		}
+		items = append(items, *v)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].VolumeID < items[j].VolumeID })
+
+	writeList(w, r, items)
+}
+
+func (s *Server) createVolume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RegionID    string `json:"regionId"`
+		Size        int32  `json:"size"`
+		VolumeType  string `json:"volumeType"`
+		DisplayName string `json:"displayName"`
+		Description string `json:"description"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, ok := queryProjectID(w, r, s.projectID)
+	if !ok {
+		return
+	}
+	if _, ok := regions[req.RegionID]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown region %q", req.RegionID))
+		return
+	}
+	if req.Size <= 0 {
+		writeError(w, http.StatusBadRequest, "size must be positive")
+		return
+	}
+
+	now := s.now().Format(time.RFC3339)
+	v := &Volume{
+		VolumeID:    fmt.Sprintf("vol-%d", s.newID()),
+		DisplayName: req.DisplayName,
+		Region:      req.RegionID,
+		Size:        req.Size,
+		Description: req.Description,
+		VolumeType:  req.VolumeType,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Status:      "available",
+		Attachments: []VolumeAttachment{},
+		projectID:   projectID,
+	}
+	s.volumes[v.VolumeID] = v
+
+	// The create response reports size as a string.
+	writeResult(w, map[string]any{
+		"volumeId":    v.VolumeID,
+		"createdAt":   v.CreatedAt,
+		"updatedAt":   v.UpdatedAt,
+		"size":        fmt.Sprint(v.Size),
+		"status":      v.Status,
+		"description": v.Description,
+		"displayName": v.DisplayName,
+		"volumeType":  v.VolumeType,
+		"region":      v.Region,
+	})
+}
+
+func (s *Server) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RegionID string `json:"regionId"`
+		VolumeID string `json:"volumeId"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.lookupVolume(w, req.VolumeID)
+	if v == nil {
+		return
+	}
+	if len(v.Attachments) > 0 {
+		writeError(w, http.StatusBadRequest, "cannot delete an attached volume")
+		return
+	}
+	delete(s.volumes, v.VolumeID)
+
+	writeResult(w, struct{}{})
+}
+
+func (s *Server) attachVolume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		VolumeID   string `json:"volumeId"`
+		MountPoint string `json:"mountPoint"`
+		RegionID   string `json:"regionId"`
+		InstanceID int64  `json:"instanceId"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.lookupVolume(w, req.VolumeID)
+	if v == nil {
+		return
+	}
+	if _, ok := s.instances[req.InstanceID]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("instance %d not found", req.InstanceID))
+		return
+	}
+	if len(v.Attachments) > 0 {
+		writeError(w, http.StatusBadRequest, "volume is already attached")
+		return
+	}
+
+	now := s.now().Format(time.RFC3339)
+	id := fmt.Sprintf("att-%d", s.newID())
+	v.Attachments = []VolumeAttachment{{
+		ID:           id,
+		ServerID:     req.InstanceID,
+		AttachmentID: id,
+		AttachedAt:   now,
+		VolumeID:     v.VolumeID,
+		Device:       req.MountPoint,
+	}}
+	v.Status = "in-use"
+	v.UpdatedAt = now
+
+	writeResult(w, req)
+}
+
+func (s *Server) detachVolume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RegionID string `json:"regionId"`
+		VolumeID string `json:"volumeId"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.lookupVolume(w, req.VolumeID)
+	if v == nil {
+		return
+	}
+	v.Attachments = []VolumeAttachment{}
+	v.Status = "available"
+	v.UpdatedAt = s.now().Format(time.RFC3339)
+
+	writeResult(w, req)
+}
+
+func (s *Server) extendVolume(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		NewSize  int32  `json:"newSize"`
+		RegionID string `json:"regionId"`
+		VolumeID string `json:"volumeId"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.lookupVolume(w, req.VolumeID)
+	if v == nil {
+		return
+	}
+	if req.NewSize <= v.Size {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("new size %d must be larger than current size %d", req.NewSize, v.Size))
+		return
+	}
+	v.Size = req.NewSize
+	v.UpdatedAt = s.now().Format(time.RFC3339)
+
+	writeResult(w, req)
+}
+
+// lookupVolume returns the volume with the given ID, writing an error
+// response and returning nil if it does not exist. Callers must hold s.mu.
+func (s *Server) lookupVolume(w http.ResponseWriter, id string) *Volume {
+	v, ok := s.volumes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("volume %q not found", id))
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,276 @@
+package conformance
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2/internal/openapi"
+)
+
+// direction is the direction a type travels in. Nullability only matters
+// for types decoded from responses.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+var (
+	jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+type checker struct {
+	spec     *openapi.Spec
+	findings map[string]bool
+
+	// checked records the struct and schema pairs which have been compared,
+	// so shared types are reported once.
+	checked map[pair]bool
+
+	// used records the deviations which suppressed a finding.
+	used map[string]bool
+}
+
+type pair struct {
+	t   reflect.Type
+	s   *openapi.Schema
+	dir direction
+}
+
+func newChecker(spec *openapi.Spec) *checker {
+	return &checker{
+		spec:     spec,
+		findings: map[string]bool{},
+		checked:  map[pair]bool{},
+		used:     map[string]bool{},
+	}
+}
+
+func (c *checker) report(format string, args ...any) {
+	c.findings[fmt.Sprintf(format, args...)] = true
+}
+
+// root checks t against s and round-trips a sample payload through t.
+func (c *checker) root(t reflect.Type, s *openapi.Schema, dir direction) {
+	c.check(typeName(t), t, s, dir)
+	if err := c.roundTrip(t, s); err != nil {
+		c.report("%s: round trip: %v", typeName(t), err)
+	}
+}
+
+// check compares the Go type t with the schema s. where names the field
+// being checked in findings.
+func (c *checker) check(where string, t reflect.Type, s *openapi.Schema, dir direction) {
+	if s == nil {
+		return
+	}
+	nullable := s.Nullable
+	if s.Ref != "" {
+		name := s.RefName()
+		if s = c.spec.Resolve(s); s == nil {
+			c.report("%s: schema %s is not defined", where, name)
+			return
+		}
+	}
+
+	pointer := t.Kind() == reflect.Pointer
+	if pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return
+	}
+
+	// Types with their own decoding, such as time.Time and netip.Addr, are
+	// only checked for their encoded type; the round trip covers the rest.
+	if custom(t) {
+		if s.Type != "string" {
+			c.report("%s: %s decodes from a string, spec has %s", where, t, describe(s))
+		}
+		return
+	}
+
+	switch {
+	case len(s.Enum) > 0:
+		// The API encodes enums by name, so string types are accepted for
+		// integer enums.
+		if t.Kind() != reflect.String && !isInt(t) {
+			c.report("%s: %s cannot hold enum %s", where, t, describe(s))
+		}
+	case s.Type == "string":
+		if t.Kind() != reflect.String {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+		}
+	case s.Type == "integer":
+		switch {
+		case !isInt(t):
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+		case s.Format == "int64" && t.Bits() < 64:
+			c.report("%s: %s is narrower than %s", where, t, describe(s))
+		}
+	case s.Type == "number":
+		if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+		}
+	case s.Type == "boolean":
+		if t.Kind() != reflect.Bool {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+		}
+	case s.Type == "array":
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+			return
+		}
+		c.check(where+"[]", t.Elem(), s.Items, dir)
+	case s.Additional() != nil:
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+			return
+		}
+		c.check(where+"{}", t.Elem(), s.Additional(), dir)
+	case len(s.Properties.Keys) > 0:
+		if t.Kind() != reflect.Struct {
+			c.report("%s: %s cannot hold %s", where, t, describe(s))
+			return
+		}
+		c.object(t, s, dir)
+	}
+
+	if dir == response && nullable && !pointer && scalar(t) {
+		c.report("%s: nullable in spec but %s cannot be null", where, t)
+	}
+}
+
+// object compares the fields of struct t with the properties of s.
+func (c *checker) object(t reflect.Type, s *openapi.Schema, dir direction) {
+	key := pair{t, s, dir}
+	if c.checked[key] {
+		return
+	}
+	c.checked[key] = true
+
+	name := typeName(t)
+	fields := jsonFields(t)
+	for _, prop := range s.Properties.Keys {
+		f, ok := fields[prop]
+		if !ok {
+			if !c.allowed(name, prop) {
+				c.report("%s: missing field for %q", name, prop)
+			}
+			continue
+		}
+		c.check(name+"."+prop, f.Type, s.Properties.Values[prop], dir)
+	}
+	for tag, f := range fields {
+		if _, ok := s.Properties.Values[tag]; ok || c.allowed(name, tag) {
+			continue
+		}
+		if prop := foldMatch(s.Properties.Keys, tag); prop != "" {
+			c.report("%s.%s: JSON tag should be %q", name, tag, prop)
+			continue
+		}
+		c.report("%s.%s: field %s is not in the spec", name, tag, f.Name)
+	}
+}
+
+// allowed reports whether a mismatch on the field of the named Go type is
+// a known deviation.
+func (c *checker) allowed(typ, field string) bool {
+	key := typ + "." + field
+	if _, ok := deviations[key]; ok {
+		c.used[key] = true
+		return true
+	}
+	return false
+}
+
+// unusedDeviations reports deviations which no longer suppress anything,
+// so the list shrinks as the types catch up with the spec.
+func (c *checker) unusedDeviations() {
+	for key := range deviations {
+		if !c.used[key] {
+			c.report("%s: listed as a deviation but matches the spec", key)
+		}
+	}
+}
+
+// jsonFields returns the fields of struct t keyed by their JSON names.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// foldMatch returns the key which matches tag ignoring case, if any.
+func foldMatch(keys []string, tag string) string {
+	for _, k := range keys {
+		if strings.EqualFold(k, tag) {
+			return k
+		}
+	}
+	return ""
+}
+
+// typeName returns the name of t without its package path or type
+// arguments.
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	if name == "" {
+		return t.String()
+	}
+	return name
+}
+
+// describe returns a short description of a schema's type for findings.
+func describe(s *openapi.Schema) string {
+	typ := s.Type
+	if typ == "" {
+		typ = "object"
+	}
+	if s.Format != "" {
+		typ += " (" + s.Format + ")"
+	}
+	return typ
+}
+
+func custom(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(jsonUnmarshaler) || p.Implements(textUnmarshaler)
+}
+
+func isInt(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// scalar reports whether t is a type whose zero value cannot be told apart
+// from null. Strings, slices and maps are excluded as the empty value is an
+// accepted stand-in for null throughout the package.
+func scalar(t reflect.Type) bool {
+	return isInt(t) || t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 || t.Kind() == reflect.Bool
+}
@@ -0,0 +1,107 @@
+// Package conformance checks the hand-written types in the gotsw package
+// against the Teraswitch OpenAPI spec. The check runs as a test, so
+// go test ./... fails when swagger.json and the types drift apart:
+//
+//	go test ./internal/conformance
+//
+// Every schema reachable from the endpoints implemented on Client is walked
+// alongside the Go type used to decode it. JSON tags, field types and
+// nullability are compared, and a sample payload generated from the schema
+// is round-tripped through json.Unmarshal and json.Marshal. Any drift fails
+// the test.
+package conformance
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/internal/openapi"
+)
+
+// endpoint pairs an implemented endpoint with the Go types used for its
+// request and response bodies.
+type endpoint struct {
+	Method   string
+	Path     string
+	Request  any
+	Response any
+
+	// ResponseSchema names the schema of the response for endpoints whose
+	// responses are not described by the spec.
+	ResponseSchema string
+}
+
+// endpoints are the endpoints implemented by hand-written methods on Client.
+var endpoints = []endpoint{
+	{Method: "GET", Path: "/v2/Metal", Response: gotsw.ListMetalResponse{}},
+	{Method: "POST", Path: "/v2/Metal", Request: gotsw.CreateBareMetalRequest{}, Response: gotsw.MetalResponse{}},
+	{Method: "GET", Path: "/v2/Metal/templates", Response: gotsw.MetalTemplateResponse{}},
+	{Method: "GET", Path: "/v2/Metal/tiers", Response: gotsw.MetalTierResponse{}},
+	{Method: "GET", Path: "/v2/Metal/{id}", Response: gotsw.MetalResponse{}},
+	{Method: "POST", Path: "/v2/Metal/{id}/Reinstall", Request: gotsw.ReinstallMetalRequest{}, Response: gotsw.MetalResponse{}},
+	{Method: "POST", Path: "/v2/Metal/{id}/PowerCommand", Response: gotsw.MetalResponse{}},
+	{Method: "GET", Path: "/v2/Metal/{id}/Logs", Response: gotsw.LogMessageResponse{}},
+	{Method: "GET", Path: "/v2/Metal/Availability", Response: gotsw.MetalConfigurationResponse{}},
+	{Method: "POST", Path: "/v2/Metal/{id}/rename", Response: gotsw.Result[struct{}]{}},
+	{Method: "GET", Path: "/v2/SshKey", Response: gotsw.SSHKey{}, ResponseSchema: "SshKey"},
+	{Method: "POST", Path: "/v2/SshKey", Request: gotsw.CreateSshKeyRequest{}, Response: gotsw.SSHKey{}, ResponseSchema: "SshKey"},
+	{Method: "GET", Path: "/v2/SshKey/{id}", Response: gotsw.SSHKey{}, ResponseSchema: "SshKey"},
+}
+
+// findings checks every endpoint against spec and returns the drift found,
+// sorted.
+func findings(spec *openapi.Spec) []string {
+	c := newChecker(spec)
+	for _, e := range endpoints {
+		c.endpoint(e)
+	}
+	c.unusedDeviations()
+
+	out := make([]string, 0, len(c.findings))
+	for f := range c.findings {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// endpoint checks the request and response types of e.
+func (c *checker) endpoint(e endpoint) {
+	key := e.Method + " " + e.Path
+	op := c.operation(e.Method, e.Path)
+	if op == nil {
+		c.report("%s: endpoint not in spec", key)
+		return
+	}
+
+	if e.Request != nil {
+		var s *openapi.Schema
+		if op.RequestBody != nil {
+			s = op.RequestBody.Content["application/json"].Schema
+		}
+		if s == nil {
+			c.report("%s: spec has no request body", key)
+		} else {
+			c.root(reflect.TypeOf(e.Request), s, request)
+		}
+	}
+
+	var s *openapi.Schema
+	if e.ResponseSchema != "" {
+		s = &openapi.Schema{Ref: "#/components/schemas/" + e.ResponseSchema}
+	} else if resp, ok := op.Responses["200"]; ok {
+		s = resp.Content["application/json"].Schema
+	}
+	if s == nil {
+		c.report("%s: spec has no response body", key)
+		return
+	}
+	c.root(reflect.TypeOf(e.Response), s, response)
+}
+
+// operation returns the spec operation for method and path.
+func (c *checker) operation(method, path string) *openapi.Operation {
+	return c.spec.Paths.Values[path].Values[strings.ToLower(method)]
+}
@@ -0,0 +1,17 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2/internal/openapi"
+)
+
+func TestConformance(t *testing.T) {
+	spec, err := openapi.Load("../../swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings(spec) {
+		t.Error(f)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2/internal/openapi"
+)
+
+func TestExportedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"id", "ID"},
+		{"projectId", "ProjectID"},
+		{"sshKeyIds", "SSHKeyIDs"},
+		{"ipv4DefaultGateway", "IPv4DefaultGateway"},
+		{"ipxeUrl", "IPXEURL"},
+		{"memoryGb", "MemoryGB"},
+		{"MetalService", "MetalService"},
+		{"bmc-credentials", "BMCCredentials"},
+		{"HTTPStatus", "HTTPStatus"},
+		{"vcpus", "VCPUs"},
+	}
+	for _, tt := range tests {
+		if got := exportedName(tt.name); got != tt.want {
+			t.Errorf("exportedName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestArgName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"id", "id"},
+		{"instanceId", "instanceID"},
+		{"ProjectId", "projectID"},
+		{"region-id", "regionID"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := argName(tt.name); got != tt.want {
+			t.Errorf("argName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGeneratedUpToDate fails when zz_generated.go differs from what the
+// generator produces for swagger.json.
+func TestGeneratedUpToDate(t *testing.T) {
+	spec, err := openapi.Load("../../swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &generator{spec: spec, imports: map[string]bool{}}
+	src, err := g.generate("gotsw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("../../zz_generated.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Error("zz_generated.go is out of date; run go generate ./...")
+	}
+}
@@ -0,0 +1,462 @@
+// Command gen generates Go types and low-level endpoint bindings from the
+// Teraswitch OpenAPI spec. It is run through go generate from the module
+// root:
+//
+//	go generate ./...
+//
+// Schemas which already have hand-written types in the gotsw package are
+// referenced rather than regenerated, and API response envelopes are mapped
+// onto the generic Result type.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2/internal/openapi"
+)
+
+// handwritten maps schemas with hand-written Go types in the gotsw package
+// to those types.
+var handwritten = map[string]string{
+	"CreateBareMetalRequest": "CreateBareMetalRequest",
+	"DriveDetails":           "DriveDetails",
+	"DriveSlot":              "DriveSlot",
+	"EventState":             "EventState",
+	"FileSystem":             "FileSystem",
+	"ListMetadata":           "ListMetadata",
+	"LogMessage":             "LogMessage",
+	"MemoryOption":           "MemoryOption",
+	"MetalConfiguration":     "MetalConfiguration",
+	"MetalService":           "Metal",
+	"MetalStorageDevice":     "MetalStorageDevice",
+	"MetalTier":              "MetalTier",
+	"MetalTierType":          "MetalTierType",
+	"NetworkOption":          "NetworkOption",
+	"Partition":              "Partition",
+	"PowerCommand":           "PowerCommand",
+	"PowerState":             "PowerState",
+	"ProvisioningEvent":      "ProvisioningEvent",
+	"RaidArray":              "RaidArray",
+	"RaidType":               "RaidType",
+	"Region":                 "Region",
+	"ReinstallMetalRequest":  "ReinstallMetalRequest",
+	"ServiceAvailability":    "ServiceAvailability",
+	"SshKey":                 "SSHKey",
+	"Status":                 "Status",
+	"StorageType":            "StorageType",
+}
+
+// handwrittenEnums are the hand-written types which are string enums, even
+// though the spec describes them as integers.
+var handwrittenEnums = map[string]bool{
+	"EventState":    true,
+	"FileSystem":    true,
+	"MetalTierType": true,
+	"PowerCommand":  true,
+	"PowerState":    true,
+	"RaidType":      true,
+	"Status":        true,
+	"StorageType":   true,
+}
+
+// operations names the binding generated for every endpoint in the spec.
+// Generation fails for endpoints missing from this list so each new endpoint
+// gets a deliberate name.
+var operations = map[string]string{
+	"GET /v2/Image":                                  "ListImages",
+	"GET /v2/Instance":                               "ListInstances",
+	"POST /v2/Instance":                              "CreateInstance",
+	"GET /v2/Instance/{id}":                          "GetInstance",
+	"DELETE /v2/Instance/{id}":                       "DeleteInstance",
+	"GET /v2/Instance/tiers":                         "ListInstanceTiers",
+	"POST /v2/Instance/{id}/PowerCommand":            "SendInstancePowerCommand",
+	"GET /v2/Instance/{instanceId}/networks":         "ListInstanceNetworks",
+	"POST /v2/Instance/{instanceId}/networks/attach": "AttachInstanceNetwork",
+	"POST /v2/Instance/{instanceId}/networks/detach": "DetachInstanceNetwork",
+	"GET /v2/Invoice/{invoiceId}":                    "GetInvoice",
+	"GET /v2/Invoice":                                "ListInvoices",
+	"GET /v2/Metal":                                  "ListMetal",
+	"POST /v2/Metal":                                 "CreateMetal",
+	"GET /v2/Metal/templates":                        "ListMetalTemplates",
+	"GET /v2/Metal/tiers":                            "ListMetalTiers",
+	"POST /v2/Metal/{id}/Reinstall":                  "ReinstallMetal",
+	"GET /v2/Metal/{id}":                             "GetMetal",
+	"POST /v2/Metal/{id}/PowerCommand":               "SendMetalPowerCommand",
+	"GET /v2/Metal/{id}/Logs":                        "GetMetalLogs",
+	"GET /v2/Metal/Availability":                     "GetMetalAvailability",
+	"POST /v2/Metal/{id}/rename":                     "RenameMetal",
+	"GET /v2/Network/{networkId}":                    "GetNetwork",
+	"DELETE /v2/Network":                             "DeleteNetwork",
+	"PUT /v2/Network":                                "UpdateNetwork",
+	"GET /v2/Network":                                "ListNetworks",
+	"POST /v2/Network":                               "CreateNetwork",
+	"POST /v2/Price/Calculate":                       "CalculatePrice",
+	"GET /v2/Search":                                 "Search",
+	"GET /v2/SshKey":                                 "ListSshKeys",
+	"POST /v2/SshKey":                                "CreateSshKey",
+	"GET /v2/SshKey/{id}":                            "GetSshKey",
+	"GET /v2/Usage":                                  "ListUsage",
+	"GET /v2/Usage/{serviceId}":                      "GetUsage",
+	"GET /v2/Volume":                                 "ListVolumes",
+	"POST /v2/Volume":                                "CreateVolume",
+	"DELETE /v2/Volume":                              "DeleteVolume",
+	"GET /v2/Volume/list-attachable":                 "ListAttachableVolumes",
+	"GET /v2/Volume/list-attached":                   "ListAttachedVolumes",
+	"PUT /v2/Volume/attach":                          "AttachVolume",
+	"PUT /v2/Volume/detach":                          "DetachVolume",
+	"PUT /v2/Volume/extend":                          "ExtendVolume",
+}
+
+// responseOverrides supplies the result type of endpoints whose responses
+// are not described by the spec.
+var responseOverrides = map[string]string{
+	"GET /v2/SshKey":      "Result[[]SSHKey]",
+	"POST /v2/SshKey":     "Result[SSHKey]",
+	"GET /v2/SshKey/{id}": "Result[SSHKey]",
+}
+
+// envelopeFields are the properties of the standard API response envelope.
+var envelopeFields = map[string]bool{
+	"success":          true,
+	"message":          true,
+	"validationErrors": true,
+	"metadata":         true,
+	"result":           true,
+}
+
+var httpMethods = []string{"get", "post", "put", "patch", "delete"}
+
+func main() {
+	specPath := flag.String("spec", "swagger.json", "path to the OpenAPI spec")
+	out := flag.String("out", "zz_generated.go", "output file")
+	pkg := flag.String("package", "gotsw", "package name of the generated file")
+	flag.Parse()
+
+	s, err := openapi.Load(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := &generator{spec: s, imports: map[string]bool{}}
+	src, err := g.generate(*pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	spec    *openapi.Spec
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(pkg string) ([]byte, error) {
+	schemas := g.spec.Components.Schemas
+	names := append([]string{}, schemas.Keys...)
+	sort.Strings(names)
+	for _, name := range names {
+		s := schemas.Values[name]
+		if _, ok := handwritten[name]; ok || isEnvelope(s) {
+			continue
+		}
+		g.schemaType(name, s)
+	}
+
+	for _, path := range g.spec.Paths.Keys {
+		ops := g.spec.Paths.Values[path]
+		for _, method := range httpMethods {
+			op, ok := ops.Values[method]
+			if !ok {
+				continue
+			}
+			if err := g.endpoint(strings.ToUpper(method), path, op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by internal/gen from swagger.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	fmt.Fprintf(&out, "import (\n")
+	for _, imp := range []string{"context", "fmt", "net/http", "net/url"} {
+		if g.imports[imp] {
+			fmt.Fprintf(&out, "%q\n", imp)
+		}
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// isEnvelope reports whether s is an instance of the standard API response
+// envelope, which maps onto Result.
+func isEnvelope(s *openapi.Schema) bool {
+	if _, ok := s.Properties.Values["success"]; !ok {
+		return false
+	}
+	for _, k := range s.Properties.Keys {
+		if !envelopeFields[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaType emits the Go type for a named schema.
+func (g *generator) schemaType(name string, s *openapi.Schema) {
+	g.comment(name+" is generated from the "+name+" schema.", s.Description)
+
+	if len(s.Enum) > 0 {
+		base := g.goType(&openapi.Schema{Type: s.Type, Format: s.Format}, false)
+		g.printf("type %s %s\n\n", name, base)
+		if base != "string" {
+			return
+		}
+		g.printf("const (\n")
+		for _, v := range s.Enum {
+			g.printf("%s%s %s = %q\n", name, exportedName(fmt.Sprint(v)), name, fmt.Sprint(v))
+		}
+		g.printf(")\n\n")
+		return
+	}
+
+	if s.Type != "object" || len(s.Properties.Keys) == 0 {
+		g.printf("type %s %s\n\n", name, g.goType(s, false))
+		return
+	}
+
+	g.printf("type %s struct {\n", name)
+	for _, prop := range s.Properties.Keys {
+		ps := s.Properties.Values[prop]
+		if ps.Description != "" {
+			g.comment("", ps.Description)
+		}
+		typ := g.goType(ps, true)
+		tag := prop
+		if ps.Nullable || strings.HasPrefix(typ, "*") {
+			tag += ",omitempty"
+		}
+		g.printf("%s %s `json:%q`\n", exportedName(prop), typ, tag)
+	}
+	g.printf("}\n\n")
+}
+
+// comment emits a doc comment made of a summary line and an optional
+// description paragraph.
+func (g *generator) comment(summary, description string) {
+	var lines []string
+	if summary != "" {
+		lines = append(lines, summary)
+	}
+	description = strings.TrimSpace(strings.ReplaceAll(description, "\r", ""))
+	if description != "" {
+		if summary != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	for _, l := range lines {
+		g.printf("// %s\n", strings.TrimRight(l, " "))
+	}
+}
+
+// goType returns the Go type for s. Fields are given pointer types where the
+// zero value would be ambiguous or the type is self-referential.
+func (g *generator) goType(s *openapi.Schema, field bool) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		name := s.RefName()
+		target := g.spec.Components.Schemas.Values[name]
+		if typ, ok := handwritten[name]; ok {
+			if field && !handwrittenEnums[name] {
+				return "*" + typ
+			}
+			return typ
+		}
+		if target != nil && isEnvelope(target) {
+			return g.envelopeType(target)
+		}
+		if field && target != nil && len(target.Enum) == 0 {
+			return "*" + name
+		}
+		return name
+	}
+
+	var typ string
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer":
+		switch s.Format {
+		case "int32":
+			typ = "int32"
+		case "int64":
+			typ = "int64"
+		default:
+			typ = "int"
+		}
+	case "number":
+		typ = "float64"
+	case "boolean":
+		typ = "bool"
+	case "array":
+		return "[]" + g.goType(s.Items, false)
+	case "object", "":
+		if ap := s.Additional(); ap != nil {
+			return "map[string]" + g.goType(ap, false)
+		}
+		if s.Type == "" {
+			return "any"
+		}
+		return "map[string]any"
+	default:
+		return "any"
+	}
+	if field && s.Nullable {
+		return "*" + typ
+	}
+	return typ
+}
+
+// envelopeType returns the Result instantiation for an envelope schema.
+func (g *generator) envelopeType(s *openapi.Schema) string {
+	result, ok := s.Properties.Values["result"]
+	if !ok {
+		return "Result[struct{}]"
+	}
+	return "Result[" + strings.TrimPrefix(g.goType(result, false), "*") + "]"
+}
+
+// endpoint emits the binding for a single operation.
+func (g *generator) endpoint(method, path string, op *openapi.Operation) error {
+	key := method + " " + path
+	name, ok := operations[key]
+	if !ok {
+		return fmt.Errorf("no binding name configured for %s", key)
+	}
+
+	resultType, ok := responseOverrides[key]
+	if !ok {
+		resp, ok := op.Responses["200"].Content["application/json"]
+		if !ok || resp.Schema == nil {
+			return fmt.Errorf("no response type for %s", key)
+		}
+		resultType = strings.TrimPrefix(g.goType(resp.Schema, false), "*")
+	}
+
+	var pathParams, queryParams []*openapi.Parameter
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			queryParams = append(queryParams, p)
+		}
+	}
+
+	// Query parameters are gathered into a params struct.
+	paramsType := name + "Params"
+	if len(queryParams) > 0 {
+		g.printf("// %s holds the query parameters of %s. Zero values are omitted.\n", paramsType, name)
+		g.printf("type %s struct {\n", paramsType)
+		for _, p := range queryParams {
+			if p.Description != "" {
+				g.comment("", p.Description)
+			}
+			g.printf("%s %s\n", exportedName(p.Name), g.goType(p.Schema, false))
+		}
+		g.printf("}\n\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	format := strings.TrimPrefix(path, "/v2/")
+	var formatArgs []string
+	for _, p := range pathParams {
+		typ := g.goType(p.Schema, false)
+		arg := argName(p.Name)
+		args = append(args, arg+" "+typ)
+		verb := "%d"
+		value := arg
+		if typ == "string" {
+			verb = "%s"
+			value = "url.PathEscape(" + arg + ")"
+			g.imports["net/url"] = true
+		}
+		format = strings.Replace(format, "{"+p.Name+"}", verb, 1)
+		formatArgs = append(formatArgs, value)
+	}
+	if len(queryParams) > 0 {
+		args = append(args, "params "+paramsType)
+	}
+	var body string
+	if op.RequestBody != nil {
+		if mt, ok := op.RequestBody.Content["application/json"]; ok && mt.Schema != nil {
+			body = strings.TrimPrefix(g.goType(mt.Schema, false), "*")
+			args = append(args, "body *"+body)
+		}
+	}
+
+	pathExpr := fmt.Sprintf("%q", format)
+	if len(formatArgs) > 0 {
+		pathExpr = fmt.Sprintf("fmt.Sprintf(%q, %s)", format, strings.Join(formatArgs, ", "))
+		g.imports["fmt"] = true
+	}
+	g.imports["context"] = true
+	g.imports["net/http"] = true
+
+	g.comment(fmt.Sprintf("%s calls %s %s.", name, method, path), op.Summary)
+	g.printf("func (a *API) %s(%s) (*%s, error) {\n", name, strings.Join(args, ", "), resultType)
+	g.printf("resp := &%s{}\n", resultType)
+	if len(queryParams) > 0 {
+		g.imports["fmt"] = true
+		g.printf("var opts []RequestOption\n")
+		for _, p := range queryParams {
+			field := "params." + exportedName(p.Name)
+			zero := "0"
+			switch typ := g.goType(p.Schema, false); {
+			case typ == "string" || handwrittenEnums[typ]:
+				zero = `""`
+			case typ == "bool":
+				zero = "false"
+			}
+			g.printf("if %s != %s {\nopts = append(opts, WithQueryParam(%q, fmt.Sprint(%s)))\n}\n", field, zero, p.Name, field)
+		}
+	}
+	g.printf("err := a.c.do(ctx, &Operation{\n")
+	g.printf("Name: %q,\n", name)
+	g.printf("Method: http.Method%s,\n", strings.ToUpper(method[:1])+strings.ToLower(method[1:]))
+	g.printf("Path: %s,\n", pathExpr)
+	if body != "" {
+		g.printf("Body: body,\n")
+	}
+	if len(queryParams) > 0 {
+		g.printf("Options: opts,\n")
+	}
+	g.printf("Result: resp,\n")
+	g.printf("})\n")
+	g.printf("if err != nil {\nreturn nil, err\n}\n")
+	g.printf("return resp, nil\n}\n\n")
+	return nil
+}
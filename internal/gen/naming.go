@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms are words rendered in all caps (or a fixed casing) in Go
+// identifiers, keyed by their lower-case form.
+var initialisms = map[string]string{
+	"api":   "API",
+	"bmc":   "BMC",
+	"cpu":   "CPU",
+	"gb":    "GB",
+	"id":    "ID",
+	"ids":   "IDs",
+	"ip":    "IP",
+	"ipv4":  "IPv4",
+	"ipv6":  "IPv6",
+	"ipxe":  "IPXE",
+	"mac":   "MAC",
+	"sku":   "SKU",
+	"ssh":   "SSH",
+	"url":   "URL",
+	"uuid":  "UUID",
+	"vcpus": "VCPUs",
+}
+
+// splitWords splits a camelCase, PascalCase or kebab-case name into words.
+func splitWords(name string) []string {
+	var words []string
+	var cur []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// exportedName converts a JSON or schema name into an exported Go
+// identifier.
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, w := range splitWords(name) {
+		if init, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(init)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// argName converts a parameter name into an unexported Go identifier.
+func argName(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	out := strings.ToLower(words[0])
+	for _, w := range words[1:] {
+		if init, ok := initialisms[strings.ToLower(w)]; ok {
+			out += init
+			continue
+		}
+		out += strings.ToUpper(w[:1]) + w[1:]
+	}
+	return out
+}
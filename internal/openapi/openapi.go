@@ -0,0 +1,136 @@
+// Package openapi decodes the subset of an OpenAPI 3 document used by the
+// code generator and the spec conformance checks.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Spec is the subset of an OpenAPI 3 document used by this module.
+type Spec struct {
+	Paths      Ordered[Ordered[*Operation]] `json:"paths"`
+	Components struct {
+		Schemas Ordered[*Schema] `json:"schemas"`
+	} `json:"components"`
+}
+
+// Load reads and decodes the spec at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	return s, nil
+}
+
+// Resolve follows s if it is a $ref, returning the referenced schema. It
+// returns nil if the reference does not exist.
+func (sp *Spec) Resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	return sp.Components.Schemas.Values[s.RefName()]
+}
+
+// Operation describes a single endpoint.
+type Operation struct {
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]MediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]MediaType `json:"content"`
+	} `json:"responses"`
+}
+
+// MediaType is the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Parameter is a path or query parameter of an operation.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema describes a JSON value.
+type Schema struct {
+	Ref                  string           `json:"$ref"`
+	Type                 string           `json:"type"`
+	Format               string           `json:"format"`
+	Description          string           `json:"description"`
+	Nullable             bool             `json:"nullable"`
+	Enum                 []any            `json:"enum"`
+	Properties           Ordered[*Schema] `json:"properties"`
+	Items                *Schema          `json:"items"`
+	AdditionalProperties json.RawMessage  `json:"additionalProperties"`
+}
+
+// RefName returns the schema name a $ref points at.
+func (s *Schema) RefName() string {
+	return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+}
+
+// Additional returns the schema of the values of a map-like object, or nil
+// if s does not describe a map.
+func (s *Schema) Additional() *Schema {
+	if len(s.AdditionalProperties) == 0 || string(s.AdditionalProperties) == "false" {
+		return nil
+	}
+	if string(s.AdditionalProperties) == "true" {
+		return &Schema{}
+	}
+	out := &Schema{}
+	if err := json.Unmarshal(s.AdditionalProperties, out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Ordered is a JSON object which remembers the order of its keys.
+type Ordered[T any] struct {
+	Keys   []string
+	Values map[string]T
+}
+
+func (o *Ordered[T]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, got %v", tok)
+	}
+
+	o.Values = map[string]T{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		o.Keys = append(o.Keys, key)
+		o.Values[key] = v
+	}
+	_, err = dec.Token()
+	return err
+}
@@ -0,0 +1,143 @@
+package inventory
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// AnsibleInventory is an Ansible dynamic inventory, as printed by an
+// inventory script's --list option. It marshals to the JSON Ansible
+// expects: an object of groups with a "_meta" key holding hostvars.
+type AnsibleInventory struct {
+	Groups   map[string][]string       // group name to host aliases
+	HostVars map[string]map[string]any // host alias to variables
+}
+
+// Ansible returns an inventory of services. Every host is in the group
+// "metal" and in groups named after its region, tier, tags and status,
+// such as "region_lax1", "tier_7302p", "tag_web" and "status_active".
+//
+// Hosts have these variables: ansible_host (when the service has a public
+// IPv4 address), tsw_id, tsw_name, tsw_project_id, tsw_region, tsw_tier,
+// tsw_cpu, tsw_memory_gb, tsw_storage (each device's name, type and
+// capacity in GB), tsw_image, tsw_status, tsw_power_state, tsw_tags,
+// tsw_ips, tsw_hourly_price and tsw_monthly_price.
+func Ansible(services []gotsw.Metal) *AnsibleInventory {
+	inv := &AnsibleInventory{
+		Groups:   map[string][]string{},
+		HostVars: map[string]map[string]any{},
+	}
+	for _, h := range Hosts(services) {
+		m := h.Metal
+		inv.add("metal", h.Alias)
+		inv.add(groupName("region", m.RegionID), h.Alias)
+		inv.add(groupName("tier", m.TierID), h.Alias)
+		inv.add(groupName("status", string(m.Status)), h.Alias)
+		for _, tag := range m.Tags {
+			inv.add(groupName("tag", tag), h.Alias)
+		}
+		inv.HostVars[h.Alias] = hostVars(h)
+	}
+	for _, hosts := range inv.Groups {
+		sort.Strings(hosts)
+	}
+	return inv
+}
+
+// Host returns the variables of the host with the given alias, as printed
+// by an inventory script's --host option, or nil if there is none.
+func (inv *AnsibleInventory) Host(alias string) map[string]any {
+	return inv.HostVars[alias]
+}
+
+// MarshalJSON encodes inv in the dynamic inventory format.
+func (inv *AnsibleInventory) MarshalJSON() ([]byte, error) {
+	type group struct {
+		Hosts []string `json:"hosts"`
+	}
+	doc := map[string]any{
+		"_meta": map[string]any{"hostvars": inv.HostVars},
+	}
+	for name, hosts := range inv.Groups {
+		doc[name] = group{Hosts: hosts}
+	}
+	return json.Marshal(doc)
+}
+
+func (inv *AnsibleInventory) add(group, alias string) {
+	if group == "" {
+		return
+	}
+	for _, h := range inv.Groups[group] {
+		if h == alias {
+			return
+		}
+	}
+	inv.Groups[group] = append(inv.Groups[group], alias)
+}
+
+// groupName returns a valid Ansible group name for value, or "" if value
+// is empty.
+func groupName(prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, value)
+	return prefix + "_" + name
+}
+
+func hostVars(h Host) map[string]any {
+	m := h.Metal
+	type device struct {
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		CapacityGB int    `json:"capacity_gb"`
+	}
+	storage := map[string]device{}
+	for key, d := range m.StorageDevices {
+		storage[key] = device{Name: d.Name, Type: string(d.Type), CapacityGB: d.CapacityGB}
+	}
+	ips := make([]string, len(m.IPAddresses))
+	for i, addr := range m.IPAddresses {
+		ips[i] = addr.String()
+	}
+	tags := m.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	vars := map[string]any{
+		"tsw_id":           m.ID,
+		"tsw_name":         m.DisplayName,
+		"tsw_project_id":   m.ProjectID,
+		"tsw_region":       m.RegionID,
+		"tsw_tier":         m.TierID,
+		"tsw_cpu":          m.Tier.CPU,
+		"tsw_memory_gb":    m.MemoryGB,
+		"tsw_storage":      storage,
+		"tsw_image":        m.ImageID,
+		"tsw_status":       m.Status,
+		"tsw_power_state":  m.PowerState,
+		"tsw_tags":         tags,
+		"tsw_ips":          ips,
+		"tsw_hourly_price": m.HourlyPrice,
+	}
+	if m.MonthlyPrice != nil {
+		vars["tsw_monthly_price"] = *m.MonthlyPrice
+	}
+	if h.Address.IsValid() {
+		vars["ansible_host"] = h.Address.String()
+	}
+	return vars
+}
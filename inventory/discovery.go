@@ -0,0 +1,214 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// DefaultTargetPort is the port of scrape targets unless configured
+// otherwise: node_exporter's.
+const DefaultTargetPort = 9100
+
+// DiscoveryFormat is a service discovery file format.
+type DiscoveryFormat string
+
+const (
+	FileSD DiscoveryFormat = "file_sd" // Prometheus file_sd_configs target groups
+	Consul DiscoveryFormat = "consul"  // Consul service definitions
+)
+
+// DiscoveryOptions configures service discovery output.
+type DiscoveryOptions struct {
+	Format  DiscoveryFormat // FileSD if empty
+	Port    int             // DefaultTargetPort if zero
+	Service string          // Consul service name; "node-exporter" if empty
+
+	// Statuses are the statuses of services to include. If empty, only
+	// Active services are, since provisioning, suspended and failed
+	// services have nothing listening to scrape.
+	Statuses []gotsw.Status
+}
+
+func (o DiscoveryOptions) port() int {
+	if o.Port == 0 {
+		return DefaultTargetPort
+	}
+	return o.Port
+}
+
+// Monitored reports whether m should be a discovery target with the
+// default options: it is Active, not powered off, and has a public IPv4
+// address.
+func Monitored(m gotsw.Metal) bool {
+	return DiscoveryOptions{}.monitored(m)
+}
+
+// monitored reports whether m should be a discovery target: its status is
+// one of o.Statuses, it is not powered off, and it has a public IPv4
+// address.
+func (o DiscoveryOptions) monitored(m gotsw.Metal) bool {
+	statuses := o.Statuses
+	if len(statuses) == 0 {
+		statuses = []gotsw.Status{gotsw.StatusActive}
+	}
+	return slices.Contains(statuses, m.Status) && m.PowerState != gotsw.PowerStateOff && PublicIPv4(m).IsValid()
+}
+
+// TargetGroup is a Prometheus file_sd target group.
+type TargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// Targets returns a target group for each monitored service, labelled
+// with tsw_id, tsw_name, tsw_project_id, tsw_region, tsw_tier and
+// tsw_tags. Tags are comma-separated with a comma at either end, as
+// Prometheus's own discovery mechanisms do, so a relabelling regex such as
+// ".*,web,.*" matches one tag.
+func Targets(services []gotsw.Metal, opts DiscoveryOptions) []TargetGroup {
+	groups := []TargetGroup{}
+	for _, h := range Hosts(services) {
+		if !opts.monitored(h.Metal) {
+			continue
+		}
+		m := h.Metal
+		labels := map[string]string{
+			"tsw_id":         strconv.FormatInt(m.ID, 10),
+			"tsw_name":       h.Alias,
+			"tsw_project_id": strconv.FormatInt(m.ProjectID, 10),
+			"tsw_region":     m.RegionID,
+			"tsw_tier":       m.TierID,
+			"tsw_tags":       "",
+		}
+		if len(m.Tags) > 0 {
+			labels["tsw_tags"] = "," + strings.Join(m.Tags, ",") + ","
+		}
+		groups = append(groups, TargetGroup{
+			Targets: []string{net.JoinHostPort(h.Address.String(), strconv.Itoa(opts.port()))},
+			Labels:  labels,
+		})
+	}
+	return groups
+}
+
+// ConsulService is a Consul service definition.
+type ConsulService struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Address string            `json:"address"`
+	Port    int               `json:"port"`
+	Tags    []string          `json:"tags"`
+	Meta    map[string]string `json:"meta"`
+}
+
+// ConsulServices returns a Consul service definition for each monitored
+// service, in the form loaded from a Consul agent's configuration
+// directory: {"services": [...]}. Tags are the service's tags; its
+// region, tier and project are in meta.
+func ConsulServices(services []gotsw.Metal, opts DiscoveryOptions) map[string][]ConsulService {
+	name := opts.Service
+	if name == "" {
+		name = "node-exporter"
+	}
+	defs := []ConsulService{}
+	for _, h := range Hosts(services) {
+		if !opts.monitored(h.Metal) {
+			continue
+		}
+		m := h.Metal
+		tags := m.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		defs = append(defs, ConsulService{
+			ID:      name + "-" + strconv.FormatInt(m.ID, 10),
+			Name:    name,
+			Address: h.Address.String(),
+			Port:    opts.port(),
+			Tags:    tags,
+			Meta: map[string]string{
+				"tsw_id":         strconv.FormatInt(m.ID, 10),
+				"tsw_name":       h.Alias,
+				"tsw_project_id": strconv.FormatInt(m.ProjectID, 10),
+				"tsw_region":     m.RegionID,
+				"tsw_tier":       m.TierID,
+			},
+		})
+	}
+	return map[string][]ConsulService{"services": defs}
+}
+
+// WriteDiscovery writes the discovery file for services to path in the
+// configured format. The file is replaced atomically, so Prometheus and
+// Consul never read a partial file.
+func WriteDiscovery(path string, services []gotsw.Metal, opts DiscoveryOptions) error {
+	var v any
+	switch opts.Format {
+	case FileSD, "":
+		v = Targets(services, opts)
+	case Consul:
+		v = ConsulServices(services, opts)
+	default:
+		return fmt.Errorf("inventory: unknown discovery format %q", opts.Format)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// SyncDiscovery lists services matching filter and writes the discovery
+// file every interval until ctx is done, then returns ctx.Err(). A failed
+// poll or write is logged with the client's logger and the previous file
+// is left in place.
+func SyncDiscovery(ctx context.Context, client *gotsw.Client, filter gotsw.ListMetalOptions, path string, opts DiscoveryOptions, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		services, err := client.ListAllMetal(ctx, filter)
+		if err == nil {
+			err = WriteDiscovery(path, services, opts)
+		}
+		if err != nil && ctx.Err() == nil {
+			client.Logger().WarnContext(ctx, "inventory: updating discovery file failed", "path", path, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames
+// it into place.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
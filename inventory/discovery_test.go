@@ -0,0 +1,121 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestMonitored(t *testing.T) {
+	public := addrs("198.51.100.1")
+	tests := []struct {
+		name     string
+		m        gotsw.Metal
+		statuses []gotsw.Status
+		want     bool
+	}{
+		{"active", gotsw.Metal{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, IPAddresses: public}, nil, true},
+		{"power unknown", gotsw.Metal{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateUnknown, IPAddresses: public}, nil, true},
+		{"powered off", gotsw.Metal{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOff, IPAddresses: public}, nil, false},
+		{"no public address", gotsw.Metal{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, IPAddresses: addrs("10.0.0.1")}, nil, false},
+		{"pending", gotsw.Metal{Status: gotsw.StatusPending, PowerState: gotsw.PowerStateOn, IPAddresses: public}, nil, false},
+		{"suspended", gotsw.Metal{Status: gotsw.StatusSuspended, PowerState: gotsw.PowerStateOn, IPAddresses: public}, nil, false},
+		{"error", gotsw.Metal{Status: gotsw.StatusError, PowerState: gotsw.PowerStateOn, IPAddresses: public}, nil, false},
+		{"terminated", gotsw.Metal{Status: gotsw.StatusTerminated, PowerState: gotsw.PowerStateOn, IPAddresses: public}, nil, false},
+		{"status configured", gotsw.Metal{Status: gotsw.StatusSuspended, PowerState: gotsw.PowerStateOn, IPAddresses: public}, []gotsw.Status{gotsw.StatusActive, gotsw.StatusSuspended}, true},
+		{"status not configured", gotsw.Metal{Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, IPAddresses: public}, []gotsw.Status{gotsw.StatusPending}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (DiscoveryOptions{Statuses: tt.statuses}).monitored(tt.m); got != tt.want {
+				t.Errorf("monitored() = %v, want %v", got, tt.want)
+			}
+			if tt.statuses == nil && Monitored(tt.m) != tt.want {
+				t.Errorf("Monitored() = %v, want %v", !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	groups := Targets(testServices(), DiscoveryOptions{Port: 9200})
+	want := []TargetGroup{{
+		Targets: []string{"198.51.100.1:9200"},
+		Labels: map[string]string{
+			"tsw_id":         "1001",
+			"tsw_name":       "web-1",
+			"tsw_project_id": "480",
+			"tsw_region":     "LAX1",
+			"tsw_tier":       "7302p",
+			"tsw_tags":       ",web,Front End,",
+		},
+	}}
+	got, _ := json.Marshal(groups)
+	wantJSON, _ := json.Marshal(want)
+	if string(got) != string(wantJSON) {
+		t.Errorf("Targets() = %s, want %s", got, wantJSON)
+	}
+}
+
+func TestConsulServices(t *testing.T) {
+	defs := ConsulServices(testServices(), DiscoveryOptions{})["services"]
+	if len(defs) != 1 {
+		t.Fatalf("ConsulServices() = %+v, want one service", defs)
+	}
+	d := defs[0]
+	if d.ID != "node-exporter-1001" || d.Name != "node-exporter" || d.Address != "198.51.100.1" || d.Port != DefaultTargetPort ||
+		!slices.Equal(d.Tags, []string{"web", "Front End"}) || d.Meta["tsw_region"] != "LAX1" {
+		t.Errorf("ConsulServices() = %+v", d)
+	}
+}
+
+func TestWriteDiscovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	for _, format := range []DiscoveryFormat{"", FileSD, Consul} {
+		if err := WriteDiscovery(path, testServices(), DiscoveryOptions{Format: format}); err != nil {
+			t.Fatalf("%q: %v", format, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(data) {
+			t.Errorf("%q: invalid JSON %s", format, data)
+		}
+	}
+	if err := WriteDiscovery(path, nil, DiscoveryOptions{Format: "xml"}); err == nil {
+		t.Error("WriteDiscovery with an unknown format succeeded")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the target file", len(entries))
+	}
+}
+
+func TestSyncDiscovery(t *testing.T) {
+	srv := gotswtest.NewServer()
+	defer srv.Close()
+	srv.AddMetal(gotsw.Metal{DisplayName: "web-1", Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, IPAddresses: addrs("198.51.100.1")})
+	path := filepath.Join(t.TempDir(), "targets.json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := SyncDiscovery(ctx, srv.Client(), gotsw.ListMetalOptions{}, path, DiscoveryOptions{}, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("SyncDiscovery() = %v, want context.DeadlineExceeded", err)
+	}
+	var groups []TargetGroup
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &groups); err != nil || len(groups) != 1 {
+		t.Errorf("targets = %s, %v", data, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package inventory generates configuration management inventory from
+// metal services, so it need not be maintained by hand.
+//
+// Ansible builds an Ansible dynamic inventory, grouping hosts by region,
+// tier, tag and status, and WriteSSHConfig an OpenSSH configuration file
+// suitable for an Include directive:
+//
+//	services, err := client.ListAllMetal(ctx, gotsw.ListMetalOptions{ProjectID: 480})
+//	...
+//	json.NewEncoder(os.Stdout).Encode(inventory.Ansible(services))
+//	inventory.WriteSSHConfig(os.Stdout, services, inventory.SSHOptions{User: "root"})
+//
+// Both use the same host aliases, derived from each service's display
+// name, and connect to its first public IPv4 address.
+//
+// For monitoring, Targets and ConsulServices describe every active
+// service as Prometheus file_sd target groups or Consul service
+// definitions, and SyncDiscovery keeps a file of either up to date:
+//
+//	err := inventory.SyncDiscovery(ctx, client, gotsw.ListMetalOptions{ProjectID: 480},
+//		"/etc/prometheus/targets/tsw.json", inventory.DiscoveryOptions{}, time.Minute)
+package inventory
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Host is a metal service as it appears in inventory.
+type Host struct {
+	// Alias is the host name used in inventory: the service's display name
+	// with characters other than letters, digits, '-', '_' and '.'
+	// replaced by '-'. A service with no name gets "metal-" and its ID.
+	// One whose alias another service already has gets "-" and its ID
+	// appended, as often as needed to make it unique.
+	Alias string
+
+	// Address is the service's first public IPv4 address. It is the zero
+	// Addr if the service has none.
+	Address netip.Addr
+
+	Metal gotsw.Metal
+}
+
+// Hosts returns the hosts for services, sorted by alias.
+func Hosts(services []gotsw.Metal) []Host {
+	sorted := append([]gotsw.Metal(nil), services...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	hosts := make([]Host, 0, len(sorted))
+	seen := map[string]bool{}
+	for _, m := range sorted {
+		alias := sanitize(m.DisplayName)
+		if alias == "" {
+			alias = "metal-" + strconv.FormatInt(m.ID, 10)
+		}
+		// Appending the ID can produce another service's alias, as
+		// with "web" and "web-12" when the second "web" has ID 12.
+		for seen[alias] {
+			alias += "-" + strconv.FormatInt(m.ID, 10)
+		}
+		seen[alias] = true
+		hosts = append(hosts, Host{Alias: alias, Address: PublicIPv4(m), Metal: m})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Alias < hosts[j].Alias })
+	return hosts
+}
+
+// PublicIPv4 returns the first public IPv4 address of m, or the zero Addr
+// if it has none.
+func PublicIPv4(m gotsw.Metal) netip.Addr {
+	for _, addr := range m.IPAddresses {
+		addr = addr.Unmap()
+		if addr.Is4() && addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr) {
+			return addr
+		}
+	}
+	return netip.Addr{}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate does
+// not cover.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// sanitize makes a display name safe to use as a host alias.
+func sanitize(name string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(name)), "-.")
+}
@@ -0,0 +1,170 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func addrs(s ...string) []netip.Addr {
+	var a []netip.Addr
+	for _, v := range s {
+		a = append(a, netip.MustParseAddr(v))
+	}
+	return a
+}
+
+func TestHostsAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []gotsw.Metal
+		want     []string // aliases in ID order
+	}{
+		{
+			name:     "sanitized",
+			services: []gotsw.Metal{{ID: 1, DisplayName: " web 1 (old) "}, {ID: 2, DisplayName: "db.example.com"}},
+			want:     []string{"web-1--old", "db.example.com"},
+		},
+		{
+			name:     "no name",
+			services: []gotsw.Metal{{ID: 7}, {ID: 8, DisplayName: "()"}},
+			want:     []string{"metal-7", "metal-8"},
+		},
+		{
+			name:     "duplicate names",
+			services: []gotsw.Metal{{ID: 3, DisplayName: "web"}, {ID: 4, DisplayName: "web"}},
+			want:     []string{"web", "web-4"},
+		},
+		{
+			name:     "suffixed alias taken",
+			services: []gotsw.Metal{{ID: 3, DisplayName: "web"}, {ID: 5, DisplayName: "web-12"}, {ID: 12, DisplayName: "web"}},
+			want:     []string{"web", "web-12", "web-12-12"},
+		},
+		{
+			name:     "no-name alias taken",
+			services: []gotsw.Metal{{ID: 1, DisplayName: "metal-9"}, {ID: 9}},
+			want:     []string{"metal-9", "metal-9-9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aliases := map[int64]string{}
+			for _, h := range Hosts(tt.services) {
+				aliases[h.Metal.ID] = h.Alias
+			}
+			var got []string
+			for _, m := range tt.services {
+				got = append(got, aliases[m.ID])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("aliases = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicIPv4(t *testing.T) {
+	tests := []struct {
+		ips  []netip.Addr
+		want string
+	}{
+		{addrs("10.0.0.1", "2001:db8::1", "198.51.100.7"), "198.51.100.7"},
+		{addrs("100.64.1.1", "192.168.1.1", "127.0.0.1"), "invalid IP"},
+		{addrs("::ffff:203.0.113.9"), "203.0.113.9"},
+		{nil, "invalid IP"},
+	}
+	for _, tt := range tests {
+		if got := PublicIPv4(gotsw.Metal{IPAddresses: tt.ips}).String(); got != tt.want {
+			t.Errorf("PublicIPv4(%v) = %s, want %s", tt.ips, got, tt.want)
+		}
+	}
+}
+
+func testServices() []gotsw.Metal {
+	monthly := 349.0
+	return []gotsw.Metal{
+		{
+			ID: 1001, DisplayName: "web-1", ProjectID: 480, RegionID: "LAX1", TierID: "7302p",
+			Status: gotsw.StatusActive, PowerState: gotsw.PowerStateOn, Tags: []string{"web", "Front End"},
+			IPAddresses: addrs("198.51.100.1"), MonthlyPrice: &monthly,
+		},
+		{
+			ID: 1002, DisplayName: "db-1", ProjectID: 480, RegionID: "PIT1", TierID: "2388g",
+			Status: gotsw.StatusPending, IPAddresses: addrs("10.0.0.2"),
+		},
+	}
+}
+
+func TestAnsible(t *testing.T) {
+	inv := Ansible(testServices())
+	groups := map[string][]string{
+		"metal":          {"db-1", "web-1"},
+		"region_lax1":    {"web-1"},
+		"tier_2388g":     {"db-1"},
+		"tag_front_end":  {"web-1"},
+		"status_pending": {"db-1"},
+	}
+	for name, want := range groups {
+		if got := inv.Groups[name]; !slices.Equal(got, want) {
+			t.Errorf("group %s = %q, want %q", name, got, want)
+		}
+	}
+	web := inv.Host("web-1")
+	if web["ansible_host"] != "198.51.100.1" || web["tsw_id"] != int64(1001) || web["tsw_monthly_price"] != 349.0 {
+		t.Errorf("web-1 vars = %v", web)
+	}
+	if _, ok := inv.Host("db-1")["ansible_host"]; ok {
+		t.Error("db-1 without a public address has ansible_host")
+	}
+	if inv.Host("nope") != nil {
+		t.Error("Host() of an unknown alias is not nil")
+	}
+
+	data, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Metal struct{ Hosts []string } `json:"metal"`
+		Meta  struct {
+			HostVars map[string]map[string]any `json:"hostvars"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Metal.Hosts) != 2 || doc.Meta.HostVars["db-1"]["tsw_status"] != "Pending" {
+		t.Errorf("JSON = %s", data)
+	}
+}
+
+func TestWriteSSHConfig(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteSSHConfig(&buf, testServices(), SSHOptions{User: "root", Port: 2222, IdentityFile: "~/.ssh/tsw", Prefix: "tsw-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Generated by gotsw from 2 metal services. Do not edit.
+
+# 1001 LAX1 7302p Active
+Host tsw-web-1
+    HostName 198.51.100.1
+    User root
+    Port 2222
+    IdentityFile ~/.ssh/tsw
+
+# No public IPv4 address:
+#   tsw-db-1 (1002)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteSSHConfig() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Count(buf.String(), "Host ") != 1 {
+		t.Error("a host without an address got a Host block")
+	}
+}
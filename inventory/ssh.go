@@ -0,0 +1,55 @@
+package inventory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// SSHOptions configures WriteSSHConfig.
+type SSHOptions struct {
+	User         string // User for every host, if set
+	IdentityFile string // IdentityFile for every host, if set
+	Port         int    // Port for every host, if not zero
+	Prefix       string // prepended to each alias, such as "tsw-"
+}
+
+// WriteSSHConfig writes an OpenSSH client configuration with a Host block
+// for each service, to be included from ~/.ssh/config:
+//
+//	Include ~/.ssh/tsw_config
+//
+// Services without a public IPv4 address are listed in a comment instead,
+// since there is no address to connect to.
+func WriteSSHConfig(w io.Writer, services []gotsw.Metal, opts SSHOptions) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Generated by gotsw from %d metal services. Do not edit.\n", len(services))
+	var skipped []Host
+	for _, h := range Hosts(services) {
+		if !h.Address.IsValid() {
+			skipped = append(skipped, h)
+			continue
+		}
+		fmt.Fprintf(bw, "\n# %d %s %s %s\n", h.Metal.ID, h.Metal.RegionID, h.Metal.TierID, h.Metal.Status)
+		fmt.Fprintf(bw, "Host %s%s\n", opts.Prefix, h.Alias)
+		fmt.Fprintf(bw, "    HostName %s\n", h.Address)
+		if opts.User != "" {
+			fmt.Fprintf(bw, "    User %s\n", opts.User)
+		}
+		if opts.Port != 0 {
+			fmt.Fprintf(bw, "    Port %d\n", opts.Port)
+		}
+		if opts.IdentityFile != "" {
+			fmt.Fprintf(bw, "    IdentityFile %s\n", opts.IdentityFile)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(bw, "\n# No public IPv4 address:\n")
+		for _, h := range skipped {
+			fmt.Fprintf(bw, "#   %s%s (%d)\n", opts.Prefix, h.Alias, h.Metal.ID)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,159 @@
+package ipxe
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Handler serves iPXE scripts keyed by metal service ID or MAC address. It
+// answers two routes, relative to where it is mounted:
+//
+//	GET /metal/{id}
+//	GET /mac/{mac}
+//
+// Use http.StripPrefix to mount it below the root of a server.
+type Handler struct {
+	mu       sync.RWMutex
+	byID     map[int64][]byte
+	byMAC    map[string][]byte
+	fallback []byte
+
+	mux *http.ServeMux
+}
+
+// NewHandler returns a Handler with no scripts.
+func NewHandler() *Handler {
+	h := &Handler{
+		byID:  map[int64][]byte{},
+		byMAC: map[string][]byte{},
+		mux:   http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /metal/{id}", h.serveMetal)
+	h.mux.HandleFunc("GET /mac/{mac}", h.serveMAC)
+	return h
+}
+
+// MetalURL returns the URL of the script for a metal service on a Handler
+// served at base.
+func MetalURL(base string, id int64) string {
+	return strings.TrimSuffix(base, "/") + "/metal/" + strconv.FormatInt(id, 10)
+}
+
+// MACURL returns a URL which iPXE expands to the script for the MAC address
+// of the booting interface on a Handler served at base. Every service can
+// share it.
+func MACURL(base string) string {
+	return strings.TrimSuffix(base, "/") + "/mac/${net0/mac}"
+}
+
+// SetMetal sets the script served to the metal service with the given ID.
+func (h *Handler) SetMetal(id int64, s Script) error {
+	data, err := s.Render()
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.byID[id] = data
+	return nil
+}
+
+// SetMAC sets the script served to the interface with the given MAC
+// address.
+func (h *Handler) SetMAC(mac string, s Script) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("ipxe: %w", err)
+	}
+	data, err := s.Render()
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.byMAC[hw.String()] = data
+	return nil
+}
+
+// SetFallback sets the script served for IDs and MAC addresses without a
+// script of their own. Pass nil to serve 404 Not Found instead.
+func (h *Handler) SetFallback(s *Script) error {
+	var data []byte
+	if s != nil {
+		var err error
+		if data, err = s.Render(); err != nil {
+			return err
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = data
+	return nil
+}
+
+// RemoveMetal stops serving a script to the metal service with the given
+// ID.
+func (h *Handler) RemoveMetal(id int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.byID, id)
+}
+
+// RemoveMAC stops serving a script to the given MAC address.
+func (h *Handler) RemoveMAC(mac string) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.byMAC, hw.String())
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveMetal(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid metal ID", http.StatusBadRequest)
+		return
+	}
+	h.mu.RLock()
+	data, ok := h.byID[id]
+	h.mu.RUnlock()
+	h.write(w, data, ok)
+}
+
+func (h *Handler) serveMAC(w http.ResponseWriter, r *http.Request) {
+	hw, err := net.ParseMAC(r.PathValue("mac"))
+	if err != nil {
+		http.Error(w, "invalid MAC address", http.StatusBadRequest)
+		return
+	}
+	h.mu.RLock()
+	data, ok := h.byMAC[hw.String()]
+	h.mu.RUnlock()
+	h.write(w, data, ok)
+}
+
+// write serves a script, or the fallback if found is false.
+func (h *Handler) write(w http.ResponseWriter, data []byte, found bool) {
+	if !found {
+		h.mu.RLock()
+		data = h.fallback
+		h.mu.RUnlock()
+	}
+	if data == nil {
+		http.Error(w, "no boot script", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
@@ -0,0 +1,145 @@
+package ipxe
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScriptRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		script Script
+		want   string
+	}{
+		{
+			name: "kernel",
+			script: Script{
+				DHCP:     true,
+				Vars:     map[string]string{"b": "2", "a": "1"},
+				Commands: []string{"echo booting"},
+				Kernel:   "http://m/vmlinuz",
+				Initrd:   []string{"http://m/initrd.img"},
+				Cmdline:  "console=ttyS0",
+			},
+			want: "#!ipxe\ndhcp\nset a 1\nset b 2\necho booting\nkernel http://m/vmlinuz console=ttyS0\ninitrd http://m/initrd.img\nboot\n",
+		},
+		{
+			name:   "chain",
+			script: Script{Chain: "http://m/next.ipxe"},
+			want:   "#!ipxe\nchain http://m/next.ipxe\n",
+		},
+		{
+			name:   "sanboot",
+			script: Script{DHCP: true, Sanboot: "http://m/rescue.iso"},
+			want:   "#!ipxe\ndhcp\nsanboot http://m/rescue.iso\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.script.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		script Script
+		want   string
+	}{
+		{"no boot method", Script{DHCP: true}, "exactly one of Kernel, Chain and Sanboot"},
+		{"two boot methods", Script{Kernel: "k", Chain: "c"}, "exactly one of Kernel, Chain and Sanboot"},
+		{"initrd without kernel", Script{Chain: "c", Initrd: []string{"i"}}, "Initrd and Cmdline require Kernel"},
+		{"line break in cmdline", Script{Kernel: "k", Cmdline: "a\nshell"}, "contains a line break"},
+		{"line break in var", Script{Chain: "c", Vars: map[string]string{"x": "1\r"}}, "contains a line break"},
+		{"bad var name", Script{Chain: "c", Vars: map[string]string{"a b": "1"}}, `invalid variable name "a b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.script.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	if got, want := MetalURL("http://boot:8080/", 1001), "http://boot:8080/metal/1001"; got != want {
+		t.Errorf("MetalURL() = %q, want %q", got, want)
+	}
+	if got, want := MACURL("http://boot:8080"), "http://boot:8080/mac/${net0/mac}"; got != want {
+		t.Errorf("MACURL() = %q, want %q", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+	if err := h.SetMetal(1001, Script{Chain: "http://m/1001"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetMAC("AA-BB-CC-DD-EE-FF", Script{Chain: "http://m/mac"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetMAC("not a mac", Script{Chain: "x"}); err == nil {
+		t.Error("SetMAC with an invalid address succeeded")
+	}
+	if err := h.SetMetal(1, Script{}); err == nil {
+		t.Error("SetMetal with an invalid script succeeded")
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/metal/1001", http.StatusOK, "chain http://m/1001"},
+		{"/mac/aa:bb:cc:dd:ee:ff", http.StatusOK, "chain http://m/mac"},
+		{"/metal/1002", http.StatusNotFound, "no boot script"},
+		{"/metal/abc", http.StatusBadRequest, "invalid metal ID"},
+		{"/mac/zz", http.StatusBadRequest, "invalid MAC address"},
+	}
+	for _, tt := range tests {
+		status, body := get(tt.path)
+		if status != tt.status || !strings.Contains(body, tt.body) {
+			t.Errorf("GET %s = %d %q, want %d containing %q", tt.path, status, body, tt.status, tt.body)
+		}
+	}
+
+	if err := h.SetFallback(&Script{Chain: "http://m/default"}); err != nil {
+		t.Fatal(err)
+	}
+	h.RemoveMetal(1001)
+	h.RemoveMAC("aa:bb:cc:dd:ee:ff")
+	for _, path := range []string{"/metal/1001", "/mac/aa:bb:cc:dd:ee:ff"} {
+		if status, body := get(path); status != http.StatusOK || !strings.Contains(body, "http://m/default") {
+			t.Errorf("GET %s after removal = %d %q, want the fallback", path, status, body)
+		}
+	}
+	h.SetFallback(nil)
+	if status, _ := get("/metal/1001"); status != http.StatusNotFound {
+		t.Errorf("GET /metal/1001 without a fallback = %d, want 404", status)
+	}
+}
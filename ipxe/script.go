@@ -0,0 +1,134 @@
+// Package ipxe renders iPXE boot scripts and serves them to metal services
+// booting with an IPXEUrl.
+//
+// A Handler holds a script per service, keyed by Metal.ID or MAC address.
+// Mount it on any HTTP server reachable from the service and pass the
+// matching URL when creating or reinstalling:
+//
+//	boot := ipxe.NewHandler()
+//	boot.SetMetal(metal.ID, ipxe.Script{
+//		DHCP:    true,
+//		Kernel:  "http://mirror.example.com/vmlinuz",
+//		Initrd:  []string{"http://mirror.example.com/initrd.img"},
+//		Cmdline: "console=ttyS0 ip=dhcp",
+//	})
+//	go http.ListenAndServe(":8080", boot)
+//
+//	url := ipxe.MetalURL("http://boot.example.com:8080", metal.ID)
+//	client.ReinstallMetalService(ctx, metal.ID, &gotsw.ReinstallMetalRequest{IPXEUrl: url})
+package ipxe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Header is the first line of every iPXE script.
+const Header = "#!ipxe"
+
+// Script describes an iPXE boot script. Exactly one of Kernel, Chain and
+// Sanboot must be set.
+type Script struct {
+	// DHCP configures the network before booting.
+	DHCP bool
+
+	// Vars are set with the set command before booting, in sorted order.
+	Vars map[string]string
+
+	// Commands are run before booting.
+	Commands []string
+
+	// Kernel is the URL of a kernel to boot with Cmdline, after loading
+	// each of Initrd.
+	Kernel  string
+	Initrd  []string
+	Cmdline string
+
+	// Chain is the URL of another script or boot image to chain load.
+	Chain string
+
+	// Sanboot is the URL of a disk image to boot from, such as an ISO.
+	Sanboot string
+}
+
+// Render returns the script text.
+func (s *Script) Render() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(Header + "\n")
+	if s.DHCP {
+		b.WriteString("dhcp\n")
+	}
+	for _, k := range sortedKeys(s.Vars) {
+		fmt.Fprintf(&b, "set %s %s\n", k, s.Vars[k])
+	}
+	for _, c := range s.Commands {
+		b.WriteString(c + "\n")
+	}
+
+	switch {
+	case s.Kernel != "":
+		b.WriteString("kernel " + s.Kernel)
+		if s.Cmdline != "" {
+			b.WriteString(" " + s.Cmdline)
+		}
+		b.WriteString("\n")
+		for _, initrd := range s.Initrd {
+			b.WriteString("initrd " + initrd + "\n")
+		}
+		b.WriteString("boot\n")
+	case s.Chain != "":
+		b.WriteString("chain " + s.Chain + "\n")
+	case s.Sanboot != "":
+		b.WriteString("sanboot " + s.Sanboot + "\n")
+	}
+	return b.Bytes(), nil
+}
+
+// Validate checks the script has a single boot method and that no value
+// would break out of its line.
+func (s *Script) Validate() error {
+	methods := 0
+	for _, v := range []string{s.Kernel, s.Chain, s.Sanboot} {
+		if v != "" {
+			methods++
+		}
+	}
+	if methods != 1 {
+		return errors.New("ipxe: exactly one of Kernel, Chain and Sanboot must be set")
+	}
+	if s.Kernel == "" && (len(s.Initrd) > 0 || s.Cmdline != "") {
+		return errors.New("ipxe: Initrd and Cmdline require Kernel")
+	}
+
+	values := []string{s.Kernel, s.Cmdline, s.Chain, s.Sanboot}
+	values = append(values, s.Initrd...)
+	values = append(values, s.Commands...)
+	for k, v := range s.Vars {
+		if k == "" || strings.ContainsAny(k, " \t") {
+			return fmt.Errorf("ipxe: invalid variable name %q", k)
+		}
+		values = append(values, k, v)
+	}
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("ipxe: %q contains a line break", v)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+package gotsw
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DefaultLogPollInterval is how often StreamMetalLogs polls unless
+// configured otherwise.
+const DefaultLogPollInterval = 5 * time.Second
+
+// StreamLogsOptions control StreamMetalLogs.
+type StreamLogsOptions struct {
+	// Interval is how often logs are polled. It defaults to
+	// DefaultLogPollInterval.
+	Interval time.Duration
+
+	// Buffer is the capacity of the stream's channel.
+	Buffer int
+}
+
+// LogStream is a stream of log messages from StreamMetalLogs.
+type LogStream struct {
+	// C delivers new log messages in order. It is closed when the
+	// stream ends.
+	C <-chan LogMessage
+
+	err error
+}
+
+// Err returns the error which ended the stream, once C is closed. It is
+// nil if the stream ended because provisioning finished, the context's
+// error if it was cancelled, and wraps ErrProvisioningFailed if
+// provisioning failed.
+func (s *LogStream) Err() error {
+	return s.err
+}
+
+// StreamMetalLogs follows the logs of a metal service. It polls
+// GetMetalLogs, sending each message once, in timestamp order, and ends
+// when ctx is done or the service finishes provisioning, after sending
+// the final messages. Messages are identified by timestamp, name and
+// text; repeats of a message already sent are dropped.
+//
+// The caller must receive from C until it is closed, or cancel ctx.
+func (c *Client) StreamMetalLogs(ctx context.Context, id int64, opts StreamLogsOptions) *LogStream {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultLogPollInterval
+	}
+	ch := make(chan LogMessage, opts.Buffer)
+	s := &LogStream{C: ch}
+
+	go func() {
+		defer close(ch)
+		s.err = c.followLogs(ctx, id, interval, ch)
+	}()
+	return s
+}
+
+func (c *Client) followLogs(ctx context.Context, id int64, interval time.Duration, ch chan<- LogMessage) error {
+	seen := map[LogMessage]bool{}
+	send := func() error {
+		resp, err := c.GetMetalLogs(ctx, id)
+		if err != nil {
+			return err
+		}
+		var fresh []LogMessage
+		for _, l := range resp.Result {
+			if !seen[l] {
+				seen[l] = true
+				fresh = append(fresh, l)
+			}
+		}
+		sort.SliceStable(fresh, func(i, j int) bool {
+			return logTime(fresh[i]).Before(logTime(fresh[j]))
+		})
+		for _, l := range fresh {
+			select {
+			case ch <- l:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Check the service before fetching logs, so messages written
+		// as provisioning finishes are not missed by the final fetch.
+		resp, err := c.GetMetalService(ctx, id)
+		if err != nil {
+			return err
+		}
+		m := &resp.Result
+		if err := send(); err != nil {
+			return err
+		}
+		if err := m.failed(); err != nil {
+			return err
+		}
+		if m.Ready() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// logTime parses a message's timestamp for ordering. Unparseable
+// timestamps sort first, in the order received.
+func logTime(l LogMessage) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, l.Timestamp)
+	return t
+}
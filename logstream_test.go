@@ -0,0 +1,116 @@
+package gotsw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logStep is the state of a service and its logs at one poll.
+type logStep struct {
+	status Status
+	logs   []LogMessage
+}
+
+// newLogServer returns a client for a server which moves through steps,
+// one per GetMetalService call, staying on the last.
+func newLogServer(t *testing.T, steps []logStep) *Client {
+	t.Helper()
+	var mu sync.Mutex
+	step := -1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var v any
+		if strings.HasSuffix(r.URL.Path, "/Logs") {
+			v = Result[[]LogMessage]{Success: true, Result: steps[max(step, 0)].logs}
+		} else {
+			step = min(step+1, len(steps)-1)
+			v = Result[Metal]{Success: true, Result: Metal{ID: 1, Status: steps[step].status}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(srv.Close)
+	c := New("key")
+	c.URL, _ = url.Parse(srv.URL + "/v2/")
+	return c
+}
+
+func TestStreamMetalLogs(t *testing.T) {
+	a := LogMessage{Timestamp: "2026-01-01T00:00:01Z", Name: "provisioning", Message: "a"}
+	b := LogMessage{Timestamp: "2026-01-01T00:00:02Z", Name: "provisioning", Message: "b"}
+	c := LogMessage{Timestamp: "2026-01-01T00:00:03Z", Name: "provisioning", Message: "c"}
+	d := LogMessage{Timestamp: "2026-01-01T00:00:04Z", Name: "provisioning", Message: "d"}
+
+	tests := []struct {
+		name    string
+		steps   []logStep
+		timeout time.Duration
+		want    string // messages received, in order
+		err     error
+	}{
+		{
+			name: "until ready",
+			steps: []logStep{
+				{StatusPending, []LogMessage{a}},
+				{StatusPending, []LogMessage{a, c, b}},
+				{StatusActive, []LogMessage{a, b, c, d}},
+			},
+			want: "abcd",
+		},
+		{
+			name: "repeated message",
+			steps: []logStep{
+				{StatusPending, []LogMessage{a, a}},
+				{StatusActive, []LogMessage{a, b}},
+			},
+			want: "ab",
+		},
+		{
+			name: "provisioning failed",
+			steps: []logStep{
+				{StatusPending, []LogMessage{a}},
+				{StatusError, []LogMessage{a, b}},
+			},
+			want: "ab",
+			err:  ErrProvisioningFailed,
+		},
+		{
+			name:    "cancelled",
+			steps:   []logStep{{StatusPending, []LogMessage{a}}},
+			timeout: 20 * time.Millisecond,
+			want:    "a",
+			err:     context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newLogServer(t, tt.steps)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			s := client.StreamMetalLogs(ctx, 1, StreamLogsOptions{Interval: time.Millisecond})
+			var got strings.Builder
+			for l := range s.C {
+				got.WriteString(l.Message)
+			}
+			if got.String() != tt.want {
+				t.Errorf("messages = %q, want %q", got.String(), tt.want)
+			}
+			if !errors.Is(s.Err(), tt.err) {
+				t.Errorf("Err() = %v, want %v", s.Err(), tt.err)
+			}
+		})
+	}
+}
@@ -2,11 +2,11 @@ package gotsw
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/netip"
+	"sort"
 	"time"
 )
 
@@ -27,7 +27,7 @@ type Metal struct {
 
 	// Hardware configuration
 	TierID         string                        `json:"tierId"`
-	Tier           CpuDetails                    `json:"tier"`
+	Tier           MetalTier                     `json:"tier"`
 	MemoryGB       int32                         `json:"memoryGb"`
 	ImageID        string                        `json:"imageId"`
 	StorageDevices map[string]MetalStorageDevice `json:"storageDevices"`
@@ -36,12 +36,12 @@ type Metal struct {
 	IPAddresses []netip.Addr `json:"ipAddresses"`
 
 	// Pricing
-	MonthlyPrice float64 `json:"monthlyPrice"`
-	HourlyPrice  float64 `json:"hourlyPrice"`
+	MonthlyPrice *float64 `json:"monthlyPrice"`
+	HourlyPrice  float64  `json:"hourlyPrice"`
 
 	// Additional metadata
 	Tags   []string            `json:"tags"`
-	Events []ProvisioningEvent `json:"events"`
+	Events []ProvisioningEvent `json:"provisioningEvents"`
 }
 
 // Status represents the current status of a service
@@ -103,9 +103,9 @@ type Result[T any] struct {
 
 // ListMetadata contains metadata about list responses
 type ListMetadata struct {
-	TotalCount int32 `json:"total_count"`
+	TotalCount int32 `json:"totalCount"`
 	Limit      int32 `json:"limit"`
-	Skip       int32 `json:"skip"`
+	Skip       int64 `json:"skip"`
 }
 
 // Now we can update our response types to use the generic Result
@@ -159,16 +159,43 @@ func (o *ListMetalOptions) ToQueryParams() []RequestOption {
 func (c *Client) ListMetal(ctx context.Context, opts ListMetalOptions) (*ListMetalResponse, error) {
 	resp := &ListMetalResponse{}
 
-	httpResp, err := c.Request(ctx, http.MethodGet, "Metal", nil, opts.ToQueryParams()...)
+	err := c.do(ctx, &Operation{
+		Name:    "ListMetal",
+		Method:  http.MethodGet,
+		Path:    "Metal",
+		Options: opts.ToQueryParams(),
+		Result:  resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
+	return resp, nil
+}
 
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
+// ListAllMetal retrieves every metal service matching opts, following
+// pagination from opts.Skip. opts.Limit sets the page size.
+func (c *Client) ListAllMetal(ctx context.Context, opts ListMetalOptions) ([]Metal, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	var all []Metal
+	for {
+		resp, err := c.ListMetal(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, errors.New(resp.Message)
+		}
+		all = append(all, resp.Result...)
+
+		n := int32(len(resp.Result))
+		opts.Skip += n
+		total := resp.Metadata.TotalCount
+		if n == 0 || (total > 0 && opts.Skip >= total) || (total == 0 && n < opts.Limit) {
+			return all, nil
+		}
 	}
-	return resp, nil
 }
 
 // CreateBareMetalRequest represents the request parameters for creating a new metal service
@@ -192,17 +219,21 @@ type CreateBareMetalRequest struct {
 }
 
 type Partition struct {
-	Name      string `json:"name"`
-	Device    string `json:"device"`
-	SizeBytes *int64 `json:"sizeBytes,omitempty"`
+	Name       string     `json:"name"`
+	Device     string     `json:"device"`
+	SizeBytes  *int64     `json:"sizeBytes,omitempty"`
+	FileSystem FileSystem `json:"fileSystem,omitempty"`
+	MountPoint string     `json:"mountPoint,omitempty"`
 }
 
 type RaidArray struct {
-	Name       string     `json:"name"`
-	Type       RaidType   `json:"type"`
-	Members    []string   `json:"members"`
-	FileSystem FileSystem `json:"filesystem"`
-	MountPoint string     `json:"mountPoint"`
+	Name       string      `json:"name"`
+	Type       RaidType    `json:"type"`
+	Members    []string    `json:"members"`
+	Partitions []Partition `json:"partitions,omitempty"`
+	SizeBytes  *int64      `json:"sizeBytes,omitempty"`
+	FileSystem FileSystem  `json:"fileSystem"`
+	MountPoint string      `json:"mountPoint"`
 }
 
 type RaidType string
@@ -234,34 +265,132 @@ const (
 // CreateMetalService creates a new metal service
 func (c *Client) CreateMetalService(ctx context.Context, projectID int64, req *CreateBareMetalRequest) (*MetalResponse, error) {
 	resp := &MetalResponse{}
-	httpResp, err := c.Request(ctx, http.MethodPost, "Metal", req,
-		WithQueryParam("projectId", fmt.Sprint(projectID)),
-	)
+	err := c.do(ctx, &Operation{
+		Name:   "CreateMetalService",
+		Method: http.MethodPost,
+		Path:   "Metal",
+		Body:   req,
+		Options: []RequestOption{
+			WithQueryParam("projectId", fmt.Sprint(projectID)),
+		},
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
+	return resp, nil
+}
 
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
+// Validate checks the request against the tier it will be created on,
+// catching mistakes the API would otherwise reject with an opaque message.
+// Every problem found is reported in the returned error, which is nil if
+// the request is valid.
+//
+// The tier must match TierID, and MemoryGB and Disks must name options it
+// offers. Required drive slots must be given a drive unless the tier
+// installs one by default. The tier must be available in RegionID in the
+// requested quantity. The server must be reachable: one of SSHKeyIDs,
+// Password, IPXEUrl or TemplateID must be set, since a template supplies
+// its own credentials.
+func (r *CreateBareMetalRequest) Validate(tier MetalTier) error {
+	var errs []error
+
+	if r.TierID != "" && r.TierID != tier.ID {
+		errs = append(errs, fmt.Errorf("tier %q does not match tier %q", r.TierID, tier.ID))
+	}
+
+	// Zero memory selects the tier's default option.
+	if r.MemoryGB != 0 {
+		found := false
+		var sizes []int
+		for _, opt := range tier.MemoryOptions {
+			sizes = append(sizes, opt.GB)
+			if opt.GB == r.MemoryGB {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("memory %dGB is not offered on tier %s (options: %v)", r.MemoryGB, tier.ID, sizes))
+		}
+	}
+
+	slots := map[string]DriveSlot{}
+	for _, slot := range tier.DriveSlots {
+		slots[slot.ID] = slot
+	}
+	for _, id := range sortedKeys(r.Disks) {
+		slot, ok := slots[id]
+		if !ok {
+			errs = append(errs, fmt.Errorf("disk %q is not a drive slot on tier %s", id, tier.ID))
+			continue
+		}
+		name := r.Disks[id]
+		found := false
+		var names []string
+		for _, opt := range slot.Options {
+			names = append(names, opt.Name)
+			if opt.Name == name {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("disk %q: %q is not a drive option (options: %v)", id, name, names))
+		}
+	}
+	for _, slot := range tier.DriveSlots {
+		// A required slot with a default drive is filled without asking.
+		if _, ok := r.Disks[slot.ID]; slot.Required && !ok && slot.Default == "" {
+			errs = append(errs, fmt.Errorf("drive slot %q is required", slot.ID))
+		}
+	}
+
+	quantity := r.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if avail, ok := tier.Availability[r.RegionID]; !ok || avail == nil {
+		errs = append(errs, fmt.Errorf("tier %s is not available in region %q", tier.ID, r.RegionID))
+	} else if avail.MaxQuantity < quantity {
+		errs = append(errs, fmt.Errorf("region %s has %d of tier %s available, %d requested", r.RegionID, avail.MaxQuantity, tier.ID, quantity))
+	}
+
+	if len(r.SSHKeyIDs) == 0 && isEmpty(r.Password) && isEmpty(r.IPXEUrl) && r.TemplateID == nil {
+		errs = append(errs, errors.New("one of SSHKeyIDs, Password, IPXEUrl or TemplateID must be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// isEmpty reports whether an optional string is unset or empty.
+func isEmpty(s *string) bool {
+	return s == nil || *s == ""
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	return resp, nil
+	sort.Strings(keys)
+	return keys
 }
 
-// // ListMetalTemplates retrieves all metal service templates
-// func (c *Client) ListMetalTemplates(ctx context.Context) (*MetalTemplateIEnumerableApiResponse, error) {
-// 	resp := &MetalTemplateIEnumerableApiResponse{}
-// 	httpResp, err := c.Request(ctx, http.MethodGet, "Metal/templates", nil)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	defer httpResp.Body.Close()
+// MetalTemplateResponse represents the API response for listing metal templates
+type MetalTemplateResponse Result[[]MetalTemplate]
 
-// 	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-// 		return nil, err
-// 	}
-// 	return resp, nil
-// }
+// ListMetalTemplates retrieves all metal service templates
+func (c *Client) ListMetalTemplates(ctx context.Context) (*MetalTemplateResponse, error) {
+	resp, err := c.API().ListMetalTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Success {
+		return nil, errors.New(resp.Message)
+	}
+	return (*MetalTemplateResponse)(resp), nil
+}
 
 // MetalTier represents a hardware configuration tier for metal services
 type MetalTier struct {
@@ -283,6 +412,8 @@ type MetalTier struct {
 }
 
 // CpuDetails represents the CPU details for a metal tier
+//
+// Deprecated: Metal.Tier now holds the full MetalTier.
 type CpuDetails struct {
 	ID             string `json:"id"`             // ID of the CPU
 	CPU            string `json:"cpu"`            // The CPU model for the tier
@@ -367,15 +498,16 @@ func (c *Client) ListMetalTiers(ctx context.Context, tierType MetalTierType) (*M
 		opts = append(opts, WithQueryParam("metalTierType", string(tierType)))
 	}
 
-	httpResp, err := c.Request(ctx, http.MethodGet, "Metal/tiers", nil, opts...)
+	err := c.do(ctx, &Operation{
+		Name:    "ListMetalTiers",
+		Method:  http.MethodGet,
+		Path:    "Metal/tiers",
+		Options: opts,
+		Result:  resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -387,15 +519,15 @@ func (c *Client) ListMetalTiers(ctx context.Context, tierType MetalTierType) (*M
 // GetMetalService retrieves a single metal service by ID
 func (c *Client) GetMetalService(ctx context.Context, id int64) (*MetalResponse, error) {
 	resp := &MetalResponse{}
-	httpResp, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("Metal/%d", id), nil)
+	err := c.do(ctx, &Operation{
+		Name:   "GetMetalService",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Metal/%d", id),
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -419,15 +551,16 @@ type ReinstallMetalRequest struct {
 // ReinstallMetalService reinstalls a metal service by ID
 func (c *Client) ReinstallMetalService(ctx context.Context, id int64, req *ReinstallMetalRequest) (*MetalResponse, error) {
 	resp := &MetalResponse{}
-	httpResp, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("Metal/%d/Reinstall", id), req)
+	err := c.do(ctx, &Operation{
+		Name:   "ReinstallMetalService",
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("Metal/%d/Reinstall", id),
+		Body:   req,
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 	return resp, nil
 }
 
@@ -441,15 +574,16 @@ const (
 // SendPowerCommand sends a power command to a metal service
 func (c *Client) SendPowerCommand(ctx context.Context, id int64, command PowerCommand) (*MetalResponse, error) {
 	resp := &MetalResponse{}
-	httpResp, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("Metal/%d/PowerCommand", id), nil, WithQueryParam("command", fmt.Sprint(command)))
+	err := c.do(ctx, &Operation{
+		Name:    "SendPowerCommand",
+		Method:  http.MethodPost,
+		Path:    fmt.Sprintf("Metal/%d/PowerCommand", id),
+		Options: []RequestOption{WithQueryParam("command", fmt.Sprint(command))},
+		Result:  resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -471,15 +605,15 @@ type LogMessageResponse Result[[]LogMessage]
 // GetMetalLogs retrieves logs for a metal service
 func (c *Client) GetMetalLogs(ctx context.Context, id int64) (*LogMessageResponse, error) {
 	resp := &LogMessageResponse{}
-	httpResp, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("Metal/%d/Logs", id), nil)
+	err := c.do(ctx, &Operation{
+		Name:   "GetMetalLogs",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Metal/%d/Logs", id),
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -507,15 +641,16 @@ func (c *Client) GetMetalAvailability(ctx context.Context, projectId int64, regi
 	}
 
 	resp := &MetalConfigurationResponse{}
-	httpResp, err := c.Request(ctx, http.MethodGet, "Metal/Availability", nil, allOpts...)
+	err := c.do(ctx, &Operation{
+		Name:    "GetMetalAvailability",
+		Method:  http.MethodGet,
+		Path:    "Metal/Availability",
+		Options: allOpts,
+		Result:  resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -527,15 +662,16 @@ func (c *Client) GetMetalAvailability(ctx context.Context, projectId int64, regi
 // RenameMetalService renames a metal service
 func (c *Client) RenameMetalService(ctx context.Context, id int64, name string) (*Result[struct{}], error) {
 	resp := &Result[struct{}]{}
-	httpResp, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("Metal/%d/rename", id), map[string]string{"name": name})
+	err := c.do(ctx, &Operation{
+		Name:   "RenameMetalService",
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("Metal/%d/rename", id),
+		Body:   map[string]string{"name": name},
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
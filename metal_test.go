@@ -0,0 +1,124 @@
+package gotsw
+
+import (
+	"strings"
+	"testing"
+)
+
+// testTier is a tier with two memory options, a required slot with a
+// default drive, a required slot without one and an optional slot.
+var testTier = MetalTier{
+	ID: "7302p",
+	Availability: map[string]*ServiceAvailability{
+		"PIT1": {MaxQuantity: 3},
+		"LAX1": nil,
+	},
+	MemoryOptions: []MemoryOption{{GB: 128, Default: true}, {GB: 256}},
+	DriveSlots: []DriveSlot{
+		{ID: "nvme0n1", Default: "960g", Required: true, Options: []MetalStorageDevice{{Name: "960g"}, {Name: "1.92t"}}},
+		{ID: "nvme1n1", Required: true, Options: []MetalStorageDevice{{Name: "960g"}}},
+		{ID: "sda", Options: []MetalStorageDevice{{Name: "8t"}}},
+	},
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestCreateBareMetalRequestValidate(t *testing.T) {
+	valid := func() CreateBareMetalRequest {
+		return CreateBareMetalRequest{
+			RegionID:  "PIT1",
+			TierID:    "7302p",
+			Disks:     map[string]string{"nvme1n1": "960g"},
+			SSHKeyIDs: []int{1},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(r *CreateBareMetalRequest)
+		want   []string // substrings of the error, none if valid
+	}{
+		{name: "valid", modify: func(r *CreateBareMetalRequest) {}},
+		{name: "empty tier ID", modify: func(r *CreateBareMetalRequest) { r.TierID = "" }},
+		{name: "memory option", modify: func(r *CreateBareMetalRequest) { r.MemoryGB = 256 }},
+		{name: "password only", modify: func(r *CreateBareMetalRequest) { r.SSHKeyIDs = nil; r.Password = ptr("x") }},
+		{name: "iPXE only", modify: func(r *CreateBareMetalRequest) { r.SSHKeyIDs = nil; r.IPXEUrl = ptr("http://boot") }},
+		{name: "template only", modify: func(r *CreateBareMetalRequest) { r.SSHKeyIDs = nil; r.TemplateID = ptr(4) }},
+		{name: "default fills required slot", modify: func(r *CreateBareMetalRequest) { r.Disks["sda"] = "8t" }},
+		{name: "quantity at limit", modify: func(r *CreateBareMetalRequest) { r.Quantity = 3 }},
+		{
+			name:   "wrong tier",
+			modify: func(r *CreateBareMetalRequest) { r.TierID = "2388g" },
+			want:   []string{`tier "2388g" does not match tier "7302p"`},
+		},
+		{
+			name:   "unknown memory",
+			modify: func(r *CreateBareMetalRequest) { r.MemoryGB = 64 },
+			want:   []string{"memory 64GB is not offered on tier 7302p (options: [128 256])"},
+		},
+		{
+			name:   "unknown slot",
+			modify: func(r *CreateBareMetalRequest) { r.Disks["sdz"] = "8t" },
+			want:   []string{`disk "sdz" is not a drive slot`},
+		},
+		{
+			name:   "unknown drive",
+			modify: func(r *CreateBareMetalRequest) { r.Disks["nvme0n1"] = "4t" },
+			want:   []string{`disk "nvme0n1": "4t" is not a drive option`},
+		},
+		{
+			name:   "required slot without default",
+			modify: func(r *CreateBareMetalRequest) { delete(r.Disks, "nvme1n1") },
+			want:   []string{`drive slot "nvme1n1" is required`},
+		},
+		{
+			name:   "region not listed",
+			modify: func(r *CreateBareMetalRequest) { r.RegionID = "SLC1" },
+			want:   []string{`tier 7302p is not available in region "SLC1"`},
+		},
+		{
+			name:   "region without availability",
+			modify: func(r *CreateBareMetalRequest) { r.RegionID = "LAX1" },
+			want:   []string{`tier 7302p is not available in region "LAX1"`},
+		},
+		{
+			name:   "quantity over limit",
+			modify: func(r *CreateBareMetalRequest) { r.Quantity = 4 },
+			want:   []string{"region PIT1 has 3 of tier 7302p available, 4 requested"},
+		},
+		{
+			name:   "no access",
+			modify: func(r *CreateBareMetalRequest) { r.SSHKeyIDs = nil; r.Password = ptr("") },
+			want:   []string{"one of SSHKeyIDs, Password, IPXEUrl or TemplateID must be set"},
+		},
+		{
+			name: "every problem reported",
+			modify: func(r *CreateBareMetalRequest) {
+				r.MemoryGB = 64
+				r.Quantity = 9
+				r.SSHKeyIDs = nil
+			},
+			want: []string{"memory 64GB", "9 requested", "must be set"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+			err := r.Validate(testTier)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want errors %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package gotsw
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Operation describes a single logical API call made through the client. It
+// is passed down the middleware chain before the HTTP request is sent.
+type Operation struct {
+	// Name is the logical name of the operation, e.g. "CreateMetalService".
+	Name string
+	// Method is the HTTP method used for the request.
+	Method string
+	// Path is the request path relative to the client URL.
+	Path string
+	// Body is the request body before it is encoded.
+	Body any
+	// Options are applied to the outgoing http.Request.
+	Options []RequestOption
+
+	// Result points at the value the response body is decoded into.
+	// Middleware may populate it directly to short-circuit the request.
+	Result any
+	// StatusCode is the HTTP status code of the response. It is zero until
+	// the request has been sent.
+	StatusCode int
+}
+
+// Query returns the query parameters set by the operation's options.
+func (op *Operation) Query() url.Values {
+	req := &http.Request{URL: &url.URL{}, Header: http.Header{}}
+	for _, opt := range op.Options {
+		opt(req)
+	}
+	return req.URL.Query()
+}
+
+// Handler executes an Operation, decoding the response into op.Result.
+type Handler func(ctx context.Context, op *Operation) error
+
+// Middleware wraps a Handler to observe or alter the operations made by the
+// client.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client. Middleware run in the order they
+// were added, so the first one added sees each operation first.
+func (c *Client) Use(mw ...Middleware) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Never append in place so a chain snapshotted by an in-flight call is
+	// left untouched.
+	c.middleware = append(c.middleware[:len(c.middleware):len(c.middleware)], mw...)
+	return c
+}
+
+// do runs op through the middleware chain.
+func (c *Client) do(ctx context.Context, op *Operation) error {
+	c.mu.RLock()
+	chain := c.middleware
+	auditor := c.auditor
+	c.mu.RUnlock()
+
+	h := Handler(c.send)
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	err := h(ctx, op)
+	if auditor != nil && audited(op) {
+		c.audit(ctx, auditor, op, err)
+	}
+	return err
+}
+
+// send is the innermost Handler. It performs the HTTP request and decodes
+// the JSON response into op.Result.
+func (c *Client) send(ctx context.Context, op *Operation) error {
+	httpResp, err := c.Request(ctx, op.Method, op.Path, op.Body, op.Options...)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	op.StatusCode = httpResp.StatusCode
+	if op.Result == nil {
+		return nil
+	}
+	return json.NewDecoder(httpResp.Body).Decode(op.Result)
+}
@@ -0,0 +1,149 @@
+package gotsw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestClient returns a client for a server answering every request with
+// body.
+func newTestClient(t *testing.T, status int, body string) (*Client, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := New("key")
+	c.URL, _ = url.Parse(srv.URL + "/v2/")
+	return c, &requests
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	c, _ := newTestClient(t, http.StatusOK, `{"success":true,"result":{"id":7}}`)
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				calls = append(calls, name+" before "+op.Name)
+				err := next(ctx, op)
+				calls = append(calls, name+" after "+http.StatusText(op.StatusCode))
+				return err
+			}
+		}
+	}
+	c.Use(record("a"), record("b"))
+	c.Use(record("c"))
+
+	resp, err := c.GetMetalService(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result.ID != 7 {
+		t.Errorf("ID = %d, want 7", resp.Result.ID)
+	}
+	want := []string{
+		"a before GetMetalService",
+		"b before GetMetalService",
+		"c before GetMetalService",
+		"c after OK",
+		"b after OK",
+		"a after OK",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	tests := []struct {
+		name     string
+		mw       Middleware
+		wantErr  error
+		wantID   int64
+		wantSent bool
+	}{
+		{
+			name: "pass through",
+			mw: func(next Handler) Handler {
+				return next
+			},
+			wantID:   1,
+			wantSent: true,
+		},
+		{
+			name: "populate result",
+			mw: func(next Handler) Handler {
+				return func(ctx context.Context, op *Operation) error {
+					*op.Result.(*MetalResponse) = MetalResponse{Success: true, Result: Metal{ID: 99}}
+					return nil
+				}
+			},
+			wantID: 99,
+		},
+		{
+			name: "refuse",
+			mw: func(next Handler) Handler {
+				return func(ctx context.Context, op *Operation) error {
+					return errTest
+				}
+			},
+			wantErr: errTest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, requests := newTestClient(t, http.StatusOK, `{"success":true,"result":{"id":1}}`)
+			c.Use(tt.mw)
+			resp, err := c.GetMetalService(context.Background(), 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && resp.Result.ID != tt.wantID {
+				t.Errorf("ID = %d, want %d", resp.Result.ID, tt.wantID)
+			}
+			if sent := requests.Load() > 0; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+var errTest = errors.New("test error")
+
+func TestUseDoesNotAlterSnapshot(t *testing.T) {
+	c := New("key")
+	noop := func(next Handler) Handler { return next }
+	c.Use(noop, noop)
+	snapshot := c.middleware
+	c.Use(noop)
+	if len(snapshot) != 2 || len(c.middleware) != 3 {
+		t.Fatalf("len(snapshot) = %d, len(middleware) = %d", len(snapshot), len(c.middleware))
+	}
+	if &snapshot[0] == &c.middleware[0] {
+		t.Error("Use appended to the chain in place")
+	}
+}
+
+func TestOperationQuery(t *testing.T) {
+	op := &Operation{Options: []RequestOption{
+		WithQueryParam("projectId", "480"),
+		WithQueryParam("region", "PIT1"),
+	}}
+	q := op.Query()
+	if got := q.Get("projectId"); got != "480" {
+		t.Errorf("projectId = %q, want 480", got)
+	}
+	if got := q.Get("region"); got != "PIT1" {
+		t.Errorf("region = %q, want PIT1", got)
+	}
+}
@@ -0,0 +1,233 @@
+// Package output renders API resources for people and scripts.
+//
+// Write accepts a Metal, MetalTier, MetalConfiguration, SSHKey or
+// LogMessage, a pointer to one, or a slice of them, and writes it as an
+// aligned table, CSV, JSON, YAML or the output of a text/template. Every
+// format uses the same flattened field names, so a column selected with
+// -columns, a CSV header, a JSON key and a template field all match:
+//
+//	output.Write(os.Stdout, services, output.Options{
+//		Format:  output.Table,
+//		Columns: []string{"id", "name", "ips"},
+//	})
+//
+//	output.Write(os.Stdout, services, output.Options{
+//		Format:   output.Template,
+//		Template: `{{.name}} {{join .ips " "}}`,
+//	})
+//
+// Fields lists the names available for each type. Other values are
+// written by building a Record, or a slice of them, for each: the fields
+// of the first record are the columns.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	Table    Format = "table"
+	CSV      Format = "csv"
+	JSON     Format = "json"
+	YAML     Format = "yaml"
+	Template Format = "template"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Table, CSV, JSON, YAML, Template}
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("output: unknown format %q", s)
+}
+
+// ErrUnsupportedType is returned for values which are not a supported
+// resource type.
+var ErrUnsupportedType = errors.New("output: unsupported type")
+
+// Options control how a value is written.
+type Options struct {
+	Format Format // defaults to Table
+
+	// Columns selects and orders the fields shown by Table and CSV.
+	// It defaults to a summary for tables and every field for CSV.
+	Columns []string
+
+	// NoHeaders omits the header line from Table and CSV.
+	NoHeaders bool
+
+	// Template is executed once per resource by the Template format,
+	// with the resource's fields as a map. A newline is written after
+	// each execution. Besides the text/template builtins it may call
+	// join, which joins a list with a separator, and json, which
+	// encodes a value as JSON.
+	Template string
+}
+
+// Write writes v to w.
+func Write(w io.Writer, v any, opts Options) error {
+	t, ok := toTable(v)
+	if !ok {
+		return fmt.Errorf("%w %T", ErrUnsupportedType, v)
+	}
+	switch opts.Format {
+	case Table, "":
+		return writeTable(w, t, opts)
+	case CSV:
+		return writeCSV(w, t, opts)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t.value())
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(t.value()); err != nil {
+			return err
+		}
+		return enc.Close()
+	case Template:
+		return writeTemplate(w, t, opts)
+	}
+	return fmt.Errorf("output: unknown format %q", opts.Format)
+}
+
+// Records returns the flattened form of v, which may be any value
+// accepted by Write.
+func Records(v any) ([]Record, error) {
+	t, ok := toTable(v)
+	if !ok {
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedType, v)
+	}
+	return t.records, nil
+}
+
+// Fields returns the field names of v's resource type, in order. v may be
+// any value accepted by Write, including an empty slice.
+func Fields(v any) ([]string, error) {
+	t, ok := toTable(v)
+	if !ok {
+		return nil, fmt.Errorf("%w %T", ErrUnsupportedType, v)
+	}
+	return t.fields, nil
+}
+
+// value returns what JSON and YAML encode: a single record, or a list.
+func (t *table) value() any {
+	if t.single && len(t.records) == 1 {
+		return t.records[0]
+	}
+	if t.records == nil {
+		return []Record{}
+	}
+	return t.records
+}
+
+// selectColumns returns the columns to write, checking that each names a
+// field.
+func (t *table) selectColumns(columns, fallback []string) ([]string, error) {
+	if len(columns) == 0 {
+		return fallback, nil
+	}
+	for _, c := range columns {
+		if !slices.Contains(t.fields, c) {
+			return nil, fmt.Errorf("output: unknown column %q (have %s)", c, strings.Join(t.fields, ", "))
+		}
+	}
+	return columns, nil
+}
+
+func (t *table) rows(columns []string) [][]string {
+	rows := make([][]string, len(t.records))
+	for i, rec := range t.records {
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			v, _ := rec.Get(c)
+			row[j] = format(v)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func writeTable(w io.Writer, t *table, opts Options) error {
+	columns, err := t.selectColumns(opts.Columns, t.columns)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if !opts.NoHeaders {
+		headers := make([]string, len(columns))
+		for i, c := range columns {
+			headers[i] = strings.ToUpper(c)
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+	for _, row := range t.rows(columns) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, t *table, opts Options) error {
+	columns, err := t.selectColumns(opts.Columns, t.fields)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if !opts.NoHeaders {
+		cw.Write(columns)
+	}
+	cw.WriteAll(t.rows(columns))
+	return cw.Error()
+}
+
+var templateFuncs = template.FuncMap{
+	"join": func(v any, sep string) string {
+		if s, ok := v.([]string); ok {
+			return strings.Join(s, sep)
+		}
+		return format(v)
+	},
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func writeTemplate(w io.Writer, t *table, opts Options) error {
+	if opts.Template == "" {
+		return errors.New("output: no template")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(opts.Template)
+	if err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	for _, rec := range t.records {
+		if err := tmpl.Execute(w, rec.Map()); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func testServices() []gotsw.Metal {
+	monthly := 199.0
+	return []gotsw.Metal{
+		{
+			ID:           1001,
+			DisplayName:  "web-1",
+			Status:       gotsw.StatusActive,
+			PowerState:   gotsw.PowerStateOn,
+			RegionID:     "PIT1",
+			TierID:       "7302p",
+			ImageID:      "debian-12",
+			IPAddresses:  []netip.Addr{netip.MustParseAddr("198.51.100.1"), netip.MustParseAddr("2001:db8::1")},
+			Tags:         []string{"web", "prod"},
+			MonthlyPrice: &monthly,
+		},
+		{
+			ID:          1002,
+			DisplayName: "db, primary",
+			Status:      gotsw.StatusPending,
+			PowerState:  gotsw.PowerStateOff,
+			RegionID:    "LAX1",
+			TierID:      "2388g",
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	services := testServices()
+	tests := []struct {
+		name string
+		v    any
+		opts Options
+		want string
+	}{
+		{
+			name: "table",
+			v:    services,
+			opts: Options{Columns: []string{"id", "name", "ips", "monthly_price"}},
+			want: "ID    NAME         IPS                       MONTHLY_PRICE\n" +
+				"1001  web-1        198.51.100.1,2001:db8::1  199.00\n" +
+				"1002  db, primary  " + strings.Repeat(" ", 26) + "\n",
+		},
+		{
+			name: "table without headers",
+			v:    &services[0],
+			opts: Options{Columns: []string{"id", "tags"}, NoHeaders: true},
+			want: "1001  web,prod\n",
+		},
+		{
+			name: "csv",
+			v:    services,
+			opts: Options{Format: CSV, Columns: []string{"id", "name", "status"}},
+			want: "id,name,status\n1001,web-1,Active\n1002,\"db, primary\",Pending\n",
+		},
+		{
+			name: "json single",
+			v:    services[1],
+			opts: Options{Format: JSON},
+			want: `{
+  "id": 1002,
+  "name": "db, primary",
+  "project_id": 0,
+  "status": "Pending",
+  "power": "Off",
+  "region": "LAX1",
+  "tier": "2388g",
+  "memory_gb": 0,
+  "image": "",
+  "ips": [],
+  "tags": [],
+  "monthly_price": null,
+  "hourly_price": 0,
+  "created": ""
+}
+`,
+		},
+		{
+			name: "json empty list",
+			v:    []gotsw.Metal{},
+			opts: Options{Format: JSON},
+			want: "[]\n",
+		},
+		{
+			name: "yaml",
+			v:    []gotsw.SSHKey{{ID: 7, DisplayName: "laptop", Key: "ssh-ed25519 AAAA me"}},
+			opts: Options{Format: YAML},
+			want: "- id: 7\n  name: laptop\n  project_id: 0\n  type: ssh-ed25519\n  key: ssh-ed25519 AAAA me\n  created: \"\"\n",
+		},
+		{
+			name: "template",
+			v:    services,
+			opts: Options{Format: Template, Template: `{{.id}} {{join .ips " "}} {{json .tags}}`},
+			want: "1001 198.51.100.1 2001:db8::1 [\"web\",\"prod\"]\n1002  []\n",
+		},
+		{
+			name: "tier availability",
+			v: []gotsw.MetalTier{{
+				ID:           "7302p",
+				Availability: map[string]*gotsw.ServiceAvailability{"PIT1": {MaxQuantity: 3}, "LAX1": {MaxQuantity: 1}, "SLC1": nil},
+			}},
+			opts: Options{Columns: []string{"id", "availability"}, NoHeaders: true},
+			want: "7302p  LAX1=1,PIT1=3\n",
+		},
+		{
+			name: "records",
+			v: []Record{
+				{{"tier", "2388g"}, {"cores", int64(8)}, {"disks", map[string]string{}}, {"monthly_price", 199.0}},
+				{{"tier", "7302p"}, {"cores", int64(16)}, {"disks", map[string]string{"sda": "8t"}}, {"monthly_price", 389.0}},
+			},
+			want: "TIER   CORES  DISKS   MONTHLY_PRICE\n" +
+				"2388g  8              199.00\n" +
+				"7302p  16     sda=8t  389.00\n",
+		},
+		{
+			name: "record json",
+			v:    Record{{"tier", "7302p"}, {"quantity", int64(2)}},
+			opts: Options{Format: JSON},
+			want: "{\n  \"tier\": \"7302p\",\n  \"quantity\": 2\n}\n",
+		},
+		{
+			name: "no records",
+			v:    []Record{},
+			opts: Options{Format: JSON},
+			want: "[]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.v, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteNilPointers(t *testing.T) {
+	values := []any{
+		(*gotsw.Metal)(nil),
+		(*gotsw.MetalTier)(nil),
+		(*gotsw.MetalConfiguration)(nil),
+		(*gotsw.SSHKey)(nil),
+		(*gotsw.LogMessage)(nil),
+	}
+	for _, v := range values {
+		for _, f := range Formats {
+			var buf bytes.Buffer
+			err := Write(&buf, v, Options{Format: f, Template: "{{.id}}", NoHeaders: true})
+			if err != nil {
+				t.Errorf("Write(%T, %s) = %v", v, f, err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != "" && got != "[]" {
+				t.Errorf("Write(%T, %s) = %q, want no records", v, f, got)
+			}
+		}
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		opts Options
+		want string
+	}{
+		{"unsupported type", 42, Options{}, "unsupported type int"},
+		{"unknown column", testServices(), Options{Columns: []string{"nope"}}, `unknown column "nope"`},
+		{"unknown format", testServices(), Options{Format: "xml"}, `unknown format "xml"`},
+		{"no template", testServices(), Options{Format: Template}, "no template"},
+		{"missing template key", testServices(), Options{Format: Template, Template: "{{.nope}}"}, "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Write(&bytes.Buffer{}, tt.v, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Write() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := Records(42); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Records(42) = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		got, err := ParseFormat(strings.ToUpper(string(f)))
+		if err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %q, %v", strings.ToUpper(string(f)), got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded")
+	}
+}
+
+func TestFields(t *testing.T) {
+	fields, err := Fields([]gotsw.LogMessage(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fields, ","); got != "timestamp,name,message" {
+		t.Errorf("Fields() = %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a named value in a Record.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// Record is a flattened resource: its fields in a fixed order, under the
+// names shared by every resource type. Values are nil, bool, int64,
+// float64, string, []string, map[string]int64 or map[string]string, so
+// they encode the same way in every format.
+type Record []Field
+
+// Get returns the value of the named field.
+func (r Record) Get(name string) (any, bool) {
+	for _, f := range r {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Map returns the fields of r keyed by name. Templates are executed
+// against it.
+func (r Record) Map() map[string]any {
+	m := make(map[string]any, len(r))
+	for _, f := range r {
+		m[f.Name] = f.Value
+	}
+	return m
+}
+
+// MarshalJSON encodes r as an object with its fields in order.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML encodes r as a mapping with its fields in order.
+func (r Record) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range r {
+		var value yaml.Node
+		if err := value.Encode(f.Value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.Name}, &value)
+	}
+	return node, nil
+}
+
+// format returns the text of a value in a table or CSV cell.
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]int64:
+		return formatMap(v, func(n int64) string { return strconv.FormatInt(n, 10) })
+	case map[string]string:
+		return formatMap(v, func(s string) string { return s })
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatMap[V any](m map[string]V, str func(V) string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + str(m[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func addrs(ips []netip.Addr) []string {
+	s := make([]string, len(ips))
+	for i, ip := range ips {
+		s[i] = ip.String()
+	}
+	return s
+}
+
+// nonNil returns s, or an empty slice if s is nil, so JSON and YAML show
+// [] rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
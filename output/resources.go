@@ -0,0 +1,219 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// A field is one column of a resource.
+type field[T any] struct {
+	name  string
+	value func(T) any
+}
+
+// A resource describes how a type is flattened into a Record. Fields which
+// mean the same thing on different types share a name: "name" is always
+// the display name, "tier" the tier ID and "created" the creation time.
+type resource[T any] struct {
+	fields  []field[T]
+	columns []string // shown in tables by default
+}
+
+func (r *resource[T]) record(v T) Record {
+	rec := make(Record, len(r.fields))
+	for i, f := range r.fields {
+		rec[i] = Field{Name: f.name, Value: f.value(v)}
+	}
+	return rec
+}
+
+func (r *resource[T]) names() []string {
+	names := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+var metalResource = &resource[gotsw.Metal]{
+	fields: []field[gotsw.Metal]{
+		{"id", func(m gotsw.Metal) any { return m.ID }},
+		{"name", func(m gotsw.Metal) any { return m.DisplayName }},
+		{"project_id", func(m gotsw.Metal) any { return m.ProjectID }},
+		{"status", func(m gotsw.Metal) any { return string(m.Status) }},
+		{"power", func(m gotsw.Metal) any { return string(m.PowerState) }},
+		{"region", func(m gotsw.Metal) any { return m.RegionID }},
+		{"tier", func(m gotsw.Metal) any { return m.TierID }},
+		{"memory_gb", func(m gotsw.Metal) any { return int64(m.MemoryGB) }},
+		{"image", func(m gotsw.Metal) any { return m.ImageID }},
+		{"ips", func(m gotsw.Metal) any { return addrs(m.IPAddresses) }},
+		{"tags", func(m gotsw.Metal) any { return nonNil(m.Tags) }},
+		{"monthly_price", func(m gotsw.Metal) any {
+			if m.MonthlyPrice == nil {
+				return nil
+			}
+			return *m.MonthlyPrice
+		}},
+		{"hourly_price", func(m gotsw.Metal) any { return m.HourlyPrice }},
+		{"created", func(m gotsw.Metal) any { return m.Created }},
+	},
+	columns: []string{"id", "name", "status", "power", "region", "tier", "image", "ips"},
+}
+
+var tierResource = &resource[gotsw.MetalTier]{
+	fields: []field[gotsw.MetalTier]{
+		{"id", func(t gotsw.MetalTier) any { return t.ID }},
+		{"type", func(t gotsw.MetalTier) any { return string(t.TierType) }},
+		{"cpu", func(t gotsw.MetalTier) any { return t.CPU }},
+		{"cpu_description", func(t gotsw.MetalTier) any { return t.CPUDescription }},
+		{"memory_gb", func(t gotsw.MetalTier) any {
+			for _, m := range t.MemoryOptions {
+				if m.Default {
+					return int64(m.GB)
+				}
+			}
+			return nil
+		}},
+		{"monthly_price", func(t gotsw.MetalTier) any { return t.MonthlyPrice }},
+		{"hourly_price", func(t gotsw.MetalTier) any { return t.HourlyPrice }},
+		{"availability", func(t gotsw.MetalTier) any {
+			avail := map[string]int64{}
+			for region, a := range t.Availability {
+				if a != nil {
+					avail[region] = int64(a.MaxQuantity)
+				}
+			}
+			return avail
+		}},
+	},
+	columns: []string{"id", "cpu", "cpu_description", "type", "monthly_price", "availability"},
+}
+
+var configurationResource = &resource[gotsw.MetalConfiguration]{
+	fields: []field[gotsw.MetalConfiguration]{
+		{"tier", func(c gotsw.MetalConfiguration) any { return c.Tier.ID }},
+		{"cpu", func(c gotsw.MetalConfiguration) any { return c.Tier.CPU }},
+		{"memory_gb", func(c gotsw.MetalConfiguration) any { return int64(c.MemoryGB) }},
+		{"disks", func(c gotsw.MetalConfiguration) any {
+			if c.Disks == nil {
+				return map[string]string{}
+			}
+			return c.Disks
+		}},
+		{"quantity", func(c gotsw.MetalConfiguration) any { return int64(c.Quantity) }},
+		{"monthly_price", func(c gotsw.MetalConfiguration) any { return c.Tier.MonthlyPrice }},
+	},
+	columns: []string{"tier", "cpu", "memory_gb", "disks", "quantity"},
+}
+
+var sshKeyResource = &resource[gotsw.SSHKey]{
+	fields: []field[gotsw.SSHKey]{
+		{"id", func(k gotsw.SSHKey) any { return k.ID }},
+		{"name", func(k gotsw.SSHKey) any { return k.DisplayName }},
+		{"project_id", func(k gotsw.SSHKey) any { return k.ProjectID }},
+		{"type", func(k gotsw.SSHKey) any {
+			if fields := strings.Fields(k.Key); len(fields) > 0 {
+				return fields[0]
+			}
+			return ""
+		}},
+		{"key", func(k gotsw.SSHKey) any { return k.Key }},
+		{"created", func(k gotsw.SSHKey) any { return k.Created }},
+	},
+	columns: []string{"id", "name", "type", "created"},
+}
+
+var logMessageResource = &resource[gotsw.LogMessage]{
+	fields: []field[gotsw.LogMessage]{
+		{"timestamp", func(l gotsw.LogMessage) any { return l.Timestamp }},
+		{"name", func(l gotsw.LogMessage) any { return l.Name }},
+		{"message", func(l gotsw.LogMessage) any { return l.Message }},
+	},
+	columns: []string{"timestamp", "name", "message"},
+}
+
+// table is the flattened form of a value passed to Write.
+type table struct {
+	records []Record
+	fields  []string // every field name, in order
+	columns []string // default table columns
+	single  bool     // v was a single resource rather than a slice
+}
+
+func flatten[T any](r *resource[T], items []T, single bool) *table {
+	t := &table{
+		records: make([]Record, len(items)),
+		fields:  r.names(),
+		columns: r.columns,
+		single:  single,
+	}
+	for i, item := range items {
+		t.records[i] = r.record(item)
+	}
+	return t
+}
+
+// flattenPtr flattens a pointer to a single resource. A nil pointer is an
+// empty table.
+func flattenPtr[T any](r *resource[T], v *T) *table {
+	if v == nil {
+		return flatten(r, nil, false)
+	}
+	return flatten(r, []T{*v}, true)
+}
+
+// recordTable makes a table of records built by the caller. Its fields
+// are those of the first record, all of which are table columns.
+func recordTable(records []Record, single bool) *table {
+	t := &table{records: records, single: single}
+	if len(records) > 0 {
+		for _, f := range records[0] {
+			t.fields = append(t.fields, f.Name)
+		}
+	}
+	t.columns = t.fields
+	return t
+}
+
+// toTable flattens v, which must be one of the supported resource types,
+// a pointer to one, or a slice of them, or records.
+func toTable(v any) (*table, bool) {
+	switch v := v.(type) {
+	case gotsw.Metal:
+		return flatten(metalResource, []gotsw.Metal{v}, true), true
+	case *gotsw.Metal:
+		return flattenPtr(metalResource, v), true
+	case []gotsw.Metal:
+		return flatten(metalResource, v, false), true
+	case gotsw.MetalTier:
+		return flatten(tierResource, []gotsw.MetalTier{v}, true), true
+	case *gotsw.MetalTier:
+		return flattenPtr(tierResource, v), true
+	case []gotsw.MetalTier:
+		return flatten(tierResource, v, false), true
+	case gotsw.MetalConfiguration:
+		return flatten(configurationResource, []gotsw.MetalConfiguration{v}, true), true
+	case *gotsw.MetalConfiguration:
+		return flattenPtr(configurationResource, v), true
+	case []gotsw.MetalConfiguration:
+		return flatten(configurationResource, v, false), true
+	case gotsw.SSHKey:
+		return flatten(sshKeyResource, []gotsw.SSHKey{v}, true), true
+	case *gotsw.SSHKey:
+		return flattenPtr(sshKeyResource, v), true
+	case []gotsw.SSHKey:
+		return flatten(sshKeyResource, v, false), true
+	case gotsw.LogMessage:
+		return flatten(logMessageResource, []gotsw.LogMessage{v}, true), true
+	case *gotsw.LogMessage:
+		return flattenPtr(logMessageResource, v), true
+	case []gotsw.LogMessage:
+		return flatten(logMessageResource, v, false), true
+	case Record:
+		return recordTable([]Record{v}, true), true
+	case []Record:
+		return recordTable(v, false), true
+	}
+	return nil, false
+}
@@ -0,0 +1,174 @@
+// Package policy checks metal service requests against rules before they
+// are sent.
+//
+// Enforce returns client middleware which evaluates each policy before
+// CreateMetalService, CreateMetal, ReinstallMetalService and
+// ReinstallMetal, and fails with a *ViolationError listing every
+// violation if any policy objects.
+// Rules is a built-in declarative policy, usually loaded from YAML:
+//
+//	rules, err := policy.LoadRules("policy.yaml")
+//	...
+//	client.Use(policy.Enforce(rules))
+//
+// Other policies implement Policy, or are functions adapted with
+// PolicyFunc:
+//
+//	client.Use(policy.Enforce(rules, policy.PolicyFunc(func(ctx context.Context, r *policy.Request) []policy.Violation {
+//		if r.Tier == "l40s" && !approvedForGPU(r.ProjectID) {
+//			return []policy.Violation{{Rule: "gpu", Message: "project is not approved for GPU tiers"}}
+//		}
+//		return nil
+//	})))
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// ErrPolicyViolation is returned, wrapped in a *ViolationError, when a
+// request breaks a policy.
+var ErrPolicyViolation = errors.New("policy violation")
+
+// Request is a request being checked, normalized from the operation's
+// body.
+type Request struct {
+	Operation string // "CreateMetalService", "CreateMetal", "ReinstallMetalService" or "ReinstallMetal"
+	ProjectID int64  // 0 if the request does not name a project
+	ServiceID int64  // the service being reinstalled
+
+	// Region, Tier, Tags and Quantity are set only for creations, since a
+	// reinstall cannot change them.
+	Region   string
+	Tier     string
+	Tags     []string
+	Quantity int
+
+	Image     string
+	Password  bool // whether a root password is set
+	SSHKeyIDs []int64
+
+	// Create or Reinstall is the original request body.
+	Create    *gotsw.CreateBareMetalRequest
+	Reinstall *gotsw.ReinstallMetalRequest
+}
+
+// IsCreate reports whether r creates services.
+func (r *Request) IsCreate() bool { return r.Create != nil }
+
+// Violation is a way in which a request breaks a policy.
+type Violation struct {
+	Rule    string // the rule broken, such as "regions"
+	Message string
+}
+
+func (v Violation) String() string { return v.Rule + ": " + v.Message }
+
+// Policy decides whether requests are allowed.
+type Policy interface {
+	// Evaluate returns every way r breaks the policy, or none if it is
+	// allowed.
+	Evaluate(ctx context.Context, r *Request) []Violation
+}
+
+// PolicyFunc is a function used as a Policy.
+type PolicyFunc func(ctx context.Context, r *Request) []Violation
+
+// Evaluate calls f.
+func (f PolicyFunc) Evaluate(ctx context.Context, r *Request) []Violation {
+	return f(ctx, r)
+}
+
+// ViolationError is returned for a request refused by Enforce.
+type ViolationError struct {
+	Operation  string
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "policy: %s refused:", e.Operation)
+	for _, v := range e.Violations {
+		b.WriteString("\n  ")
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+// Unwrap returns ErrPolicyViolation.
+func (e *ViolationError) Unwrap() error { return ErrPolicyViolation }
+
+// Enforce returns middleware which evaluates every policy before metal
+// services are created or reinstalled and refuses the request, without
+// sending it, if any policy reports a violation. The error lists the
+// violations of all the policies.
+func Enforce(policies ...Policy) gotsw.Middleware {
+	return func(next gotsw.Handler) gotsw.Handler {
+		return func(ctx context.Context, op *gotsw.Operation) error {
+			r, ok := newRequest(op)
+			if !ok {
+				return next(ctx, op)
+			}
+			var violations []Violation
+			for _, p := range policies {
+				violations = append(violations, p.Evaluate(ctx, r)...)
+			}
+			if len(violations) > 0 {
+				return &ViolationError{Operation: op.Name, Violations: violations}
+			}
+			return next(ctx, op)
+		}
+	}
+}
+
+// newRequest returns the Request for op, or false if op is not checked.
+func newRequest(op *gotsw.Operation) (*Request, bool) {
+	r := &Request{Operation: op.Name}
+	if v := op.Query().Get("projectId"); v != "" {
+		r.ProjectID, _ = strconv.ParseInt(v, 10, 64)
+	}
+	switch op.Name {
+	case "CreateMetalService", "CreateMetal":
+		body, ok := op.Body.(*gotsw.CreateBareMetalRequest)
+		if !ok || body == nil {
+			return nil, false
+		}
+		r.Create = body
+		r.Region, r.Tier, r.Image = body.RegionID, body.TierID, body.ImageID
+		r.Tags = body.Tags
+		r.Quantity = max(body.Quantity, 1)
+		r.Password = body.Password != nil && *body.Password != ""
+		for _, id := range body.SSHKeyIDs {
+			r.SSHKeyIDs = append(r.SSHKeyIDs, int64(id))
+		}
+	case "ReinstallMetalService", "ReinstallMetal":
+		body, ok := op.Body.(*gotsw.ReinstallMetalRequest)
+		if !ok || body == nil {
+			return nil, false
+		}
+		r.Reinstall = body
+		r.ServiceID = serviceID(op.Path)
+		r.Image = body.ImageID
+		r.Password = body.Password != ""
+		r.SSHKeyIDs = body.SSHKeyIDs
+	default:
+		return nil, false
+	}
+	return r, true
+}
+
+// serviceID returns the ID in a path such as "Metal/1001/Reinstall".
+func serviceID(path string) int64 {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(parts[1], 10, 64)
+	return id
+}
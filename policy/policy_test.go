@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+	"github.com/teraswitch/gotsw/v2/gotswtest"
+)
+
+func TestEnforce(t *testing.T) {
+	srv := gotswtest.NewServer(gotswtest.WithProvisionDelay(0))
+	defer srv.Close()
+	ctx := context.Background()
+	id := srv.AddMetal(gotsw.Metal{DisplayName: "web-1", Status: gotsw.StatusActive, RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12"})
+
+	var seen []*Request
+	record := PolicyFunc(func(_ context.Context, r *Request) []Violation {
+		seen = append(seen, r)
+		return nil
+	})
+	rules := &Rules{Images: List{Allow: []string{"debian-*"}}, MaxQuantity: 2}
+	client := srv.Client().Use(Enforce(rules, record))
+
+	tests := []struct {
+		name       string
+		call       func() error
+		violations int
+	}{
+		{
+			name: "allowed create",
+			call: func() error {
+				_, err := client.CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{RegionID: "PIT1", TierID: "7302p", ImageID: "debian-12"})
+				return err
+			},
+		},
+		{
+			name: "refused create",
+			call: func() error {
+				_, err := client.CreateMetalService(ctx, srv.ProjectID(), &gotsw.CreateBareMetalRequest{RegionID: "PIT1", TierID: "7302p", ImageID: "ubuntu-noble", Quantity: 3})
+				return err
+			},
+			violations: 2,
+		},
+		{
+			name: "refused reinstall",
+			call: func() error {
+				_, err := client.ReinstallMetalService(ctx, id, &gotsw.ReinstallMetalRequest{ImageID: "windows-2022"})
+				return err
+			},
+			violations: 1,
+		},
+		{
+			name: "refused low-level reinstall",
+			call: func() error {
+				_, err := client.API().ReinstallMetal(ctx, id, &gotsw.ReinstallMetalRequest{ImageID: "ubuntu-noble"})
+				return err
+			},
+			violations: 1,
+		},
+		{
+			name: "other operations",
+			call: func() error {
+				_, err := client.GetMetalService(ctx, id)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var ve *ViolationError
+			switch {
+			case tt.violations == 0 && err != nil:
+				t.Errorf("err = %v, want nil", err)
+			case tt.violations > 0 && (!errors.As(err, &ve) || !errors.Is(err, ErrPolicyViolation)):
+				t.Errorf("err = %v, want a *ViolationError", err)
+			case tt.violations > 0 && len(ve.Violations) != tt.violations:
+				t.Errorf("violations = %v, want %d", ve.Violations, tt.violations)
+			}
+		})
+	}
+
+	if srv.Stock("PIT1", "7302p") != 9 {
+		t.Errorf("stock = %d, want 9: only the allowed create should be sent", srv.Stock("PIT1", "7302p"))
+	}
+	if m, _ := srv.Metal(id); m.ImageID != "debian-12" {
+		t.Errorf("image = %s after a refused reinstall", m.ImageID)
+	}
+	if len(seen) != 4 {
+		t.Fatalf("policies evaluated %d requests, want 4", len(seen))
+	}
+	if r := seen[1]; r.Operation != "CreateMetalService" || r.ProjectID != srv.ProjectID() || r.Quantity != 3 || !r.IsCreate() {
+		t.Errorf("create request = %+v", r)
+	}
+	if r := seen[2]; r.Operation != "ReinstallMetalService" || r.ServiceID != id || r.Image != "windows-2022" || r.IsCreate() {
+		t.Errorf("reinstall request = %+v", r)
+	}
+	if r := seen[3]; r.Operation != "ReinstallMetal" || r.ServiceID != id || r.Image != "ubuntu-noble" {
+		t.Errorf("low-level reinstall request = %+v", r)
+	}
+}
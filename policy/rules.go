@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is a declarative policy:
+//
+//	regions:
+//	  allow: [LAX1, PIT1]
+//	images:
+//	  allow: ["ubuntu-*", "debian-*"]
+//	tiers:
+//	  deny: [l40s]
+//	required_tags: ["owner:?*"]
+//	forbid_password: true
+//	max_quantity: 5
+//
+// Values in lists and tag patterns are matched as path.Match patterns, so
+// "*" matches any run of characters other than '/'. Region, tier and
+// image matching ignores case. Empty rules allow everything.
+type Rules struct {
+	Regions List `yaml:"regions"`
+	Tiers   List `yaml:"tiers"`
+	Images  List `yaml:"images"`
+
+	// RequiredTags are patterns each of which must match at least one of
+	// the tags of a new service.
+	RequiredTags []string `yaml:"required_tags"`
+
+	// ForbidPassword refuses requests which set a root password, so
+	// servers are reached with SSH keys.
+	ForbidPassword bool `yaml:"forbid_password"`
+
+	// RequireSSHKeys refuses requests without SSH keys.
+	RequireSSHKeys bool `yaml:"require_ssh_keys"`
+
+	// MaxQuantity, if not zero, is the most services one request may
+	// create.
+	MaxQuantity int `yaml:"max_quantity"`
+}
+
+// List allows values matching a pattern in Allow, if it is not empty,
+// unless they also match a pattern in Deny.
+type List struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// check returns why value is not allowed, or "" if it is.
+func (l List) check(value string) string {
+	if p, ok := matchAny(l.Deny, value, true); ok {
+		return fmt.Sprintf("%q is denied (%s)", value, p)
+	}
+	if len(l.Allow) > 0 {
+		if _, ok := matchAny(l.Allow, value, true); !ok {
+			return fmt.Sprintf("%q is not allowed (allowed: %s)", value, strings.Join(l.Allow, ", "))
+		}
+	}
+	return ""
+}
+
+func matchAny(patterns []string, value string, fold bool) (string, bool) {
+	for _, p := range patterns {
+		pat, v := p, value
+		if fold {
+			pat, v = strings.ToLower(pat), strings.ToLower(v)
+		}
+		if ok, _ := path.Match(pat, v); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// LoadRules reads rules from a YAML file.
+func LoadRules(file string) (*Rules, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return r, nil
+}
+
+// ParseRules parses rules from YAML. Unknown keys and invalid patterns
+// are errors, so a typo cannot silently disable a rule.
+func ParseRules(data []byte) (*Rules, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	r := &Rules{}
+	if err := dec.Decode(r); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Validate checks that every pattern is well formed.
+func (r *Rules) Validate() error {
+	var errs []error
+	lists := map[string]List{"regions": r.Regions, "tiers": r.Tiers, "images": r.Images}
+	for _, name := range []string{"regions", "tiers", "images"} {
+		for _, p := range append(append([]string{}, lists[name].Allow...), lists[name].Deny...) {
+			if _, err := path.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("policy: %s: invalid pattern %q", name, p))
+			}
+		}
+	}
+	for _, p := range r.RequiredTags {
+		if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, fmt.Errorf("policy: required_tags: invalid pattern %q", p))
+		}
+	}
+	if r.MaxQuantity < 0 {
+		errs = append(errs, errors.New("policy: max_quantity must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Evaluate returns every rule r breaks.
+func (rules *Rules) Evaluate(ctx context.Context, r *Request) []Violation {
+	var vs []Violation
+	add := func(rule, format string, args ...any) {
+		vs = append(vs, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if r.IsCreate() {
+		if msg := rules.Regions.check(r.Region); msg != "" {
+			add("regions", "region %s", msg)
+		}
+		if msg := rules.Tiers.check(r.Tier); msg != "" {
+			add("tiers", "tier %s", msg)
+		}
+	}
+	// An empty image on reinstall keeps the current one.
+	if r.Image != "" || r.IsCreate() {
+		if msg := rules.Images.check(r.Image); msg != "" {
+			add("images", "image %s", msg)
+		}
+	}
+	if r.IsCreate() {
+		for _, p := range rules.RequiredTags {
+			if _, ok := matchAnyTag(p, r.Tags); !ok {
+				add("required_tags", "no tag matches %q", p)
+			}
+		}
+		if rules.MaxQuantity > 0 && r.Quantity > rules.MaxQuantity {
+			add("max_quantity", "quantity %d is more than %d", r.Quantity, rules.MaxQuantity)
+		}
+	}
+	if rules.ForbidPassword && r.Password {
+		add("forbid_password", "root passwords are not allowed; use SSH keys")
+	}
+	if rules.RequireSSHKeys && len(r.SSHKeyIDs) == 0 {
+		add("require_ssh_keys", "at least one SSH key is required")
+	}
+	return vs
+}
+
+func matchAnyTag(pattern string, tags []string) (string, bool) {
+	for _, tag := range tags {
+		if ok, _ := path.Match(pattern, tag); ok {
+			return tag, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func createRequest(region, tier, image string, tags ...string) *Request {
+	return &Request{
+		Operation: "CreateMetalService",
+		Region:    region,
+		Tier:      tier,
+		Image:     image,
+		Tags:      tags,
+		Quantity:  1,
+		SSHKeyIDs: []int64{1},
+		Create:    &gotsw.CreateBareMetalRequest{},
+	}
+}
+
+func TestRulesEvaluate(t *testing.T) {
+	rules := &Rules{
+		Regions:        List{Allow: []string{"LAX1", "PIT1"}},
+		Tiers:          List{Deny: []string{"l40s"}},
+		Images:         List{Allow: []string{"ubuntu-*", "debian-*"}, Deny: []string{"ubuntu-jammy"}},
+		RequiredTags:   []string{"owner:?*"},
+		ForbidPassword: true,
+		RequireSSHKeys: true,
+		MaxQuantity:    5,
+	}
+	tests := []struct {
+		name string
+		r    *Request
+		want string // rules broken, space separated
+	}{
+		{"allowed", createRequest("PIT1", "7302p", "debian-12", "owner:ops"), ""},
+		{"case is ignored", createRequest("pit1", "7302P", "Ubuntu-Noble", "owner:ops"), ""},
+		{"region", createRequest("SLC1", "7302p", "debian-12", "owner:ops"), "regions"},
+		{"tier denied", createRequest("PIT1", "L40S", "debian-12", "owner:ops"), "tiers"},
+		{"image denied over allowed", createRequest("PIT1", "7302p", "ubuntu-jammy", "owner:ops"), "images"},
+		{"image not allowed", createRequest("PIT1", "7302p", "windows-2022", "owner:ops"), "images"},
+		{"empty image on create", createRequest("PIT1", "7302p", "", "owner:ops"), "images"},
+		{"tag missing", createRequest("PIT1", "7302p", "debian-12", "team:ops"), "required_tags"},
+		{"tag empty value", createRequest("PIT1", "7302p", "debian-12", "owner:"), "required_tags"},
+		{
+			name: "quantity",
+			r: func() *Request {
+				r := createRequest("PIT1", "7302p", "debian-12", "owner:ops")
+				r.Quantity = 6
+				return r
+			}(),
+			want: "max_quantity",
+		},
+		{
+			name: "password and no keys",
+			r: func() *Request {
+				r := createRequest("PIT1", "7302p", "debian-12", "owner:ops")
+				r.Password, r.SSHKeyIDs = true, nil
+				return r
+			}(),
+			want: "forbid_password require_ssh_keys",
+		},
+		{
+			name: "everything",
+			r:    &Request{Region: "AMS1", Tier: "l40s", Image: "windows-2022", Quantity: 10, Password: true, Create: &gotsw.CreateBareMetalRequest{}},
+			want: "regions tiers images required_tags max_quantity forbid_password require_ssh_keys",
+		},
+		{
+			name: "reinstall keeping the image",
+			r:    &Request{Operation: "ReinstallMetalService", SSHKeyIDs: []int64{1}, Reinstall: &gotsw.ReinstallMetalRequest{}},
+			want: "",
+		},
+		{
+			name: "reinstall",
+			r:    &Request{Operation: "ReinstallMetalService", Image: "windows-2022", Password: true, Reinstall: &gotsw.ReinstallMetalRequest{}},
+			want: "images forbid_password require_ssh_keys",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range rules.Evaluate(context.Background(), tt.r) {
+				got = append(got, v.Rule)
+				if v.Message == "" {
+					t.Errorf("%s violation has no message", v.Rule)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("Evaluate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if vs := (&Rules{}).Evaluate(context.Background(), createRequest("", "", "")); len(vs) != 0 {
+		t.Errorf("empty rules returned %v", vs)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules([]byte("regions:\n  allow: [LAX1]\nrequired_tags: [\"owner:*\"]\nmax_quantity: 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Regions.Allow) != 1 || r.RequiredTags[0] != "owner:*" || r.MaxQuantity != 3 {
+		t.Errorf("ParseRules() = %+v", r)
+	}
+	if r, err := ParseRules(nil); err != nil || r == nil {
+		t.Errorf("ParseRules(nil) = %v, %v, want empty rules", r, err)
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown key", "region:\n  allow: [LAX1]\n", "field region not found"},
+		{"invalid pattern", "images:\n  deny: [\"[\"]\n", `images: invalid pattern "["`},
+		{"invalid tag pattern", "required_tags: [\"owner:[\"]\n", `required_tags: invalid pattern`},
+		{"negative quantity", "max_quantity: -1\n", "max_quantity must not be negative"},
+		{"not yaml", "regions: [", "policy:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseRules() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
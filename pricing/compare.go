@@ -0,0 +1,203 @@
+package pricing
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// Requirements are the minimum a configuration must provide. Zero fields
+// are not constrained.
+type Requirements struct {
+	MinCores     int
+	MinThreads   int
+	MinMemoryGB  int
+	MinStorageGB int // total capacity of the drives
+
+	// StorageType, if set, counts only drives of this type towards
+	// MinStorageGB.
+	StorageType gotsw.StorageType
+
+	// Region, if set, excludes tiers with no capacity there according to
+	// their Availability.
+	Region string
+
+	TierType gotsw.MetalTierType // if set, only tiers of this type
+}
+
+// Candidate is the cheapest configuration of a tier meeting some
+// Requirements.
+type Candidate struct {
+	// Request selects the configuration: its TierID, MemoryGB and Disks
+	// are set. Disks is nil when the tier's default drives suffice.
+	Request gotsw.CreateBareMetalRequest
+	Quote   *Quote
+
+	Cores     int // 0 if the tier's CPU description could not be parsed
+	Threads   int
+	MemoryGB  int
+	StorageGB int // counted as for Requirements.MinStorageGB
+}
+
+// Compare returns the cheapest configuration of each tier meeting r,
+// sorted by monthly price. Hidden tiers, and tiers whose core or thread
+// count cannot be read when r constrains it, are left out.
+func Compare(tiers []gotsw.MetalTier, r Requirements) []Candidate {
+	var candidates []Candidate
+	for _, tier := range tiers {
+		if c, ok := cheapest(tier, r); ok {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Quote.Monthly != candidates[j].Quote.Monthly {
+			return candidates[i].Quote.Monthly < candidates[j].Quote.Monthly
+		}
+		return candidates[i].Request.TierID < candidates[j].Request.TierID
+	})
+	return candidates
+}
+
+func cheapest(tier gotsw.MetalTier, r Requirements) (Candidate, bool) {
+	if tier.Hidden || (r.TierType != "" && tier.TierType != r.TierType) {
+		return Candidate{}, false
+	}
+	if r.Region != "" {
+		if avail := tier.Availability[r.Region]; avail == nil || avail.MaxQuantity == 0 {
+			return Candidate{}, false
+		}
+	}
+	cores, threads, _ := ParseCPU(tier.CPUDescription)
+	if cores < r.MinCores || threads < r.MinThreads {
+		return Candidate{}, false
+	}
+
+	req := gotsw.CreateBareMetalRequest{TierID: tier.ID}
+	mem, ok := cheapestMemory(tier, r.MinMemoryGB)
+	if !ok {
+		return Candidate{}, false
+	}
+	req.MemoryGB = mem
+
+	storage := defaultStorage(tier, r.StorageType)
+	if storage < r.MinStorageGB {
+		disks, gb, ok := cheapestDisks(tier, r.MinStorageGB, r.StorageType)
+		if !ok {
+			return Candidate{}, false
+		}
+		req.Disks, storage = disks, gb
+	}
+
+	quote, err := Estimate(req, []gotsw.MetalTier{tier})
+	if err != nil {
+		return Candidate{}, false
+	}
+	return Candidate{
+		Request:   req,
+		Quote:     quote,
+		Cores:     cores,
+		Threads:   threads,
+		MemoryGB:  mem,
+		StorageGB: storage,
+	}, true
+}
+
+// cheapestMemory returns the cheapest memory size of at least need GB.
+func cheapestMemory(tier gotsw.MetalTier, need int) (int, bool) {
+	best, found := 0, false
+	var bestPrice float64
+	for _, opt := range tier.MemoryOptions {
+		if opt.GB < need {
+			continue
+		}
+		if !found || opt.MonthlyPrice < bestPrice || (opt.MonthlyPrice == bestPrice && opt.GB > best) {
+			best, bestPrice, found = opt.GB, opt.MonthlyPrice, true
+		}
+	}
+	return best, found || (len(tier.MemoryOptions) == 0 && need == 0)
+}
+
+// defaultStorage returns the capacity of the tier's default drives.
+func defaultStorage(tier gotsw.MetalTier, typ gotsw.StorageType) int {
+	total := 0
+	for _, slot := range tier.DriveSlots {
+		if d, ok := driveOption(slot, slot.Default); ok && (typ == "" || d.Type == typ) {
+			total += d.CapacityGB
+		}
+	}
+	return total
+}
+
+// cheapestDisks chooses a drive for each slot so the capacity counted is
+// at least need at the lowest monthly price. It works slot by slot,
+// keeping the cheapest choices for each capacity reached, capped at need.
+func cheapestDisks(tier gotsw.MetalTier, need int, typ gotsw.StorageType) (map[string]string, int, bool) {
+	type partial struct {
+		price    float64
+		capacity int // uncapped
+		disks    map[string]string
+	}
+	states := map[int]partial{0: {disks: map[string]string{}}}
+	for _, slot := range tier.DriveSlots {
+		next := map[int]partial{}
+		keep := func(p partial) {
+			key := min(p.capacity, need)
+			if cur, ok := next[key]; !ok || p.price < cur.price {
+				next[key] = p
+			}
+		}
+		for _, key := range sortedKeys(states) {
+			p := states[key]
+			if slot.Default == "" && !slot.Required {
+				keep(p) // the slot may be left empty
+			}
+			for _, d := range slot.Options {
+				gb := 0
+				if typ == "" || d.Type == typ {
+					gb = d.CapacityGB
+				}
+				disks := make(map[string]string, len(p.disks)+1)
+				for k, v := range p.disks {
+					disks[k] = v
+				}
+				disks[slot.ID] = d.Name
+				keep(partial{price: p.price + d.MonthlyPrice, capacity: p.capacity + gb, disks: disks})
+			}
+		}
+		states = next
+	}
+	best, ok := states[need]
+	return best.disks, best.capacity, ok
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+var (
+	coresPattern   = regexp.MustCompile(`(?i)(\d+)\s*c(?:ores?)?\b`)
+	threadsPattern = regexp.MustCompile(`(?i)(\d+)\s*t(?:hreads?)?\b`)
+)
+
+// ParseCPU reads the core and thread counts from a tier's CPU description,
+// such as "16c / 32t". A description without a thread count has as many
+// threads as cores. ok is false if there is no core count.
+func ParseCPU(description string) (cores, threads int, ok bool) {
+	m := coresPattern.FindStringSubmatch(description)
+	if m == nil {
+		return 0, 0, false
+	}
+	cores, _ = strconv.Atoi(m[1])
+	threads = cores
+	if m := threadsPattern.FindStringSubmatch(description); m != nil {
+		threads, _ = strconv.Atoi(m[1])
+	}
+	return cores, threads, true
+}
@@ -0,0 +1,83 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Requirements
+		want string // tier:memory:storage:disks:monthly, space separated
+	}{
+		{
+			name: "no requirements",
+			want: "2388g:0:480:map[]:199 7302p:128:960:map[]:349",
+		},
+		{
+			name: "cores",
+			r:    Requirements{MinCores: 10},
+			want: "7302p:128:960:map[]:349",
+		},
+		{
+			name: "memory",
+			r:    Requirements{MinMemoryGB: 200},
+			want: "7302p:256:960:map[]:449",
+		},
+		{
+			name: "storage",
+			r:    Requirements{MinStorageGB: 4000},
+			want: "7302p:128:8960:map[nvme0n1:960g sda:8t]:389",
+		},
+		{
+			name: "storage type",
+			r:    Requirements{MinStorageGB: 1000, StorageType: gotsw.StorageTypeNVME},
+			want: "7302p:128:3840:map[nvme0n1:3.84t]:409",
+		},
+		{
+			name: "region",
+			r:    Requirements{Region: "LAX1"},
+			want: "2388g:0:480:map[]:199",
+		},
+		{
+			name: "unmet",
+			r:    Requirements{MinThreads: 64},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Compare(testTiers(), tt.r) {
+				got = append(got, fmt.Sprintf("%s:%d:%d:%v:%v", c.Request.TierID, c.MemoryGB, c.StorageGB, c.Request.Disks, c.Quote.Monthly))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("Compare() = %q, want %q", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCPU(t *testing.T) {
+	tests := []struct {
+		desc           string
+		cores, threads int
+		ok             bool
+	}{
+		{"16c / 32t", 16, 32, true},
+		{"8 Cores, 16 Threads", 8, 16, true},
+		{"24 cores", 24, 24, true},
+		{"AMD EPYC", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		cores, threads, ok := ParseCPU(tt.desc)
+		if cores != tt.cores || threads != tt.threads || ok != tt.ok {
+			t.Errorf("ParseCPU(%q) = %d, %d, %v, want %d, %d, %v", tt.desc, cores, threads, ok, tt.cores, tt.threads, tt.ok)
+		}
+	}
+}
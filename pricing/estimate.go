@@ -0,0 +1,186 @@
+// Package pricing estimates the cost of metal services from tier pricing,
+// without calling the API.
+//
+// A tier's price covers its base configuration. Each memory, drive and
+// network option adds its own price, which is zero for the defaults.
+// Estimate adds these up for a CreateBareMetalRequest, given the tiers
+// from ListMetalTiers:
+//
+//	tiers, err := client.ListMetalTiers(ctx, "")
+//	...
+//	quote, err := pricing.Estimate(gotsw.CreateBareMetalRequest{
+//		TierID:   "7302p",
+//		MemoryGB: 256,
+//		Disks:    map[string]string{"nvme0n1": "3.84t"},
+//		Quantity: 3,
+//	}, tiers.Result)
+//	fmt.Print(quote)
+//
+// Compare finds every tier which can meet minimum CPU, memory and storage
+// requirements and prices its cheapest such configuration.
+//
+// Prices are list prices. Discounts for reserved pricing are not
+// reflected.
+package pricing
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+// LineItem is one priced component of a service.
+type LineItem struct {
+	Component   string  // "tier", "memory", "disk <slot>" or "network"
+	Description string  // the option chosen, such as "256GB" or "3.84t NVME"
+	Hourly      float64 // per service
+	Monthly     float64 // per service
+}
+
+// Quote is the estimated cost of a request.
+type Quote struct {
+	Tier     gotsw.MetalTier
+	Quantity int
+	Items    []LineItem
+
+	// Hourly and Monthly are the cost of one service: the sum of Items.
+	Hourly  float64
+	Monthly float64
+}
+
+// TotalHourly returns the hourly cost of every service in the request.
+func (q *Quote) TotalHourly() float64 { return q.Hourly * float64(q.Quantity) }
+
+// TotalMonthly returns the monthly cost of every service in the request.
+func (q *Quote) TotalMonthly() float64 { return q.Monthly * float64(q.Quantity) }
+
+// String formats the quote as a table of line items and totals.
+func (q *Quote) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "COMPONENT\tOPTION\tHOURLY\tMONTHLY\n")
+	for _, item := range q.Items {
+		fmt.Fprintf(w, "%s\t%s\t%.4f\t%.2f\n", item.Component, item.Description, item.Hourly, item.Monthly)
+	}
+	fmt.Fprintf(w, "per service\t\t%.4f\t%.2f\n", q.Hourly, q.Monthly)
+	if q.Quantity != 1 {
+		fmt.Fprintf(w, "total (x%d)\t\t%.4f\t%.2f\n", q.Quantity, q.TotalHourly(), q.TotalMonthly())
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Estimate prices req from tiers. Memory and drives not given in req are
+// the tier's defaults, and the network is the tier's default option. It
+// is an error if req names a tier, memory size or drive the tier does not
+// offer. A zero Quantity is one service.
+func Estimate(req gotsw.CreateBareMetalRequest, tiers []gotsw.MetalTier) (*Quote, error) {
+	tier, ok := findTier(tiers, req.TierID)
+	if !ok {
+		return nil, fmt.Errorf("pricing: unknown tier %q", req.TierID)
+	}
+	q := &Quote{Tier: tier, Quantity: max(req.Quantity, 1)}
+	q.add(LineItem{Component: "tier", Description: tier.ID, Hourly: tier.HourlyPrice, Monthly: tier.MonthlyPrice})
+
+	mem, ok := memoryOption(tier, req.MemoryGB)
+	if !ok {
+		return nil, fmt.Errorf("pricing: memory %dGB is not offered on tier %s", req.MemoryGB, tier.ID)
+	}
+	if mem.GB != 0 {
+		q.add(LineItem{Component: "memory", Description: fmt.Sprintf("%dGB", mem.GB), Hourly: mem.HourlyPrice, Monthly: mem.MonthlyPrice})
+	}
+
+	slots := map[string]bool{}
+	for _, slot := range tier.DriveSlots {
+		slots[slot.ID] = true
+		name, ok := req.Disks[slot.ID]
+		if !ok {
+			name = slot.Default
+		}
+		if name == "" {
+			continue
+		}
+		drive, ok := driveOption(slot, name)
+		if !ok {
+			return nil, fmt.Errorf("pricing: drive %q is not offered in slot %s of tier %s", name, slot.ID, tier.ID)
+		}
+		q.add(LineItem{Component: "disk " + slot.ID, Description: driveDescription(drive), Hourly: drive.HourlyPrice, Monthly: drive.MonthlyPrice})
+	}
+	for slot := range req.Disks {
+		if !slots[slot] {
+			return nil, fmt.Errorf("pricing: %q is not a drive slot on tier %s", slot, tier.ID)
+		}
+	}
+
+	if net, ok := defaultNetwork(tier); ok {
+		desc := fmt.Sprintf("%dGbps", net.SpeedGbps)
+		if net.IsBonded {
+			desc += " bonded"
+		}
+		q.add(LineItem{Component: "network", Description: desc, Hourly: net.HourlyPrice, Monthly: net.MonthlyPrice})
+	}
+	return q, nil
+}
+
+func (q *Quote) add(item LineItem) {
+	q.Items = append(q.Items, item)
+	q.Hourly += item.Hourly
+	q.Monthly += item.Monthly
+}
+
+func findTier(tiers []gotsw.MetalTier, id string) (gotsw.MetalTier, bool) {
+	for _, t := range tiers {
+		if strings.EqualFold(t.ID, id) {
+			return t, true
+		}
+	}
+	return gotsw.MetalTier{}, false
+}
+
+// memoryOption returns the option of gb, or the default if gb is zero. A
+// tier without memory options has only its base memory, which is a zero
+// option.
+func memoryOption(tier gotsw.MetalTier, gb int) (gotsw.MemoryOption, bool) {
+	if len(tier.MemoryOptions) == 0 {
+		return gotsw.MemoryOption{}, gb == 0
+	}
+	for _, opt := range tier.MemoryOptions {
+		if (gb == 0 && opt.Default) || (gb != 0 && opt.GB == gb) {
+			return opt, true
+		}
+	}
+	if gb == 0 {
+		return tier.MemoryOptions[0], true
+	}
+	return gotsw.MemoryOption{}, false
+}
+
+func driveOption(slot gotsw.DriveSlot, name string) (gotsw.MetalStorageDevice, bool) {
+	for _, opt := range slot.Options {
+		if opt.Name == name {
+			return opt, true
+		}
+	}
+	return gotsw.MetalStorageDevice{}, false
+}
+
+func driveDescription(d gotsw.MetalStorageDevice) string {
+	if d.Type == "" {
+		return d.Name
+	}
+	return d.Name + " " + string(d.Type)
+}
+
+func defaultNetwork(tier gotsw.MetalTier) (gotsw.NetworkOption, bool) {
+	for _, opt := range tier.NetworkOptions {
+		if opt.Default {
+			return opt, true
+		}
+	}
+	if len(tier.NetworkOptions) > 0 {
+		return tier.NetworkOptions[0], true
+	}
+	return gotsw.NetworkOption{}, false
+}
@@ -0,0 +1,119 @@
+package pricing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/teraswitch/gotsw/v2"
+)
+
+func testTiers() []gotsw.MetalTier {
+	return []gotsw.MetalTier{
+		{
+			ID:             "7302p",
+			CPUDescription: "16c / 32t",
+			MonthlyPrice:   349,
+			HourlyPrice:    0.5,
+			MemoryOptions: []gotsw.MemoryOption{
+				{GB: 128, Default: true},
+				{GB: 256, MonthlyPrice: 100, HourlyPrice: 0.15},
+			},
+			DriveSlots: []gotsw.DriveSlot{
+				{ID: "nvme0n1", Default: "960g", Required: true, Options: []gotsw.MetalStorageDevice{
+					{Name: "960g", Type: gotsw.StorageTypeNVME, CapacityGB: 960},
+					{Name: "3.84t", Type: gotsw.StorageTypeNVME, CapacityGB: 3840, MonthlyPrice: 60, HourlyPrice: 0.09},
+				}},
+				{ID: "sda", Options: []gotsw.MetalStorageDevice{
+					{Name: "8t", Type: gotsw.StorageTypeHDD, CapacityGB: 8000, MonthlyPrice: 40, HourlyPrice: 0.06},
+				}},
+			},
+			NetworkOptions: []gotsw.NetworkOption{{SpeedGbps: 10, Default: true}, {SpeedGbps: 25, MonthlyPrice: 50}},
+			Availability:   map[string]*gotsw.ServiceAvailability{"PIT1": {MaxQuantity: 10}},
+		},
+		{
+			ID:             "2388g",
+			CPUDescription: "8 cores",
+			MonthlyPrice:   199,
+			HourlyPrice:    0.3,
+			DriveSlots: []gotsw.DriveSlot{
+				{ID: "nvme0n1", Default: "480g", Required: true, Options: []gotsw.MetalStorageDevice{
+					{Name: "480g", Type: gotsw.StorageTypeSSD, CapacityGB: 480},
+				}},
+			},
+			Availability: map[string]*gotsw.ServiceAvailability{"LAX1": {MaxQuantity: 5}},
+		},
+		{ID: "old", CPUDescription: "64c / 128t", MonthlyPrice: 10, Hidden: true},
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     gotsw.CreateBareMetalRequest
+		items   string // component=description, space separated
+		monthly float64
+		total   float64
+	}{
+		{
+			name:    "defaults",
+			req:     gotsw.CreateBareMetalRequest{TierID: "7302p"},
+			items:   "tier=7302p memory=128GB disk nvme0n1=960g NVME network=10Gbps",
+			monthly: 349,
+			total:   349,
+		},
+		{
+			name:    "options",
+			req:     gotsw.CreateBareMetalRequest{TierID: "7302P", MemoryGB: 256, Disks: map[string]string{"nvme0n1": "3.84t", "sda": "8t"}, Quantity: 3},
+			items:   "tier=7302p memory=256GB disk nvme0n1=3.84t NVME disk sda=8t HDD network=10Gbps",
+			monthly: 549,
+			total:   1647,
+		},
+		{
+			name:    "no memory options",
+			req:     gotsw.CreateBareMetalRequest{TierID: "2388g"},
+			items:   "tier=2388g disk nvme0n1=480g SSD",
+			monthly: 199,
+			total:   199,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Estimate(tt.req, testTiers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var items []string
+			for _, item := range q.Items {
+				items = append(items, item.Component+"="+item.Description)
+			}
+			if got := strings.Join(items, " "); got != tt.items {
+				t.Errorf("items = %q, want %q", got, tt.items)
+			}
+			if q.Monthly != tt.monthly || q.TotalMonthly() != tt.total {
+				t.Errorf("Monthly = %v, TotalMonthly() = %v, want %v and %v", q.Monthly, q.TotalMonthly(), tt.monthly, tt.total)
+			}
+		})
+	}
+}
+
+func TestEstimateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  gotsw.CreateBareMetalRequest
+		want string
+	}{
+		{"unknown tier", gotsw.CreateBareMetalRequest{TierID: "nope"}, `unknown tier "nope"`},
+		{"memory", gotsw.CreateBareMetalRequest{TierID: "7302p", MemoryGB: 64}, "memory 64GB is not offered"},
+		{"memory without options", gotsw.CreateBareMetalRequest{TierID: "2388g", MemoryGB: 64}, "memory 64GB is not offered"},
+		{"drive", gotsw.CreateBareMetalRequest{TierID: "7302p", Disks: map[string]string{"nvme0n1": "8t"}}, `drive "8t" is not offered in slot nvme0n1`},
+		{"slot", gotsw.CreateBareMetalRequest{TierID: "7302p", Disks: map[string]string{"sdz": "8t"}}, `"sdz" is not a drive slot`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Estimate(tt.req, testTiers())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Estimate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
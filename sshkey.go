@@ -2,7 +2,6 @@ package gotsw
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -28,15 +27,15 @@ type ListSshKeyResponse Result[[]SSHKey]
 // ListSshKeys retrieves all SSH keys assigned to your project
 func (c *Client) ListSshKeys(ctx context.Context) ([]SSHKey, error) {
 	resp := &ListSshKeyResponse{}
-	httpResp, err := c.Request(ctx, http.MethodGet, "SshKey", nil)
+	err := c.do(ctx, &Operation{
+		Name:   "ListSshKeys",
+		Method: http.MethodGet,
+		Path:   "SshKey",
+		Result: resp,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return nil, err
-	}
 
 	if !resp.Success {
 		return nil, errors.New(resp.Message)
@@ -48,15 +47,15 @@ func (c *Client) ListSshKeys(ctx context.Context) ([]SSHKey, error) {
 // GetSshKey retrieves a specific SSH key by ID
 func (c *Client) GetSshKey(ctx context.Context, id int64) (SSHKey, error) {
 	resp := &SshKeyResponse{}
-	httpResp, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("SshKey/%d", id), nil)
+	err := c.do(ctx, &Operation{
+		Name:   "GetSshKey",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("SshKey/%d", id),
+		Result: resp,
+	})
 	if err != nil {
 		return SSHKey{}, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return SSHKey{}, err
-	}
 
 	if !resp.Success {
 		return SSHKey{}, errors.New(resp.Message)
@@ -75,15 +74,16 @@ type CreateSshKeyRequest struct {
 func (c *Client) CreateSshKey(ctx context.Context, projectID int64, key CreateSshKeyRequest) (SSHKey, error) {
 	resp := &SshKeyResponse{}
 	key.ProjectID = projectID
-	httpResp, err := c.Request(ctx, http.MethodPost, "SshKey", key)
+	err := c.do(ctx, &Operation{
+		Name:   "CreateSshKey",
+		Method: http.MethodPost,
+		Path:   "SshKey",
+		Body:   key,
+		Result: resp,
+	})
 	if err != nil {
 		return SSHKey{}, err
 	}
-	defer httpResp.Body.Close()
-
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
-		return SSHKey{}, err
-	}
 
 	if !resp.Success {
 		return SSHKey{}, errors.New(resp.Message)
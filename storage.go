@@ -0,0 +1,250 @@
+package gotsw
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sizes for storage layouts. Drive capacities are reported in decimal
+// units, so a "960g" drive holds 960 * Gigabyte bytes.
+const (
+	Gigabyte int64 = 1000 * 1000 * 1000
+	Terabyte int64 = 1000 * Gigabyte
+)
+
+// Remainder is the size of a volume which takes the space left on its
+// devices once every other volume has been allocated.
+const Remainder int64 = 0
+
+// ErrStorageNotCustomizable is returned when a custom storage layout is used
+// with an image which has DisableCustomizableStorage set.
+var ErrStorageNotCustomizable = errors.New("image does not support custom storage layouts")
+
+// StorageLayout builds the Partitions and RaidArrays of a create or reinstall
+// request. Each volume is described once and the builder derives the
+// partition and array names which reference each other:
+//
+//	parts, arrays, err := gotsw.NewStorageLayout().
+//		Mirror([]string{"nvme0n1", "nvme1n1"}, 100*gotsw.Gigabyte, gotsw.FileSystemExt4, "/").
+//		Mirror([]string{"nvme0n1", "nvme1n1"}, gotsw.Remainder, gotsw.FileSystemXfs, "/data").
+//		Build(metal.StorageDevices)
+type StorageLayout struct {
+	volumes []volume
+	image   *Image
+}
+
+// volume is a filesystem placed on one device or across a RAID array of
+// devices.
+type volume struct {
+	raid       RaidType
+	devices    []string
+	size       int64
+	fileSystem FileSystem
+	mountPoint string
+}
+
+// NewStorageLayout returns an empty storage layout.
+func NewStorageLayout() *StorageLayout {
+	return &StorageLayout{}
+}
+
+// Partition adds a partition of size bytes on device, formatted with fs and
+// mounted at mountPoint.
+func (l *StorageLayout) Partition(device string, size int64, fs FileSystem, mountPoint string) *StorageLayout {
+	l.volumes = append(l.volumes, volume{raid: RaidTypeNone, devices: []string{device}, size: size, fileSystem: fs, mountPoint: mountPoint})
+	return l
+}
+
+// Mirror adds a RAID 1 array across a partition of size bytes on each of
+// devices, formatted with fs and mounted at mountPoint.
+func (l *StorageLayout) Mirror(devices []string, size int64, fs FileSystem, mountPoint string) *StorageLayout {
+	l.volumes = append(l.volumes, volume{raid: RaidTypeRaid1, devices: devices, size: size, fileSystem: fs, mountPoint: mountPoint})
+	return l
+}
+
+// Stripe adds a RAID 0 array across a partition of size bytes on each of
+// devices, formatted with fs and mounted at mountPoint.
+func (l *StorageLayout) Stripe(devices []string, size int64, fs FileSystem, mountPoint string) *StorageLayout {
+	l.volumes = append(l.volumes, volume{raid: RaidTypeRaid0, devices: devices, size: size, fileSystem: fs, mountPoint: mountPoint})
+	return l
+}
+
+// ForImage records the image the layout will be installed with. Build fails
+// with ErrStorageNotCustomizable if the image does not allow custom storage.
+func (l *StorageLayout) ForImage(image Image) *StorageLayout {
+	l.image = &image
+	return l
+}
+
+// Build returns the partitions and RAID arrays for the layout. If devices is
+// not nil, as with Metal.StorageDevices or the result of TierStorageDevices,
+// the layout is checked to fit on them.
+func (l *StorageLayout) Build(devices map[string]MetalStorageDevice) ([]Partition, []RaidArray, error) {
+	if l.image != nil && l.image.DisableCustomizableStorage && len(l.volumes) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrStorageNotCustomizable, l.image.ID)
+	}
+
+	var partitions []Partition
+	var arrays []RaidArray
+	counts := map[string]int{}
+	for _, v := range l.volumes {
+		var size *int64
+		if v.size != Remainder {
+			size = &v.size
+		}
+
+		var members []string
+		for _, device := range v.devices {
+			counts[device]++
+			p := Partition{
+				Name:      fmt.Sprintf("%s-part%d", device, counts[device]),
+				Device:    device,
+				SizeBytes: size,
+			}
+			if v.raid == RaidTypeNone {
+				p.FileSystem = v.fileSystem
+				p.MountPoint = v.mountPoint
+			}
+			partitions = append(partitions, p)
+			members = append(members, p.Name)
+		}
+
+		if v.raid != RaidTypeNone {
+			arrays = append(arrays, RaidArray{
+				Name:       fmt.Sprintf("md%d", len(arrays)),
+				Type:       v.raid,
+				Members:    members,
+				FileSystem: v.fileSystem,
+				MountPoint: v.mountPoint,
+			})
+		}
+	}
+
+	if err := ValidateStorage(partitions, arrays, devices); err != nil {
+		return nil, nil, err
+	}
+	return partitions, arrays, nil
+}
+
+// TierStorageDevices returns the drives a metal service on tier will have
+// when created with disks, keyed by drive slot. Slots missing from disks use
+// their default drive.
+func TierStorageDevices(tier MetalTier, disks map[string]string) map[string]MetalStorageDevice {
+	devices := map[string]MetalStorageDevice{}
+	for _, slot := range tier.DriveSlots {
+		name, ok := disks[slot.ID]
+		if !ok {
+			name = slot.Default
+		}
+		for _, opt := range slot.Options {
+			if opt.Name == name {
+				devices[slot.ID] = opt
+			}
+		}
+	}
+	return devices
+}
+
+// ValidateStorage checks partitions and RAID arrays for a create or
+// reinstall request, whether built by StorageLayout or by hand. If devices
+// is not nil, partitions must be on one of its drives and fit within its
+// capacity. Every problem found is reported in the returned error.
+func ValidateStorage(partitions []Partition, arrays []RaidArray, devices map[string]MetalStorageDevice) error {
+	var errs []error
+
+	// mounts counts the filesystems mounted at each path.
+	mounts := map[string]int{}
+	mount := func(mountPoint string) {
+		if mountPoint != "" {
+			mounts[mountPoint]++
+		}
+	}
+
+	byName := map[string]Partition{}
+	used := map[string]int64{}
+	remainder := map[string]bool{}
+	for _, p := range partitions {
+		if _, ok := byName[p.Name]; ok {
+			errs = append(errs, fmt.Errorf("partition %q is defined more than once", p.Name))
+		}
+		byName[p.Name] = p
+		mount(p.MountPoint)
+
+		if devices != nil {
+			if _, ok := devices[p.Device]; !ok {
+				errs = append(errs, fmt.Errorf("partition %q: device %q does not exist", p.Name, p.Device))
+				continue
+			}
+		}
+		switch {
+		case p.SizeBytes == nil:
+			if remainder[p.Device] {
+				errs = append(errs, fmt.Errorf("partition %q: device %q already has a partition using the remaining space", p.Name, p.Device))
+			}
+			remainder[p.Device] = true
+		case *p.SizeBytes <= 0:
+			errs = append(errs, fmt.Errorf("partition %q: size must be positive", p.Name))
+		default:
+			used[p.Device] += *p.SizeBytes
+		}
+	}
+	for _, device := range sortedKeys(used) {
+		d, ok := devices[device]
+		if !ok {
+			continue
+		}
+		if capacity := int64(d.CapacityGB) * Gigabyte; used[device] > capacity {
+			errs = append(errs, fmt.Errorf("device %q: partitions use %d bytes, capacity is %d", device, used[device], capacity))
+		}
+	}
+
+	members := map[string]string{}
+	for _, a := range arrays {
+		mount(a.MountPoint)
+
+		minMembers := 2
+		if a.Type == RaidTypeNone {
+			minMembers = 1
+		}
+		if len(a.Members) < minMembers {
+			errs = append(errs, fmt.Errorf("raid array %q: %s needs at least %d members, has %d", a.Name, a.Type, minMembers, len(a.Members)))
+		}
+		for _, m := range a.Members {
+			if other, ok := members[m]; ok {
+				errs = append(errs, fmt.Errorf("raid array %q: member %q is already used by %q", a.Name, m, other))
+			}
+			members[m] = a.Name
+
+			p, isPartition := byName[m]
+			_, isDevice := devices[m]
+			switch {
+			case isPartition && (p.FileSystem != "" || p.MountPoint != ""):
+				errs = append(errs, fmt.Errorf("raid array %q: member partition %q must not have its own filesystem", a.Name, m))
+			case !isPartition && devices != nil && !isDevice:
+				errs = append(errs, fmt.Errorf("raid array %q: member %q is not a partition or device", a.Name, m))
+			}
+		}
+		if len(a.Partitions) > 0 && (a.SizeBytes != nil || a.FileSystem != "" || a.MountPoint != "") {
+			errs = append(errs, fmt.Errorf("raid array %q: partitioned arrays cannot set a size, filesystem or mount point", a.Name))
+		}
+		for _, p := range a.Partitions {
+			mount(p.MountPoint)
+		}
+	}
+
+	if len(partitions) > 0 || len(arrays) > 0 {
+		switch n := mounts["/"]; {
+		case n == 0:
+			errs = append(errs, errors.New("no filesystem is mounted at /"))
+		case n > 1:
+			errs = append(errs, fmt.Errorf("%d filesystems are mounted at /", n))
+		}
+	}
+	for _, mp := range sortedKeys(mounts) {
+		if mp != "/" && mounts[mp] > 1 {
+			errs = append(errs, fmt.Errorf("%d filesystems are mounted at %s", mounts[mp], mp))
+		}
+	}
+
+	return errors.Join(errs...)
+}
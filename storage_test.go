@@ -0,0 +1,190 @@
+package gotsw
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testDevices = map[string]MetalStorageDevice{
+	"nvme0n1": {Name: "960g", CapacityGB: 960},
+	"nvme1n1": {Name: "960g", CapacityGB: 960},
+}
+
+func TestStorageLayoutBuild(t *testing.T) {
+	parts, arrays, err := NewStorageLayout().
+		Mirror([]string{"nvme0n1", "nvme1n1"}, 100*Gigabyte, FileSystemExt4, "/").
+		Partition("nvme0n1", 8*Gigabyte, FileSystemSwap, "").
+		Stripe([]string{"nvme0n1", "nvme1n1"}, Remainder, FileSystemXfs, "/data").
+		Build(testDevices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := 100 * Gigabyte
+	swap := 8 * Gigabyte
+	wantParts := []Partition{
+		{Name: "nvme0n1-part1", Device: "nvme0n1", SizeBytes: &size},
+		{Name: "nvme1n1-part1", Device: "nvme1n1", SizeBytes: &size},
+		{Name: "nvme0n1-part2", Device: "nvme0n1", SizeBytes: &swap, FileSystem: FileSystemSwap},
+		{Name: "nvme0n1-part3", Device: "nvme0n1"},
+		{Name: "nvme1n1-part2", Device: "nvme1n1"},
+	}
+	wantArrays := []RaidArray{
+		{Name: "md0", Type: RaidTypeRaid1, Members: []string{"nvme0n1-part1", "nvme1n1-part1"}, FileSystem: FileSystemExt4, MountPoint: "/"},
+		{Name: "md1", Type: RaidTypeRaid0, Members: []string{"nvme0n1-part3", "nvme1n1-part2"}, FileSystem: FileSystemXfs, MountPoint: "/data"},
+	}
+	if !reflect.DeepEqual(parts, wantParts) {
+		t.Errorf("partitions = %+v, want %+v", parts, wantParts)
+	}
+	if !reflect.DeepEqual(arrays, wantArrays) {
+		t.Errorf("arrays = %+v, want %+v", arrays, wantArrays)
+	}
+}
+
+func TestStorageLayoutForImage(t *testing.T) {
+	image := Image{ID: "windows-2022", DisableCustomizableStorage: true}
+	_, _, err := NewStorageLayout().ForImage(image).Partition("nvme0n1", Remainder, FileSystemExt4, "/").Build(nil)
+	if !errors.Is(err, ErrStorageNotCustomizable) {
+		t.Errorf("err = %v, want ErrStorageNotCustomizable", err)
+	}
+	if _, _, err := NewStorageLayout().ForImage(image).Build(nil); err != nil {
+		t.Errorf("empty layout: err = %v, want nil", err)
+	}
+}
+
+func TestTierStorageDevices(t *testing.T) {
+	got := TierStorageDevices(testTier, map[string]string{"nvme0n1": "1.92t", "nvme1n1": "960g"})
+	names := map[string]string{}
+	for slot, d := range got {
+		names[slot] = d.Name
+	}
+	want := map[string]string{"nvme0n1": "1.92t", "nvme1n1": "960g"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("TierStorageDevices() = %v, want %v", names, want)
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	size := func(gb int64) *int64 {
+		b := gb * Gigabyte
+		return &b
+	}
+	zero := int64(0)
+	root := Partition{Name: "root", Device: "nvme0n1", SizeBytes: size(100), FileSystem: FileSystemExt4, MountPoint: "/"}
+	tests := []struct {
+		name       string
+		partitions []Partition
+		arrays     []RaidArray
+		devices    map[string]MetalStorageDevice
+		want       []string // substrings of the error, none if valid
+	}{
+		{name: "empty"},
+		{name: "single root partition", partitions: []Partition{root}, devices: testDevices},
+		{name: "unknown devices allowed without a device list", partitions: []Partition{{Name: "r", Device: "sdz", MountPoint: "/"}}},
+		{
+			name:       "duplicate partition",
+			partitions: []Partition{root, root},
+			want:       []string{`partition "root" is defined more than once`, "2 filesystems are mounted at /"},
+		},
+		{
+			name:       "missing device",
+			partitions: []Partition{{Name: "root", Device: "sdz", MountPoint: "/"}},
+			devices:    testDevices,
+			want:       []string{`partition "root": device "sdz" does not exist`},
+		},
+		{
+			name: "two remainder partitions",
+			partitions: []Partition{
+				{Name: "a", Device: "nvme0n1", MountPoint: "/"},
+				{Name: "b", Device: "nvme0n1", MountPoint: "/data"},
+			},
+			want: []string{`partition "b": device "nvme0n1" already has a partition using the remaining space`},
+		},
+		{
+			name:       "zero size",
+			partitions: []Partition{{Name: "root", Device: "nvme0n1", SizeBytes: &zero, MountPoint: "/"}},
+			want:       []string{`partition "root": size must be positive`},
+		},
+		{
+			name: "over capacity",
+			partitions: []Partition{
+				{Name: "root", Device: "nvme0n1", SizeBytes: size(900), MountPoint: "/"},
+				{Name: "data", Device: "nvme0n1", SizeBytes: size(100), MountPoint: "/data"},
+			},
+			devices: testDevices,
+			want:    []string{`device "nvme0n1": partitions use 1000000000000 bytes, capacity is 960000000000`},
+		},
+		{
+			name:       "raid too few members",
+			partitions: []Partition{{Name: "p1", Device: "nvme0n1"}},
+			arrays:     []RaidArray{{Name: "md0", Type: RaidTypeRaid1, Members: []string{"p1"}, MountPoint: "/"}},
+			want:       []string{`raid array "md0": Raid1 needs at least 2 members, has 1`},
+		},
+		{
+			name:       "raid member reused",
+			partitions: []Partition{{Name: "p1", Device: "nvme0n1"}, {Name: "p2", Device: "nvme1n1"}},
+			arrays: []RaidArray{
+				{Name: "md0", Type: RaidTypeRaid1, Members: []string{"p1", "p2"}, MountPoint: "/"},
+				{Name: "md1", Type: RaidTypeRaid0, Members: []string{"p2", "p1"}, MountPoint: "/data"},
+			},
+			want: []string{`raid array "md1": member "p2" is already used by "md0"`},
+		},
+		{
+			name:       "raid member with filesystem",
+			partitions: []Partition{{Name: "p1", Device: "nvme0n1", FileSystem: FileSystemExt4}, {Name: "p2", Device: "nvme1n1"}},
+			arrays:     []RaidArray{{Name: "md0", Type: RaidTypeRaid1, Members: []string{"p1", "p2"}, MountPoint: "/"}},
+			want:       []string{`raid array "md0": member partition "p1" must not have its own filesystem`},
+		},
+		{
+			name:    "raid member unknown",
+			arrays:  []RaidArray{{Name: "md0", Type: RaidTypeRaid1, Members: []string{"nvme0n1", "sdz"}, MountPoint: "/"}},
+			devices: testDevices,
+			want:    []string{`raid array "md0": member "sdz" is not a partition or device`},
+		},
+		{
+			name: "partitioned array with filesystem",
+			arrays: []RaidArray{{
+				Name: "md0", Type: RaidTypeRaid1, Members: []string{"nvme0n1", "nvme1n1"},
+				FileSystem: FileSystemExt4,
+				Partitions: []Partition{{Name: "md0p1", MountPoint: "/"}},
+			}},
+			devices: testDevices,
+			want:    []string{`raid array "md0": partitioned arrays cannot set a size, filesystem or mount point`},
+		},
+		{
+			name:       "no root",
+			partitions: []Partition{{Name: "data", Device: "nvme0n1", MountPoint: "/data"}},
+			want:       []string{"no filesystem is mounted at /"},
+		},
+		{
+			name: "duplicate mount point",
+			partitions: []Partition{
+				root,
+				{Name: "a", Device: "nvme1n1", SizeBytes: size(10), MountPoint: "/data"},
+				{Name: "b", Device: "nvme1n1", SizeBytes: size(10), MountPoint: "/data"},
+			},
+			want: []string{"2 filesystems are mounted at /data"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStorage(tt.partitions, tt.arrays, tt.devices)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateStorage() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ValidateStorage() = nil, want errors %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateStorage() = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package gotsw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultReadyPollInterval is how often WaitMetalReady polls when given
+// no interval.
+const DefaultReadyPollInterval = 15 * time.Second
+
+// ErrProvisioningFailed is returned by WaitMetalReady when a service ends
+// up in the Error status or a provisioning event fails.
+var ErrProvisioningFailed = errors.New("gotsw: provisioning failed")
+
+// Ready reports whether the service has finished provisioning: it is
+// Active and every provisioning event is Complete.
+func (m *Metal) Ready() bool {
+	if m.Status != StatusActive {
+		return false
+	}
+	for _, e := range m.Events {
+		if e.State != EventStateComplete {
+			return false
+		}
+	}
+	return true
+}
+
+// failed returns an error if provisioning of the service has failed.
+func (m *Metal) failed() error {
+	if m.Status == StatusError {
+		return fmt.Errorf("%w: service %d is in status %s", ErrProvisioningFailed, m.ID, m.Status)
+	}
+	for _, e := range m.Events {
+		if e.State == EventStateError {
+			return fmt.Errorf("%w: service %d: %s", ErrProvisioningFailed, m.ID, e.Body)
+		}
+	}
+	return nil
+}
+
+// WaitMetalReady polls a metal service every interval until it is Ready,
+// returning the service. An interval of zero or less is
+// DefaultReadyPollInterval. It fails with ErrProvisioningFailed if
+// provisioning fails, or with the context's error if ctx is done first;
+// bound the wait with context.WithTimeout.
+func (c *Client) WaitMetalReady(ctx context.Context, id int64, interval time.Duration) (*Metal, error) {
+	if interval <= 0 {
+		interval = DefaultReadyPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		resp, err := c.GetMetalService(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		m := &resp.Result
+		if err := m.failed(); err != nil {
+			return m, err
+		}
+		if m.Ready() {
+			return m, nil
+		}
+		select {
+		case <-ctx.Done():
+			return m, fmt.Errorf("waiting for service %d: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
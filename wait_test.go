@@ -0,0 +1,66 @@
+package gotsw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStatusServer returns a client for a server which moves a service
+// through statuses, one per request, staying on the last.
+func newStatusServer(t *testing.T, statuses ...Status) *Client {
+	t.Helper()
+	var mu sync.Mutex
+	step := -1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		step = min(step+1, len(statuses)-1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Result[Metal]{Success: true, Result: Metal{ID: 1, Status: statuses[step]}})
+	}))
+	t.Cleanup(srv.Close)
+	c := New("key")
+	c.URL, _ = url.Parse(srv.URL + "/v2/")
+	return c
+}
+
+func TestWaitMetalReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []Status
+		interval time.Duration
+		timeout  time.Duration
+		err      error
+	}{
+		{name: "ready", statuses: []Status{StatusPending, StatusActive}, interval: time.Millisecond},
+		{name: "failed", statuses: []Status{StatusPending, StatusError}, interval: time.Millisecond, err: ErrProvisioningFailed},
+		{name: "timeout", statuses: []Status{StatusPending}, interval: time.Millisecond, timeout: 20 * time.Millisecond, err: context.DeadlineExceeded},
+		{name: "zero interval", statuses: []Status{StatusActive}},
+		{name: "negative interval", statuses: []Status{StatusPending}, interval: -time.Second, timeout: 20 * time.Millisecond, err: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newStatusServer(t, tt.statuses...)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			m, err := client.WaitMetalReady(ctx, 1, tt.interval)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("WaitMetalReady() = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !m.Ready() {
+				t.Errorf("WaitMetalReady() returned %s service", m.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package gotsw
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch polls when given no interval.
+const DefaultWatchInterval = 30 * time.Second
+
+// ChangeType is a kind of change to a metal service.
+type ChangeType string
+
+const (
+	ChangeAdded             ChangeType = "Added"
+	ChangeRemoved           ChangeType = "Removed"
+	ChangeStatus            ChangeType = "StatusChanged"
+	ChangePowerState        ChangeType = "PowerStateChanged"
+	ChangeRenamed           ChangeType = "Renamed"
+	ChangeTags              ChangeType = "TagsChanged"
+	ChangeIPs               ChangeType = "IPsChanged"
+	ChangeProvisioningEvent ChangeType = "NewProvisioningEvent" // a provisioning event appeared or changed state
+)
+
+// MetalChange is a change to a metal service seen by Watch.
+type MetalChange struct {
+	Type ChangeType
+	ID   int64
+	Time time.Time // when the change was seen
+
+	// Before and After are snapshots of the service either side of the
+	// change. Before is nil for ChangeAdded and After for ChangeRemoved.
+	Before *Metal
+	After  *Metal
+
+	// Event is the new or updated event for ChangeProvisioningEvent.
+	Event *ProvisioningEvent
+}
+
+// DiffMetal returns the changes between two snapshots of the same metal
+// service, in the order of the ChangeType constants. Either snapshot may
+// be nil, for an added or removed service.
+func DiffMetal(before, after *Metal) []MetalChange {
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		return []MetalChange{{Type: ChangeAdded, ID: after.ID, After: after}}
+	case after == nil:
+		return []MetalChange{{Type: ChangeRemoved, ID: before.ID, Before: before}}
+	}
+
+	var changes []MetalChange
+	add := func(t ChangeType) {
+		changes = append(changes, MetalChange{Type: t, ID: after.ID, Before: before, After: after})
+	}
+	if before.Status != after.Status {
+		add(ChangeStatus)
+	}
+	if before.PowerState != after.PowerState {
+		add(ChangePowerState)
+	}
+	if before.DisplayName != after.DisplayName {
+		add(ChangeRenamed)
+	}
+	if !sameStrings(before.Tags, after.Tags) {
+		add(ChangeTags)
+	}
+	if !slices.Equal(before.IPAddresses, after.IPAddresses) {
+		add(ChangeIPs)
+	}
+
+	type eventKey struct {
+		priority  int32
+		body      string
+		timestamp time.Time
+	}
+	seen := map[eventKey]EventState{}
+	for _, e := range before.Events {
+		seen[eventKey{e.Priority, e.Body, e.Timestamp}] = e.State
+	}
+	for i := range after.Events {
+		e := &after.Events[i]
+		if state, ok := seen[eventKey{e.Priority, e.Body, e.Timestamp}]; ok && state == e.State {
+			continue
+		}
+		changes = append(changes, MetalChange{
+			Type:   ChangeProvisioningEvent,
+			ID:     after.ID,
+			Before: before,
+			After:  after,
+			Event:  e,
+		})
+	}
+	return changes
+}
+
+// sameStrings reports whether a and b hold the same strings, ignoring
+// order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// Watch polls ListMetal with opts every interval and sends the changes it
+// sees on the returned channel, which is closed when ctx is done. An
+// interval of zero or less is DefaultWatchInterval. The first poll
+// reports every matching service as added. Pagination is
+// followed; a failed poll is logged with the client's logger and retried
+// at the next interval, without reporting services as removed.
+func (c *Client) Watch(ctx context.Context, opts ListMetalOptions, interval time.Duration) <-chan MetalChange {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ch := make(chan MetalChange)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var known map[int64]*Metal
+		for {
+			current, err := c.ListAllMetal(ctx, opts)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				c.Logger().WarnContext(ctx, "gotsw: watch: listing metal services failed", "error", err)
+			default:
+				next := make(map[int64]*Metal, len(current))
+				for i := range current {
+					next[current[i].ID] = &current[i]
+				}
+				if !c.sendChanges(ctx, ch, known, next, current) {
+					return
+				}
+				known = next
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
+
+// sendChanges sends the changes from known to next, with additions and
+// changes in list order followed by removals. It returns false if ctx was
+// done first.
+func (c *Client) sendChanges(ctx context.Context, ch chan<- MetalChange, known, next map[int64]*Metal, list []Metal) bool {
+	now := time.Now()
+	var changes []MetalChange
+	for i := range list {
+		changes = append(changes, DiffMetal(known[list[i].ID], &list[i])...)
+	}
+	var removed []int64
+	for id := range known {
+		if next[id] == nil {
+			removed = append(removed, id)
+		}
+	}
+	slices.Sort(removed)
+	for _, id := range removed {
+		changes = append(changes, DiffMetal(known[id], nil)...)
+	}
+
+	for _, change := range changes {
+		change.Time = now
+		select {
+		case ch <- change:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
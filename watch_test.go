@@ -0,0 +1,147 @@
+package gotsw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiffMetal(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := Metal{
+		ID:          1,
+		DisplayName: "web-1",
+		Status:      StatusPending,
+		PowerState:  PowerStateOff,
+		Tags:        []string{"a", "b"},
+		IPAddresses: []netip.Addr{netip.MustParseAddr("198.51.100.1")},
+		Events:      []ProvisioningEvent{{Priority: 0, Body: "Installing", Timestamp: ts, State: EventStateInProgress}},
+	}
+	tests := []struct {
+		name   string
+		before *Metal
+		change func(m *Metal) // applied to a copy of base for after
+		want   string         // change types, space separated
+	}{
+		{name: "added", want: "Added"},
+		{name: "removed", before: &base, change: nil, want: "Removed"},
+		{name: "unchanged", before: &base, change: func(*Metal) {}, want: ""},
+		{name: "tags reordered", before: &base, change: func(m *Metal) { m.Tags = []string{"b", "a"} }, want: ""},
+		{
+			name:   "several",
+			before: &base,
+			change: func(m *Metal) {
+				m.Status = StatusActive
+				m.PowerState = PowerStateOn
+				m.DisplayName = "web-2"
+				m.Tags = []string{"a"}
+				m.IPAddresses = nil
+			},
+			want: "StatusChanged PowerStateChanged Renamed TagsChanged IPsChanged",
+		},
+		{
+			name:   "provisioning events",
+			before: &base,
+			change: func(m *Metal) {
+				m.Events = []ProvisioningEvent{
+					{Priority: 0, Body: "Installing", Timestamp: ts, State: EventStateComplete},
+					{Priority: 1, Body: "Configuring", Timestamp: ts.Add(time.Minute), State: EventStateInProgress},
+				}
+			},
+			want: "NewProvisioningEvent:Installing NewProvisioningEvent:Configuring",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var after *Metal
+			switch {
+			case tt.before == nil:
+				after = &base
+			case tt.change != nil:
+				m := base
+				m.Tags = append([]string(nil), base.Tags...)
+				tt.change(&m)
+				after = &m
+			}
+			var got []string
+			for _, c := range DiffMetal(tt.before, after) {
+				s := string(c.Type)
+				if c.Event != nil {
+					s += ":" + c.Event.Body
+				}
+				got = append(got, s)
+				if c.ID != 1 {
+					t.Errorf("%s: ID = %d, want 1", c.Type, c.ID)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("DiffMetal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if changes := DiffMetal(nil, nil); changes != nil {
+		t.Errorf("DiffMetal(nil, nil) = %v", changes)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	lists := [][]Metal{
+		{{ID: 1, DisplayName: "a", Status: StatusPending}, {ID: 2, DisplayName: "b", Status: StatusActive}},
+		nil, // a failed poll
+		{{ID: 1, DisplayName: "a", Status: StatusActive}, {ID: 3, DisplayName: "c", Status: StatusPending}},
+	}
+	var mu sync.Mutex
+	poll := -1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		poll = min(poll+1, len(lists)-1)
+		if lists[poll] == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Result[[]Metal]{Success: true, Result: lists[poll]})
+	}))
+	defer srv.Close()
+	c := New("key")
+	c.URL, _ = url.Parse(srv.URL + "/v2/")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	want := []string{"Added 1", "Added 2", "StatusChanged 1", "Added 3", "Removed 2"}
+	var got []string
+	for change := range c.Watch(ctx, ListMetalOptions{}, time.Millisecond) {
+		got = append(got, fmt.Sprintf("%s %d", change.Type, change.ID))
+		if change.Time.IsZero() {
+			t.Errorf("%s %d has no time", change.Type, change.ID)
+		}
+		if len(got) == len(want) {
+			cancel()
+		}
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+}
+
+func TestWatchZeroInterval(t *testing.T) {
+	c, _ := newTestClient(t, http.StatusOK, `{"success":true,"result":[{"id":1,"displayName":"a"}]}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := c.Watch(ctx, ListMetalOptions{}, 0)
+	if change := <-ch; change.Type != ChangeAdded || change.ID != 1 {
+		t.Errorf("first change = %s %d, want Added 1", change.Type, change.ID)
+	}
+	cancel()
+	for range ch {
+	}
+}
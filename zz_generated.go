@@ -0,0 +1,1948 @@
+// Code generated by internal/gen from swagger.json. DO NOT EDIT.
+
+package gotsw
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Account is generated from the Account schema.
+type Account struct {
+	ID      int64  `json:"id"`
+	Created string `json:"created"`
+	Deleted string `json:"deleted,omitempty"`
+	// Object ID prefix
+	ObjectType string `json:"objectType,omitempty"`
+	// Name of the account
+	AccountName string `json:"accountName,omitempty"`
+	// Id of the account in the Hostbill System
+	ExternalIdentifier string `json:"externalIdentifier,omitempty"`
+	// The ID for the Stripe customer account
+	StripeID    string      `json:"stripeId,omitempty"`
+	AccountRole AccountRole `json:"accountRole"`
+	// Flag that denote if account is using TSW billing
+	UseBilling bool `json:"useBilling"`
+	// Flag that denotes if an account is locked or not
+	AccountLocked bool `json:"accountLocked"`
+	// Flag that denotes if the account has a valid payment method set up
+	ValidPaymentMethod bool `json:"validPaymentMethod"`
+	// Flag that denotes if account needs valid payment set up to provision machine
+	RequirePaymentMethodOnAccountForProvisioning bool `json:"requirePaymentMethodOnAccountForProvisioning"`
+}
+
+// AccountRole is generated from the AccountRole schema.
+type AccountRole int32
+
+// AttachToNetworkRequest is generated from the AttachToNetworkRequest schema.
+//
+// Details for attaching an instance to a network
+type AttachToNetworkRequest struct {
+	// Id of the network to attach to the instance
+	NetworkID string `json:"networkId,omitempty"`
+}
+
+// AttachVolumeRequest is generated from the AttachVolumeRequest schema.
+type AttachVolumeRequest struct {
+	// The id of the volume to attach
+	VolumeID string `json:"volumeId"`
+	// The location where the volume will be mounted on the instance
+	MountPoint string `json:"mountPoint"`
+	// The region of both the volume and the instance
+	RegionID string `json:"regionId"`
+	// *Optional* - The id of the instance to attach the volume to
+	InstanceID int64 `json:"instanceId"`
+}
+
+// AttachVolumeResponse is generated from the AttachVolumeResponse schema.
+//
+// Response to the request to attach a volume to an instance
+type AttachVolumeResponse struct {
+	// The id of the region
+	RegionID string `json:"regionId,omitempty"`
+	// The id of the instance the volume is attached to
+	InstanceID int64 `json:"instanceId"`
+	// The location where the volume is mounted on the instance
+	MountPoint string `json:"mountPoint,omitempty"`
+	// The id of the volume to attach
+	VolumeID string `json:"volumeId,omitempty"`
+}
+
+// CalculatePriceRequest is generated from the CalculatePriceRequest schema.
+type CalculatePriceRequest struct {
+	ServiceType ServiceType       `json:"serviceType"`
+	RegionID    string            `json:"regionId,omitempty"`
+	TierID      string            `json:"tierId,omitempty"`
+	Reserved    bool              `json:"reserved"`
+	MemoryGB    float64           `json:"memoryGb"`
+	NetworkGbps float64           `json:"networkGbps"`
+	Disks       map[string]string `json:"disks,omitempty"`
+}
+
+// CalculatePriceResponse is generated from the CalculatePriceResponse schema.
+//
+// Provides a breakdown of the pricing for a service
+type CalculatePriceResponse struct {
+	ServiceType ServiceType           `json:"serviceType"`
+	RegionID    string                `json:"regionId,omitempty"`
+	TierID      string                `json:"tierId,omitempty"`
+	Reserved    bool                  `json:"reserved"`
+	Memory      *MemoryPriceResponse  `json:"memory,omitempty"`
+	Network     *NetworkPriceResponse `json:"network,omitempty"`
+	// The price details for the drive configuration
+	Drives []DrivePriceResponse `json:"drives,omitempty"`
+	// The total monthly price for the service
+	MonthlyPrice float64 `json:"monthlyPrice"`
+	// The total hourly price for the service
+	HourlyPrice float64 `json:"hourlyPrice"`
+	// The base monthly price for the tier
+	TierMonthlyPrice float64 `json:"tierMonthlyPrice"`
+	// The base hourly price for the tier
+	TierHourlyPrice float64 `json:"tierHourlyPrice"`
+}
+
+// CloudService is generated from the CloudService schema.
+//
+// Represents a cloud service, which is a virtual machine.
+type CloudService struct {
+	ID                 int64       `json:"id"`
+	Created            string      `json:"created"`
+	Deleted            string      `json:"deleted,omitempty"`
+	ProjectID          int64       `json:"projectId"`
+	ParentServiceID    *int64      `json:"parentServiceId,omitempty"`
+	ServiceType        ServiceType `json:"serviceType"`
+	Status             Status      `json:"status"`
+	RegionID           string      `json:"regionId,omitempty"`
+	TierID             string      `json:"tierId,omitempty"`
+	ExternalIdentifier string      `json:"externalIdentifier,omitempty"`
+	BillingID          string      `json:"billingId,omitempty"`
+	ContractID         *int64      `json:"contractId,omitempty"`
+	DisplayName        string      `json:"displayName,omitempty"`
+	Tags               []string    `json:"tags,omitempty"`
+	// UID for the cost rate for this service as it's stored in the billing system
+	RateID         string       `json:"rateId,omitempty"`
+	Region         *Region      `json:"region,omitempty"`
+	Account        *Account     `json:"account,omitempty"`
+	MetalDevice    *MetalDevice `json:"metalDevice,omitempty"`
+	SKU            string       `json:"sku,omitempty"`
+	ReservePricing *bool        `json:"reservePricing,omitempty"`
+	// Object type pneumonic
+	ObjectType string `json:"objectType,omitempty"`
+	// ID for the OS image used to create
+	ImageID    string     `json:"imageId,omitempty"`
+	PowerState PowerState `json:"powerState"`
+	// The IP addresses assigned to the service
+	IPAddresses []string   `json:"ipAddresses,omitempty"`
+	Tier        *CloudTier `json:"tier,omitempty"`
+	Image       *Image     `json:"image,omitempty"`
+}
+
+// CloudTier is generated from the CloudTier schema.
+//
+// Tier for a cloud service.
+type CloudTier struct {
+	// ID of the tier
+	ID string `json:"id,omitempty"`
+	// Amount of memory for the VM
+	Memory int32 `json:"memory"`
+	// Number of virtual CPUs for the VM
+	VCPUs    int32 `json:"vcpus"`
+	Transfer int32 `json:"transfer"`
+	Hidden   bool  `json:"hidden"`
+}
+
+// CreateInstanceRequest is generated from the CreateInstanceRequest schema.
+type CreateInstanceRequest struct {
+	// Display name for the service.
+	DisplayName string `json:"displayName,omitempty"`
+	// The region this service should be created in (for example: PIT1).  If an invalid region is provided you will receive a 400 Bad Request.  Use the Regions endpoint to retrieve available regions.
+	RegionID string `json:"regionId"`
+	// The service tier to be created.  For metal, this is typically the server config.  For example: 7302p would create a Epyc 7302P system.  Tier availability can be retrieved using the regions endpoints.
+	TierID    string `json:"tierId"`
+	ProjectID int64  `json:"projectId"`
+	// The image to use when creating this service.  Available images can be retrieved via the images endpoint.
+	ImageID string `json:"imageId,omitempty"`
+	// The SSH key ids to be added to the service.  These keys will be added to the authorized_keys file for the root user.
+	SSHKeyIDs []int64 `json:"sshKeyIds,omitempty"`
+	// The password to be set for the root user.  If not provided, a random password will be generated.
+	Password string `json:"password,omitempty"`
+	// Additional user data
+	UserData string     `json:"userData,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	SSHKeys  []SSHKey   `json:"sshKeys,omitempty"`
+	BootSize int32      `json:"bootSize"`
+	TierObj  *CloudTier `json:"tierObj,omitempty"`
+}
+
+// CreateMetalRequest is generated from the CreateMetalRequest schema.
+//
+// Request details for creating a metal service.
+type CreateMetalRequest struct {
+	// Display name for the service.
+	DisplayName string `json:"displayName,omitempty"`
+	// The region this service should be created in (for example: PIT1).  If an invalid region is provided you will receive a 400 Bad Request.  Use the Regions endpoint to retrieve available regions.
+	RegionID string `json:"regionId"`
+	// The service tier to be created.  For metal, this is typically the server config.  For example: 7302p would create a Epyc 7302P system.  Tier availability can be retrieved using the regions endpoints.
+	TierID    string `json:"tierId"`
+	ProjectID int64  `json:"projectId"`
+	// The image to use when creating this service.  Available images can be retrieved via the images endpoint.
+	ImageID string `json:"imageId,omitempty"`
+	// The SSH key ids to be added to the service.  These keys will be added to the authorized_keys file for the root user.
+	SSHKeyIDs []int64 `json:"sshKeyIds,omitempty"`
+	// The password to be set for the root user.  If not provided, a random password will be generated.
+	Password string `json:"password,omitempty"`
+	// Additional user data
+	UserData string   `json:"userData,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	SSHKeys  []SSHKey `json:"sshKeys,omitempty"`
+	// The amount of memory in GB to be allocated to the service.
+	MemoryGB int32 `json:"memoryGb"`
+	// Dictionary of disk names and sizes in GB. If not specified, the default configuration for the metal tier will
+	// be used.
+	//
+	// The key is the disk name and the value is the size in GB.  For example:
+	//
+	//     "disks": {
+	//         "nvme0n1": "960g",
+	//         "nvme1n1": "960g"
+	//     }
+	Disks map[string]string `json:"disks,omitempty"`
+	// Partitions to be created.  Not specifying this will result in a single root partition being created.
+	//
+	// Example for specifying partitions:
+	//
+	//     "partitions": [
+	//         {
+	//             "name": "nvme0n1-part1",
+	//             "device": "nvme0n1",
+	//             "sizeBytes": 50000000000
+	//         },
+	//         {
+	//             "name": "nvme0n1-part1",
+	//             "device": "nvme0n1",
+	//             "sizeBytes": 50000000000
+	//         },
+	//         {
+	//             "name": "nvme1n1-part2",
+	//             "device": "nvme1n1"
+	//             // If sizeBytes is not specified for a device, the remainder of the space will be used.
+	//         }
+	//     ]
+	Partitions []Partition `json:"partitions,omitempty"`
+	// Raid arrays to be created. Can reference physical device names or partitions from mediums of the same class.
+	//
+	// Example of specifying raid arrays:
+	//
+	//     "raidArrays": [
+	//         {
+	//             "name": "md0",
+	//             "type": "Raid1",
+	//             "members": [
+	//                 "nvme0n1-part1",
+	//                 "nvme1n1-part1"
+	//             ],
+	//             "fileSystem": "Ext4",
+	//             "mountPoint": "/"
+	//         }
+	//     ]
+	RaidArrays []RaidArray `json:"raidArrays,omitempty"`
+	// If doing an iPXE boot, this is the URL to the script.
+	IPXEURL string `json:"ipxeUrl,omitempty"`
+	// Template can be specified instead of image, partitions, sshKeyId, and userData.
+	TemplateID *int64 `json:"templateId,omitempty"`
+	// The number of services to be created.  By default, one will be created.
+	Quantity *int32 `json:"quantity,omitempty"`
+	// Denotes if the metal service is being reserved for a whole year. If so, it gets the discounted rate
+	ReservePricing bool       `json:"reservePricing"`
+	SSHKeyID       int64      `json:"sshKeyId"`
+	TierObj        *MetalTier `json:"tierObj,omitempty"`
+}
+
+// CreateNetworkRequest is generated from the CreateNetworkRequest schema.
+//
+// Details about the network to be created
+type CreateNetworkRequest struct {
+	// The id of the region that this network will be set up in
+	RegionID string `json:"regionId,omitempty"`
+	// *Optional* - The display name of the network
+	DisplayName string `json:"displayName,omitempty"`
+	// The IPv4 network address. For example: 10.99.0.0
+	V4Subnet string `json:"v4Subnet,omitempty"`
+	// The number of bits for the netmask in CIDR notation. For example: 24
+	V4SubnetMask string `json:"v4SubnetMask,omitempty"`
+}
+
+// CreateNetworkResponseDetails is generated from the CreateNetworkResponseDetails schema.
+//
+// Details about the newly created network
+type CreateNetworkResponseDetails struct {
+	// The unique id for the network
+	ID string `json:"id,omitempty"`
+	// The region id for the network
+	RegionID string `json:"regionId,omitempty"`
+	// The timestamp for when the network was created
+	DateCreated string `json:"dateCreated"`
+	// The display name of the network
+	DisplayName string `json:"displayName,omitempty"`
+	// The IPv4 network address. For example: 10.99.0.0
+	V4Subnet string `json:"v4Subnet,omitempty"`
+	// The number of bits for the netmask in CIDR notation. For example: 24
+	V4SubnetMask string `json:"v4SubnetMask,omitempty"`
+}
+
+// CreateVolumeRequest is generated from the CreateVolumeRequest schema.
+//
+// Request to create a storage volume
+type CreateVolumeRequest struct {
+	// The data center region where the storage volume will be created
+	RegionID string `json:"regionId"`
+	// The size of the volume, in gibibytes (GiB).
+	Size int32 `json:"size"`
+	// Decide whether you want an HDD or an NVME SSD
+	VolumeType string `json:"volumeType,omitempty"`
+	// *Optional* - The name of the storage volume
+	DisplayName string `json:"displayName,omitempty"`
+	// *Optional* - The volume description.
+	Description string `json:"description,omitempty"`
+	// *Optional* - The name of the image from which you want to create the volume.
+	ImageName string `json:"imageName,omitempty"`
+}
+
+// CreateVolumeResponse is generated from the CreateVolumeResponse schema.
+//
+// Response to the request to create a storage volume
+type CreateVolumeResponse struct {
+	// The id of the storage volume
+	VolumeID string `json:"volumeId,omitempty"`
+	// The time the volume was last updated
+	UpdatedAt string `json:"updatedAt"`
+	// The time the volume was created
+	CreatedAt string `json:"createdAt"`
+	// The size of the volume
+	Size string `json:"size,omitempty"`
+	// The current status of the volume
+	Status string `json:"status,omitempty"`
+	// The description of the volume
+	Description string `json:"description,omitempty"`
+	// The name of the volume
+	DisplayName string `json:"displayName,omitempty"`
+	// The type of the volume. This should be "SSD" or "HDD"
+	VolumeType string `json:"volumeType,omitempty"`
+	// The region in which the volume was created
+	Region string `json:"region,omitempty"`
+}
+
+// DeleteVolumeRequest is generated from the DeleteVolumeRequest schema.
+//
+// Details needed to delete a volume
+type DeleteVolumeRequest struct {
+	// The region that the volume is in
+	RegionID string `json:"regionId"`
+	// The id of the volume to be deleted
+	VolumeID string `json:"volumeId"`
+}
+
+// DetachFromNetworkRequest is generated from the DetachFromNetworkRequest schema.
+//
+// Details for detaching an instance from a network
+type DetachFromNetworkRequest struct {
+	// The ID of the network to detach from
+	NetworkID string `json:"networkId,omitempty"`
+}
+
+// DetachVolumeRequest is generated from the DetachVolumeRequest schema.
+//
+// Request to detach a volume from an instance
+type DetachVolumeRequest struct {
+	// The region of both the volume and the instance
+	RegionID string `json:"regionId"`
+	// The ID of the volume to detach
+	VolumeID string `json:"volumeId"`
+}
+
+// DetachVolumeResponse is generated from the DetachVolumeResponse schema.
+//
+// Response to detaching a volume from an instance
+type DetachVolumeResponse struct {
+	// The region of both the volume and the instance
+	RegionID string `json:"regionId,omitempty"`
+	// The id of the volume to detach
+	VolumeID string `json:"volumeId,omitempty"`
+}
+
+// Details is generated from the Details schema.
+//
+// Details about the instance networks
+type Details struct {
+	// The ID of the network
+	NetworkID string `json:"networkId,omitempty"`
+	// The ID of the region the network is in
+	RegionID string `json:"regionId,omitempty"`
+	// Human readable description of the network
+	Description string `json:"description,omitempty"`
+	// The IPV4 subnet of the network
+	V4Subnet string `json:"v4Subnet,omitempty"`
+	// The IPV4 subnet mask of the network
+	V4SubnetMask string `json:"v4SubnetMask,omitempty"`
+}
+
+// DeviceVendor is generated from the DeviceVendor schema.
+type DeviceVendor int32
+
+// DrivePriceResponse is generated from the DrivePriceResponse schema.
+//
+// Details about the price of a drive option
+type DrivePriceResponse struct {
+	Slot string  `json:"slot,omitempty"`
+	Size float64 `json:"size"`
+	Unit string  `json:"unit,omitempty"`
+	// The monthly price for the drive option
+	MonthlyPrice float64 `json:"monthlyPrice"`
+	// The hourly price for the drive option
+	HourlyPrice float64 `json:"hourlyPrice"`
+}
+
+// ExtendVolumeRequest is generated from the ExtendVolumeRequest schema.
+//
+// Details needed to extend a volume
+type ExtendVolumeRequest struct {
+	// The new size for the volume, in gibibytes (GiB)
+	NewSize int32 `json:"newSize"`
+	// The region in which the volume is located
+	RegionID string `json:"regionId"`
+	// The id of the volume to be extended
+	VolumeID string `json:"volumeId"`
+}
+
+// ExtendVolumeResponse is generated from the ExtendVolumeResponse schema.
+//
+// Response to extending a volume
+type ExtendVolumeResponse struct {
+	// The id of the volume to extend
+	RegionID string `json:"regionId,omitempty"`
+	// The new size for the volume, in gibibytes (GiB)
+	NewSize int32 `json:"newSize"`
+	// The id of the volume to be extended
+	VolumeID string `json:"volumeId,omitempty"`
+}
+
+// GetInvoiceResponseDetails is generated from the GetInvoiceResponseDetails schema.
+//
+// Details about the invoice
+type GetInvoiceResponseDetails struct {
+	// The id of the invoice
+	ID int64 `json:"id"`
+	// The total amount of the invoice
+	Total float64 `json:"total"`
+	// The account id the invoice applies to
+	AccountID int64 `json:"accountId"`
+	// The status of the invoice. One of: Draft, Sent, Paid, Void
+	Status string `json:"status,omitempty"`
+	// The due date for the invoice
+	DueDate string `json:"dueDate,omitempty"`
+	// The individual line items of the invoice
+	Lines []InvoiceLineResponse `json:"lines,omitempty"`
+	// The date that the invoice was created
+	InvoiceDate string `json:"invoiceDate,omitempty"`
+	// The date that the invoice was paid
+	DatePaid string `json:"datePaid,omitempty"`
+	// The date to pay the invoice before
+	PayBefore string `json:"payBefore"`
+}
+
+// GetNetworkResponseDetails is generated from the GetNetworkResponseDetails schema.
+//
+// Object containing details about the network
+type GetNetworkResponseDetails struct {
+	// A unique id for the network
+	ID string `json:"id,omitempty"`
+	// The id of the region the network is in
+	RegionID string `json:"regionId,omitempty"`
+	// The timestamp for when the network was created
+	DateTimeCreated string `json:"dateTimeCreated,omitempty"`
+	// The display name of the network
+	DisplayName string `json:"displayName,omitempty"`
+	// The IPv4 network address. For example: 10.99.0.0
+	V4Subnet string `json:"v4Subnet,omitempty"`
+	// The number of bits for the netmask in CIDR notation. For example: 24
+	V4SubnetMask string `json:"v4SubnetMask,omitempty"`
+}
+
+// Image is generated from the Image schema.
+//
+// Details about the OS image applied to a service
+type Image struct {
+	// The id for the OS image
+	ID string `json:"id,omitempty"`
+	// Human-readable name for the OS image
+	DisplayName string `json:"displayName,omitempty"`
+	// Base name for the Operating System. Like ```Ubuntu``` or
+	// ```Windows Server```
+	OperatingSystemName string `json:"operatingSystemName,omitempty"`
+	// Specific version of the Operating System. Like ```18.04 LTS``` for
+	// Ubuntu or ```2019 Standard``` for Windows
+	OperatingSystemVersion string `json:"operatingSystemVersion,omitempty"`
+	// Reduces customization options for storage. Necessary for Windows
+	// and iPXE installs.
+	DisableCustomizableStorage bool   `json:"disableCustomizableStorage"`
+	MetalIdentifier            string `json:"metalIdentifier,omitempty"`
+	MetalCloudInit             string `json:"metalCloudInit,omitempty"`
+	MetalUefiCloudInit         string `json:"metalUefiCloudInit,omitempty"`
+}
+
+// Interface is generated from the Interface schema.
+type Interface struct {
+	Name       string `json:"name,omitempty"`
+	LinkSpeed  int32  `json:"linkSpeed"`
+	MACAddress string `json:"macAddress,omitempty"`
+}
+
+// InvoiceLineResponse is generated from the InvoiceLineResponse schema.
+//
+// Individual line item on the invoice
+type InvoiceLineResponse struct {
+	// Description of the service being billed for
+	Description string `json:"description,omitempty"`
+	// Metadata about the service provided. This could be anything, but it would be additional information worth
+	// calling out about the service, like region, memory, or hard disks.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// The amount of usage calculated for the service
+	Amount float64 `json:"amount"`
+	// The total cost of the line item. This is the amount multiplied by the unit price.
+	Total float64 `json:"total"`
+	// The rate a which the service is billed at.
+	UnitPrice float64 `json:"unitPrice"`
+	// The display name of the service
+	DisplayName string `json:"displayName,omitempty"`
+	// The region the service is in
+	RegionID    string      `json:"regionId,omitempty"`
+	ServiceType ServiceType `json:"serviceType"`
+	// The service tier. In the case of metal this would processor of the system.
+	Tier string `json:"tier,omitempty"`
+	// The id of the service associated with the invoice line
+	ServiceID int64 `json:"serviceId"`
+}
+
+// InvoiceResponseRow is generated from the InvoiceResponseRow schema.
+type InvoiceResponseRow struct {
+	// The unique identifier of the invoice
+	ID int64 `json:"id"`
+	// The total amount of the invoice
+	Total float64 `json:"total"`
+	// The account id the invoice applies to
+	AccountID int64 `json:"accountId"`
+	// The status of the invoice. One of: Draft, Sent, Paid, Void
+	Status string `json:"status,omitempty"`
+	// The due date for the invoice in UTC time.
+	DueDate string `json:"dueDate,omitempty"`
+	// The date that the invoice was created in UTC time.
+	DatePaid string `json:"datePaid,omitempty"`
+	// The date to pay the invoice before in UTC time.
+	PayBefore string `json:"payBefore"`
+	// The date that the invoice was paid in UTC time.
+	InvoiceDate string `json:"invoiceDate,omitempty"`
+}
+
+// ListUsageResponse is generated from the ListUsageResponse schema.
+//
+// Usage details for a specific year and month
+type ListUsageResponse struct {
+	// The usages for the services
+	Usages []UsageResponseRow `json:"usages,omitempty"`
+	// The total cost of the usage for the month
+	Total float64 `json:"total"`
+	Month Month   `json:"month"`
+	// The year for the usage
+	Year int32 `json:"year"`
+}
+
+// ListVolumesResponseRecord is generated from the ListVolumesResponseRecord schema.
+//
+// A single record of a volume from a request to list all volumes
+type ListVolumesResponseRecord struct {
+	// The UUID of the volume
+	VolumeID string `json:"volumeId,omitempty"`
+	// The name of the volume
+	DisplayName string `json:"displayName,omitempty"`
+	// The region where the volume is located
+	Region string `json:"region,omitempty"`
+	// The size of the volume
+	Size int32 `json:"size"`
+	// The description of the volume
+	Description string `json:"description,omitempty"`
+	// The type of the volume hdd or nvme
+	VolumeType string `json:"volumeType,omitempty"`
+	// The time the volume was created
+	CreatedAt string `json:"createdAt,omitempty"`
+	// The time the volume was last updated
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	// The current status of the volume
+	Status string `json:"status,omitempty"`
+	// The attachments for the volume
+	Attachments []VolumeAttachment `json:"attachments,omitempty"`
+}
+
+// MemoryModule is generated from the MemoryModule schema.
+type MemoryModule struct {
+	Name              string `json:"name,omitempty"`
+	Manufacturer      string `json:"manufacturer,omitempty"`
+	MemoryType        string `json:"memoryType,omitempty"`
+	CapacityGB        int32  `json:"capacityGb"`
+	OperatingSpeedMhz int32  `json:"operatingSpeedMhz"`
+	PartNumber        string `json:"partNumber,omitempty"`
+	SerialNumber      string `json:"serialNumber,omitempty"`
+	Status            string `json:"status,omitempty"`
+}
+
+// MemoryPriceResponse is generated from the MemoryPriceResponse schema.
+//
+// Details about the price of a memory option
+type MemoryPriceResponse struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit,omitempty"`
+	// The monthly price for the memory option
+	MonthlyPrice float64 `json:"monthlyPrice"`
+	// The hourly price for the memory option
+	HourlyPrice float64 `json:"hourlyPrice"`
+}
+
+// MetalDevice is generated from the MetalDevice schema.
+type MetalDevice struct {
+	ID                    int32          `json:"id"`
+	Name                  string         `json:"name,omitempty"`
+	RegionID              string         `json:"regionId,omitempty"`
+	RackID                int32          `json:"rackId"`
+	RackName              string         `json:"rackName,omitempty"`
+	RackFacilityID        string         `json:"rackFacilityId,omitempty"`
+	RackPosition          float64        `json:"rackPosition"`
+	UHeight               int32          `json:"uHeight"`
+	DeviceBay             string         `json:"deviceBay,omitempty"`
+	DeviceVendor          DeviceVendor   `json:"deviceVendor"`
+	DeviceType            string         `json:"deviceType,omitempty"`
+	Serial                string         `json:"serial,omitempty"`
+	Status                string         `json:"status,omitempty"`
+	PowerStatus           string         `json:"powerStatus,omitempty"`
+	PowerTheoreticalWatts int32          `json:"powerTheoreticalWatts"`
+	CPUModel              string         `json:"cpuModel,omitempty"`
+	MemoryGB              int32          `json:"memoryGb"`
+	TierID                string         `json:"tierId,omitempty"`
+	StorageSummary        string         `json:"storageSummary,omitempty"`
+	BMCIP                 string         `json:"bmcIp,omitempty"`
+	MemoryModules         []MemoryModule `json:"memoryModules,omitempty"`
+	Children              []MetalDevice  `json:"children,omitempty"`
+	Service               *Metal         `json:"service,omitempty"`
+}
+
+// MetalTemplate is generated from the MetalTemplate schema.
+type MetalTemplate struct {
+	ID          int64               `json:"id"`
+	Created     string              `json:"created"`
+	Deleted     string              `json:"deleted,omitempty"`
+	ObjectType  string              `json:"objectType,omitempty"`
+	ProjectID   int64               `json:"projectId"`
+	DisplayName string              `json:"displayName,omitempty"`
+	CreateModel *CreateMetalRequest `json:"createModel,omitempty"`
+	CloudInit   string              `json:"cloudInit,omitempty"`
+}
+
+// Month is generated from the Month schema.
+type Month int32
+
+// NetworkPriceResponse is generated from the NetworkPriceResponse schema.
+//
+// Details about the price of a network configuration
+type NetworkPriceResponse struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit,omitempty"`
+	// The monthly price for the network configuration
+	MonthlyPrice float64 `json:"monthlyPrice"`
+	// The hourly price for the network configuration
+	HourlyPrice float64 `json:"hourlyPrice"`
+}
+
+// SearchResponseRecord is generated from the SearchResponseRecord schema.
+//
+// Single record of a service for the search response
+type SearchResponseRecord struct {
+	// ID of the service
+	ID int64 `json:"id"`
+	// The hostname of the instance
+	DisplayName string `json:"displayName,omitempty"`
+	// Text of the service type Metal, Instance, etc...
+	ServiceType string `json:"serviceType,omitempty"`
+	// The region the service is in
+	RegionID string `json:"regionId,omitempty"`
+	// Text of the current status of the host
+	Status string `json:"status,omitempty"`
+}
+
+// ServiceRenameRequest is generated from the ServiceRenameRequest schema.
+//
+// Describes a request to rename a service.
+type ServiceRenameRequest struct {
+	// The new name for the service
+	Name string `json:"name,omitempty"`
+}
+
+// ServiceType is generated from the ServiceType schema.
+type ServiceType int32
+
+// UpdateNetworkRequest is generated from the UpdateNetworkRequest schema.
+//
+// Details about what to be updated about the network
+type UpdateNetworkRequest struct {
+	// The display name of the network
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// UpdateNetworkResponseDetails is generated from the UpdateNetworkResponseDetails schema.
+//
+// Details about the update to the network
+type UpdateNetworkResponseDetails struct {
+	// The display name of the network
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// UsageResponseRow is generated from the UsageResponseRow schema.
+//
+// Usage for a service for a specific year and month.
+type UsageResponseRow struct {
+	// Id for the service
+	ServiceID int64 `json:"serviceId"`
+	// Year of the usage
+	Year  int32 `json:"year"`
+	Month Month `json:"month"`
+	// The amount of usage that occurred during the month. Units are based on the service type.
+	// For instance, for bare metal servers, this is the number of hours the service was
+	// ```active```.
+	Amount float64 `json:"amount"`
+	// The rate is the cost per unit of usage. For instance, for bare metal servers, this is the
+	// hourly rate
+	Rate float64 `json:"rate"`
+	// Total is the total cost of the usage for the month. This is calculated by multiplying the
+	// amount of usage by the rate.
+	Total float64 `json:"total"`
+	// The display name of the service as presented on the portal
+	DisplayName string `json:"displayName,omitempty"`
+	// The region that the service is located in (if applicable)
+	RegionID string `json:"regionId,omitempty"`
+}
+
+// VolumeAttachment is generated from the VolumeAttachment schema.
+//
+// Contains details about the attachment of a volume to a server
+type VolumeAttachment struct {
+	// The id of the server the volume is attached to in Openstack
+	OpenstackServerID string `json:"openstackServerId,omitempty"`
+	// The TSW id of the server the volume is attached to
+	ServerID int64 `json:"serverId"`
+	// The id for the specific attachment of the volume to the server
+	AttachmentID string `json:"attachmentId,omitempty"`
+	// The time the volume was attached to the server
+	AttachedAt string `json:"attachedAt,omitempty"`
+	// The id of the volume in the attachment
+	VolumeID string `json:"volumeId,omitempty"`
+	// The path of the device on the instance
+	Device string `json:"device,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+// ListInstancesParams holds the query parameters of ListInstances. Zero values are omitted.
+type ListInstancesParams struct {
+	// Filter for services of a status (Optional) Available values are ```Active```, ```Pending```,
+	// ```Suspended```, ```Terminated```
+	Status string
+	// Filter for services in a region (Optional) May be comma separated to include multiple regions.
+	// Available values can be retrieved from the Regions endpoint.
+	Region string
+	// Filter for services of a certain tier (Optional) May be comma separated to include multiple tiers.
+	// Available values can be retrieved from the tiers endpoint.
+	Tier string
+	// Filter for services with a tag (Optional) May be comma separated to include multiple tags.
+	// Available values can be retrieved from the tags endpoint.
+	Tag string
+	// The number of services to return (Optional: default 100)
+	Limit int32
+	// The number of services to skip (Optional: default: 0)
+	Skip int32
+	// The id of the project to filter services by
+	ProjectID int32
+	// Specifies whether the tier is a Compute or GPU type
+	MetalTierType MetalTierType
+}
+
+// ListInstances calls GET /v2/Instance.
+//
+// List Instances
+func (a *API) ListInstances(ctx context.Context, params ListInstancesParams) (*Result[[]CloudService], error) {
+	resp := &Result[[]CloudService]{}
+	var opts []RequestOption
+	if params.Status != "" {
+		opts = append(opts, WithQueryParam("Status", fmt.Sprint(params.Status)))
+	}
+	if params.Region != "" {
+		opts = append(opts, WithQueryParam("Region", fmt.Sprint(params.Region)))
+	}
+	if params.Tier != "" {
+		opts = append(opts, WithQueryParam("Tier", fmt.Sprint(params.Tier)))
+	}
+	if params.Tag != "" {
+		opts = append(opts, WithQueryParam("Tag", fmt.Sprint(params.Tag)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("Limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("Skip", fmt.Sprint(params.Skip)))
+	}
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("ProjectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.MetalTierType != "" {
+		opts = append(opts, WithQueryParam("MetalTierType", fmt.Sprint(params.MetalTierType)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListInstances",
+		Method:  http.MethodGet,
+		Path:    "Instance",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateInstance calls POST /v2/Instance.
+//
+// Create Instance
+func (a *API) CreateInstance(ctx context.Context, body *CreateInstanceRequest) (*Result[CloudService], error) {
+	resp := &Result[CloudService]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "CreateInstance",
+		Method: http.MethodPost,
+		Path:   "Instance",
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetInstance calls GET /v2/Instance/{id}.
+//
+// Get Instance
+func (a *API) GetInstance(ctx context.Context, id int64) (*Result[CloudService], error) {
+	resp := &Result[CloudService]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetInstance",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Instance/%d", id),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteInstance calls DELETE /v2/Instance/{id}.
+//
+// Terminate Instance
+func (a *API) DeleteInstance(ctx context.Context, id int64) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "DeleteInstance",
+		Method: http.MethodDelete,
+		Path:   fmt.Sprintf("Instance/%d", id),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListInstanceTiers calls GET /v2/Instance/tiers.
+//
+// List Tiers
+func (a *API) ListInstanceTiers(ctx context.Context) (*Result[[]CloudTier], error) {
+	resp := &Result[[]CloudTier]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "ListInstanceTiers",
+		Method: http.MethodGet,
+		Path:   "Instance/tiers",
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendInstancePowerCommandParams holds the query parameters of SendInstancePowerCommand. Zero values are omitted.
+type SendInstancePowerCommandParams struct {
+	Command PowerCommand
+}
+
+// SendInstancePowerCommand calls POST /v2/Instance/{id}/PowerCommand.
+//
+// Send Power Command
+func (a *API) SendInstancePowerCommand(ctx context.Context, id int64, params SendInstancePowerCommandParams) (*Result[CloudService], error) {
+	resp := &Result[CloudService]{}
+	var opts []RequestOption
+	if params.Command != "" {
+		opts = append(opts, WithQueryParam("command", fmt.Sprint(params.Command)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "SendInstancePowerCommand",
+		Method:  http.MethodPost,
+		Path:    fmt.Sprintf("Instance/%d/PowerCommand", id),
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListInstanceNetworksParams holds the query parameters of ListInstanceNetworks. Zero values are omitted.
+type ListInstanceNetworksParams struct {
+	// The project id that the instance is under
+	ProjectID int64
+	// The ID for the instance
+	InstanceID int64
+	// *Optional* - filter to only return that many volume records. Max of 100.
+	Limit int32
+	// *Optional* - filter to skip that many volume records to support paging.
+	Skip int32
+}
+
+// ListInstanceNetworks calls GET /v2/Instance/{instanceId}/networks.
+//
+// List Networks
+func (a *API) ListInstanceNetworks(ctx context.Context, instanceID string, params ListInstanceNetworksParams) (*Result[[]Details], error) {
+	resp := &Result[[]Details]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("ProjectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.InstanceID != 0 {
+		opts = append(opts, WithQueryParam("InstanceId", fmt.Sprint(params.InstanceID)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("Limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("Skip", fmt.Sprint(params.Skip)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListInstanceNetworks",
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("Instance/%s/networks", url.PathEscape(instanceID)),
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AttachInstanceNetworkParams holds the query parameters of AttachInstanceNetwork. Zero values are omitted.
+type AttachInstanceNetworkParams struct {
+	ProjectID int64
+}
+
+// AttachInstanceNetwork calls POST /v2/Instance/{instanceId}/networks/attach.
+//
+// Attach Network
+func (a *API) AttachInstanceNetwork(ctx context.Context, instanceID int64, params AttachInstanceNetworkParams, body *AttachToNetworkRequest) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "AttachInstanceNetwork",
+		Method:  http.MethodPost,
+		Path:    fmt.Sprintf("Instance/%d/networks/attach", instanceID),
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DetachInstanceNetworkParams holds the query parameters of DetachInstanceNetwork. Zero values are omitted.
+type DetachInstanceNetworkParams struct {
+	ProjectID int64
+}
+
+// DetachInstanceNetwork calls POST /v2/Instance/{instanceId}/networks/detach.
+//
+// Detach network
+func (a *API) DetachInstanceNetwork(ctx context.Context, instanceID int64, params DetachInstanceNetworkParams, body *DetachFromNetworkRequest) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "DetachInstanceNetwork",
+		Method:  http.MethodPost,
+		Path:    fmt.Sprintf("Instance/%d/networks/detach", instanceID),
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListImages calls GET /v2/Image.
+//
+// List all images available to the project
+func (a *API) ListImages(ctx context.Context) (*Result[[]Image], error) {
+	resp := &Result[[]Image]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "ListImages",
+		Method: http.MethodGet,
+		Path:   "Image",
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetInvoice calls GET /v2/Invoice/{invoiceId}.
+//
+// Get Invoice
+func (a *API) GetInvoice(ctx context.Context, invoiceID int64) (*Result[GetInvoiceResponseDetails], error) {
+	resp := &Result[GetInvoiceResponseDetails]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetInvoice",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Invoice/%d", invoiceID),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListInvoicesParams holds the query parameters of ListInvoices. Zero values are omitted.
+type ListInvoicesParams struct {
+	// The number of invoices to skip (for pagination)
+	Skip int32
+	// The maximum number of invoices to return (for pagination)
+	Limit int32
+}
+
+// ListInvoices calls GET /v2/Invoice.
+//
+// List Invoices
+func (a *API) ListInvoices(ctx context.Context, params ListInvoicesParams) (*Result[[]InvoiceResponseRow], error) {
+	resp := &Result[[]InvoiceResponseRow]{}
+	var opts []RequestOption
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("skip", fmt.Sprint(params.Skip)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("limit", fmt.Sprint(params.Limit)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListInvoices",
+		Method:  http.MethodGet,
+		Path:    "Invoice",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListMetalParams holds the query parameters of ListMetal. Zero values are omitted.
+type ListMetalParams struct {
+	// Filter for services of a status (Optional) Available values are ```Active```, ```Pending```,
+	// ```Suspended```, ```Terminated```
+	Status string
+	// Filter for services in a region (Optional) May be comma separated to include multiple regions.
+	// Available values can be retrieved from the Regions endpoint.
+	Region string
+	// Filter for services of a certain tier (Optional) May be comma separated to include multiple tiers.
+	// Available values can be retrieved from the tiers endpoint.
+	Tier string
+	// Filter for services with a tag (Optional) May be comma separated to include multiple tags.
+	// Available values can be retrieved from the tags endpoint.
+	Tag string
+	// The number of services to return (Optional: default 100)
+	Limit int32
+	// The number of services to skip (Optional: default: 0)
+	Skip int32
+	// The id of the project to filter services by
+	ProjectID int32
+	// Specifies whether the tier is a Compute or GPU type
+	MetalTierType MetalTierType
+}
+
+// ListMetal calls GET /v2/Metal.
+//
+// List Metal Services
+func (a *API) ListMetal(ctx context.Context, params ListMetalParams) (*Result[[]Metal], error) {
+	resp := &Result[[]Metal]{}
+	var opts []RequestOption
+	if params.Status != "" {
+		opts = append(opts, WithQueryParam("Status", fmt.Sprint(params.Status)))
+	}
+	if params.Region != "" {
+		opts = append(opts, WithQueryParam("Region", fmt.Sprint(params.Region)))
+	}
+	if params.Tier != "" {
+		opts = append(opts, WithQueryParam("Tier", fmt.Sprint(params.Tier)))
+	}
+	if params.Tag != "" {
+		opts = append(opts, WithQueryParam("Tag", fmt.Sprint(params.Tag)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("Limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("Skip", fmt.Sprint(params.Skip)))
+	}
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("ProjectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.MetalTierType != "" {
+		opts = append(opts, WithQueryParam("MetalTierType", fmt.Sprint(params.MetalTierType)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListMetal",
+		Method:  http.MethodGet,
+		Path:    "Metal",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateMetal calls POST /v2/Metal.
+//
+// Create Metal Service
+func (a *API) CreateMetal(ctx context.Context, body *CreateBareMetalRequest) (*Result[Metal], error) {
+	resp := &Result[Metal]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "CreateMetal",
+		Method: http.MethodPost,
+		Path:   "Metal",
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListMetalTemplates calls GET /v2/Metal/templates.
+//
+// List Templates
+func (a *API) ListMetalTemplates(ctx context.Context) (*Result[[]MetalTemplate], error) {
+	resp := &Result[[]MetalTemplate]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "ListMetalTemplates",
+		Method: http.MethodGet,
+		Path:   "Metal/templates",
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListMetalTiersParams holds the query parameters of ListMetalTiers. Zero values are omitted.
+type ListMetalTiersParams struct {
+	MetalTierType MetalTierType
+}
+
+// ListMetalTiers calls GET /v2/Metal/tiers.
+//
+// List Tiers
+func (a *API) ListMetalTiers(ctx context.Context, params ListMetalTiersParams) (*Result[[]MetalTier], error) {
+	resp := &Result[[]MetalTier]{}
+	var opts []RequestOption
+	if params.MetalTierType != "" {
+		opts = append(opts, WithQueryParam("metalTierType", fmt.Sprint(params.MetalTierType)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListMetalTiers",
+		Method:  http.MethodGet,
+		Path:    "Metal/tiers",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReinstallMetal calls POST /v2/Metal/{id}/Reinstall.
+//
+// Reinstall Metal Service
+func (a *API) ReinstallMetal(ctx context.Context, id int64, body *ReinstallMetalRequest) (*Result[Metal], error) {
+	resp := &Result[Metal]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "ReinstallMetal",
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("Metal/%d/Reinstall", id),
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMetal calls GET /v2/Metal/{id}.
+//
+// Get Metal Service
+func (a *API) GetMetal(ctx context.Context, id int64) (*Result[Metal], error) {
+	resp := &Result[Metal]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetMetal",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Metal/%d", id),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendMetalPowerCommandParams holds the query parameters of SendMetalPowerCommand. Zero values are omitted.
+type SendMetalPowerCommandParams struct {
+	// The power command to send
+	Command PowerCommand
+}
+
+// SendMetalPowerCommand calls POST /v2/Metal/{id}/PowerCommand.
+//
+// Send Power Command
+func (a *API) SendMetalPowerCommand(ctx context.Context, id int64, params SendMetalPowerCommandParams) (*Result[Metal], error) {
+	resp := &Result[Metal]{}
+	var opts []RequestOption
+	if params.Command != "" {
+		opts = append(opts, WithQueryParam("command", fmt.Sprint(params.Command)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "SendMetalPowerCommand",
+		Method:  http.MethodPost,
+		Path:    fmt.Sprintf("Metal/%d/PowerCommand", id),
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMetalLogs calls GET /v2/Metal/{id}/Logs.
+//
+// Get Logs
+func (a *API) GetMetalLogs(ctx context.Context, id int64) (*Result[[]LogMessage], error) {
+	resp := &Result[[]LogMessage]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetMetalLogs",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Metal/%d/Logs", id),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMetalAvailabilityParams holds the query parameters of GetMetalAvailability. Zero values are omitted.
+type GetMetalAvailabilityParams struct {
+	// The region in which we're querying for availability
+	Region string
+	// The id of the project we're querying for
+	ProjectID int64
+	// Specifies whether you're querying for Compute or GPU metal services
+	MetalTierType MetalTierType
+}
+
+// GetMetalAvailability calls GET /v2/Metal/Availability.
+//
+// Get Availability
+func (a *API) GetMetalAvailability(ctx context.Context, params GetMetalAvailabilityParams) (*Result[[]MetalConfiguration], error) {
+	resp := &Result[[]MetalConfiguration]{}
+	var opts []RequestOption
+	if params.Region != "" {
+		opts = append(opts, WithQueryParam("Region", fmt.Sprint(params.Region)))
+	}
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("ProjectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.MetalTierType != "" {
+		opts = append(opts, WithQueryParam("MetalTierType", fmt.Sprint(params.MetalTierType)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "GetMetalAvailability",
+		Method:  http.MethodGet,
+		Path:    "Metal/Availability",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RenameMetal calls POST /v2/Metal/{id}/rename.
+//
+// Rename
+func (a *API) RenameMetal(ctx context.Context, id int64, body *ServiceRenameRequest) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "RenameMetal",
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("Metal/%d/rename", id),
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetNetwork calls GET /v2/Network/{networkId}.
+//
+// Get Network
+func (a *API) GetNetwork(ctx context.Context, networkID string) (*Result[GetNetworkResponseDetails], error) {
+	resp := &Result[GetNetworkResponseDetails]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetNetwork",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("Network/%s", url.PathEscape(networkID)),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListNetworksParams holds the query parameters of ListNetworks. Zero values are omitted.
+type ListNetworksParams struct {
+	// The id for the project that the networks are in
+	ProjectID int64
+	// *Optional* - Filter down the networks to a specific region. If more than one region is
+	// desired, then provide the region ids in the form of a comma separated list. If you
+	// would like to query across all regions, then leave this field blank.
+	RegionID string
+	// *Optional* - filter to only return that many volume records. Max of 100.
+	Limit int32
+	// *Optional* - filter to skip that many volume records to support paging.
+	Skip int32
+}
+
+// ListNetworks calls GET /v2/Network.
+//
+// List Networks
+func (a *API) ListNetworks(ctx context.Context, params ListNetworksParams) (*Result[GetNetworkResponseDetails], error) {
+	resp := &Result[GetNetworkResponseDetails]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("ProjectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.RegionID != "" {
+		opts = append(opts, WithQueryParam("RegionId", fmt.Sprint(params.RegionID)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("Limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("Skip", fmt.Sprint(params.Skip)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListNetworks",
+		Method:  http.MethodGet,
+		Path:    "Network",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateNetworkParams holds the query parameters of CreateNetwork. Zero values are omitted.
+type CreateNetworkParams struct {
+	ProjectID int64
+}
+
+// CreateNetwork calls POST /v2/Network.
+//
+// Create Network
+func (a *API) CreateNetwork(ctx context.Context, params CreateNetworkParams, body *CreateNetworkRequest) (*Result[CreateNetworkResponseDetails], error) {
+	resp := &Result[CreateNetworkResponseDetails]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "CreateNetwork",
+		Method:  http.MethodPost,
+		Path:    "Network",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateNetworkParams holds the query parameters of UpdateNetwork. Zero values are omitted.
+type UpdateNetworkParams struct {
+	// The ID of the network to update
+	NetworkID string
+}
+
+// UpdateNetwork calls PUT /v2/Network.
+//
+// Update Network
+func (a *API) UpdateNetwork(ctx context.Context, params UpdateNetworkParams, body *UpdateNetworkRequest) (*Result[UpdateNetworkResponseDetails], error) {
+	resp := &Result[UpdateNetworkResponseDetails]{}
+	var opts []RequestOption
+	if params.NetworkID != "" {
+		opts = append(opts, WithQueryParam("networkId", fmt.Sprint(params.NetworkID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "UpdateNetwork",
+		Method:  http.MethodPut,
+		Path:    "Network",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteNetworkParams holds the query parameters of DeleteNetwork. Zero values are omitted.
+type DeleteNetworkParams struct {
+	// The ID of the network to delete
+	NetworkID string
+}
+
+// DeleteNetwork calls DELETE /v2/Network.
+//
+// Delete Network
+func (a *API) DeleteNetwork(ctx context.Context, params DeleteNetworkParams) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	var opts []RequestOption
+	if params.NetworkID != "" {
+		opts = append(opts, WithQueryParam("networkId", fmt.Sprint(params.NetworkID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "DeleteNetwork",
+		Method:  http.MethodDelete,
+		Path:    "Network",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CalculatePrice calls POST /v2/Price/Calculate.
+//
+// Calculate Price
+func (a *API) CalculatePrice(ctx context.Context, body *CalculatePriceRequest) (*CalculatePriceResponse, error) {
+	resp := &CalculatePriceResponse{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "CalculatePrice",
+		Method: http.MethodPost,
+		Path:   "Price/Calculate",
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SearchParams holds the query parameters of Search. Zero values are omitted.
+type SearchParams struct {
+	// The search query.
+	Query string
+	// The number of services to skip.
+	Skip int32
+	// The number of services to take.
+	Take int32
+}
+
+// Search calls GET /v2/Search.
+//
+// Search
+func (a *API) Search(ctx context.Context, params SearchParams) (*Result[[]SearchResponseRecord], error) {
+	resp := &Result[[]SearchResponseRecord]{}
+	var opts []RequestOption
+	if params.Query != "" {
+		opts = append(opts, WithQueryParam("query", fmt.Sprint(params.Query)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("skip", fmt.Sprint(params.Skip)))
+	}
+	if params.Take != 0 {
+		opts = append(opts, WithQueryParam("take", fmt.Sprint(params.Take)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "Search",
+		Method:  http.MethodGet,
+		Path:    "Search",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListSshKeys calls GET /v2/SshKey.
+//
+// List SSH Keys
+func (a *API) ListSshKeys(ctx context.Context) (*Result[[]SSHKey], error) {
+	resp := &Result[[]SSHKey]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "ListSshKeys",
+		Method: http.MethodGet,
+		Path:   "SshKey",
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateSshKey calls POST /v2/SshKey.
+//
+// Create SSH Key
+func (a *API) CreateSshKey(ctx context.Context, body *SSHKey) (*Result[SSHKey], error) {
+	resp := &Result[SSHKey]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "CreateSshKey",
+		Method: http.MethodPost,
+		Path:   "SshKey",
+		Body:   body,
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetSshKey calls GET /v2/SshKey/{id}.
+//
+// Get SSH Key
+func (a *API) GetSshKey(ctx context.Context, id int64) (*Result[SSHKey], error) {
+	resp := &Result[SSHKey]{}
+	err := a.c.do(ctx, &Operation{
+		Name:   "GetSshKey",
+		Method: http.MethodGet,
+		Path:   fmt.Sprintf("SshKey/%d", id),
+		Result: resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListUsageParams holds the query parameters of ListUsage. Zero values are omitted.
+type ListUsageParams struct {
+	// The year to list usages for.
+	Year int32
+	// The month to list usages for.
+	Month int32
+	// The project ID to list usages for.
+	ProjectID int64
+	// The number of usages to skip.
+	Skip int32
+	// The number of usages to take.
+	Limit int32
+}
+
+// ListUsage calls GET /v2/Usage.
+//
+// List usages
+func (a *API) ListUsage(ctx context.Context, params ListUsageParams) (*Result[ListUsageResponse], error) {
+	resp := &Result[ListUsageResponse]{}
+	var opts []RequestOption
+	if params.Year != 0 {
+		opts = append(opts, WithQueryParam("year", fmt.Sprint(params.Year)))
+	}
+	if params.Month != 0 {
+		opts = append(opts, WithQueryParam("month", fmt.Sprint(params.Month)))
+	}
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("skip", fmt.Sprint(params.Skip)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("limit", fmt.Sprint(params.Limit)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListUsage",
+		Method:  http.MethodGet,
+		Path:    "Usage",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUsageParams holds the query parameters of GetUsage. Zero values are omitted.
+type GetUsageParams struct {
+	// The year to get usage for.
+	Year int32
+	// The month to get usage for.
+	Month int32
+	// The project ID to get usage for.
+	ProjectID int64
+}
+
+// GetUsage calls GET /v2/Usage/{serviceId}.
+//
+// Get Usage
+func (a *API) GetUsage(ctx context.Context, serviceID int64, params GetUsageParams) (*Result[UsageResponseRow], error) {
+	resp := &Result[UsageResponseRow]{}
+	var opts []RequestOption
+	if params.Year != 0 {
+		opts = append(opts, WithQueryParam("year", fmt.Sprint(params.Year)))
+	}
+	if params.Month != 0 {
+		opts = append(opts, WithQueryParam("month", fmt.Sprint(params.Month)))
+	}
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "GetUsage",
+		Method:  http.MethodGet,
+		Path:    fmt.Sprintf("Usage/%d", serviceID),
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListVolumesParams holds the query parameters of ListVolumes. Zero values are omitted.
+type ListVolumesParams struct {
+	// The project ID to list volumes for.
+	ProjectID int64
+	// The region ID to list volumes for. If not specified, volumes from all regions will be listed.
+	RegionID string
+	// The number of volumes to take. If not specified, all volumes will be listed.
+	Limit int32
+	// The number of volumes to skip. If not specified, no volumes will be skipped.
+	Skip int32
+}
+
+// ListVolumes calls GET /v2/Volume.
+//
+// List Volumes
+func (a *API) ListVolumes(ctx context.Context, params ListVolumesParams) (*Result[[]ListVolumesResponseRecord], error) {
+	resp := &Result[[]ListVolumesResponseRecord]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.RegionID != "" {
+		opts = append(opts, WithQueryParam("regionId", fmt.Sprint(params.RegionID)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("skip", fmt.Sprint(params.Skip)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListVolumes",
+		Method:  http.MethodGet,
+		Path:    "Volume",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateVolumeParams holds the query parameters of CreateVolume. Zero values are omitted.
+type CreateVolumeParams struct {
+	// The project ID to create the volume in.
+	ProjectID int64
+}
+
+// CreateVolume calls POST /v2/Volume.
+//
+// Create Volume
+func (a *API) CreateVolume(ctx context.Context, params CreateVolumeParams, body *CreateVolumeRequest) (*Result[CreateVolumeResponse], error) {
+	resp := &Result[CreateVolumeResponse]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "CreateVolume",
+		Method:  http.MethodPost,
+		Path:    "Volume",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteVolumeParams holds the query parameters of DeleteVolume. Zero values are omitted.
+type DeleteVolumeParams struct {
+	// The project ID to delete the volume in.
+	ProjectID int64
+}
+
+// DeleteVolume calls DELETE /v2/Volume.
+//
+// Delete Volume
+func (a *API) DeleteVolume(ctx context.Context, params DeleteVolumeParams, body *DeleteVolumeRequest) (*Result[struct{}], error) {
+	resp := &Result[struct{}]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "DeleteVolume",
+		Method:  http.MethodDelete,
+		Path:    "Volume",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListAttachableVolumesParams holds the query parameters of ListAttachableVolumes. Zero values are omitted.
+type ListAttachableVolumesParams struct {
+	// The project ID to list attachable volumes for.
+	ProjectID int64
+	// The instance ID to list attachable volumes for.
+	InstanceID int64
+	// The number of volumes to take. If not specified, all volumes will be listed.
+	Limit int32
+	// The number of volumes to skip. If not specified, no volumes will be skipped.
+	Skip int32
+}
+
+// ListAttachableVolumes calls GET /v2/Volume/list-attachable.
+//
+// List Attachable Volumes
+func (a *API) ListAttachableVolumes(ctx context.Context, params ListAttachableVolumesParams) (*Result[[]ListVolumesResponseRecord], error) {
+	resp := &Result[[]ListVolumesResponseRecord]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.InstanceID != 0 {
+		opts = append(opts, WithQueryParam("instanceId", fmt.Sprint(params.InstanceID)))
+	}
+	if params.Limit != 0 {
+		opts = append(opts, WithQueryParam("limit", fmt.Sprint(params.Limit)))
+	}
+	if params.Skip != 0 {
+		opts = append(opts, WithQueryParam("skip", fmt.Sprint(params.Skip)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListAttachableVolumes",
+		Method:  http.MethodGet,
+		Path:    "Volume/list-attachable",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListAttachedVolumesParams holds the query parameters of ListAttachedVolumes. Zero values are omitted.
+type ListAttachedVolumesParams struct {
+	// The project ID to list attached volumes for.
+	ProjectID int64
+	// The instance ID to list attached volumes for.
+	InstanceID int64
+}
+
+// ListAttachedVolumes calls GET /v2/Volume/list-attached.
+//
+// List Attached Volumes
+func (a *API) ListAttachedVolumes(ctx context.Context, params ListAttachedVolumesParams) (*Result[[]ListVolumesResponseRecord], error) {
+	resp := &Result[[]ListVolumesResponseRecord]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	if params.InstanceID != 0 {
+		opts = append(opts, WithQueryParam("instanceId", fmt.Sprint(params.InstanceID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ListAttachedVolumes",
+		Method:  http.MethodGet,
+		Path:    "Volume/list-attached",
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AttachVolumeParams holds the query parameters of AttachVolume. Zero values are omitted.
+type AttachVolumeParams struct {
+	// The project ID to attach the volume in.
+	ProjectID int64
+}
+
+// AttachVolume calls PUT /v2/Volume/attach.
+//
+// Attach volume
+func (a *API) AttachVolume(ctx context.Context, params AttachVolumeParams, body *AttachVolumeRequest) (*Result[AttachVolumeResponse], error) {
+	resp := &Result[AttachVolumeResponse]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "AttachVolume",
+		Method:  http.MethodPut,
+		Path:    "Volume/attach",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DetachVolumeParams holds the query parameters of DetachVolume. Zero values are omitted.
+type DetachVolumeParams struct {
+	ProjectID int64
+}
+
+// DetachVolume calls PUT /v2/Volume/detach.
+//
+// Detach Volume
+func (a *API) DetachVolume(ctx context.Context, params DetachVolumeParams, body *DetachVolumeRequest) (*Result[DetachVolumeResponse], error) {
+	resp := &Result[DetachVolumeResponse]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "DetachVolume",
+		Method:  http.MethodPut,
+		Path:    "Volume/detach",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ExtendVolumeParams holds the query parameters of ExtendVolume. Zero values are omitted.
+type ExtendVolumeParams struct {
+	// The project ID to extend the volume in.
+	ProjectID int64
+}
+
+// ExtendVolume calls PUT /v2/Volume/extend.
+//
+// Extend volume
+func (a *API) ExtendVolume(ctx context.Context, params ExtendVolumeParams, body *ExtendVolumeRequest) (*Result[ExtendVolumeResponse], error) {
+	resp := &Result[ExtendVolumeResponse]{}
+	var opts []RequestOption
+	if params.ProjectID != 0 {
+		opts = append(opts, WithQueryParam("projectId", fmt.Sprint(params.ProjectID)))
+	}
+	err := a.c.do(ctx, &Operation{
+		Name:    "ExtendVolume",
+		Method:  http.MethodPut,
+		Path:    "Volume/extend",
+		Body:    body,
+		Options: opts,
+		Result:  resp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}